@@ -0,0 +1,75 @@
+// Command sessionserver runs a local stand-in for Mojang's session server,
+// so online mode logins can be tested without Mojang accounts.
+//
+// Point mc-srv at it with -session-server-url, e.g.
+//
+//	sessionserver -addr localhost:8081 -profile 'Notch,069a79f4-44e9-4726-a5be-fca90e38aaf5,token'
+//	mc-srv -session-server-url http://localhost:8081
+//
+// Clients join through it with the profile's access token,
+// e.g. with authtest.Join.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/auth/authtest"
+	"github.com/google/uuid"
+)
+
+var (
+	addrFlag     = flag.String("addr", "localhost:8081", "Address to serve the session server on.")
+	profilesFlag []profile
+)
+
+// profile is a profile that can join servers with an access token.
+type profile struct {
+	accessToken string
+	profile     auth.Profile
+}
+
+func init() {
+	flag.Func("profile", "Profile that can join servers, as name,uuid,access-token. May be repeated.", func(s string) error {
+		p, err := parseProfile(s)
+		if err != nil {
+			return err
+		}
+		profilesFlag = append(profilesFlag, p)
+		return nil
+	})
+}
+
+// parseProfile parses a profile in the name,uuid,access-token format.
+func parseProfile(s string) (profile, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 3 {
+		return profile{}, fmt.Errorf("profile %q isn't name,uuid,access-token", s)
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return profile{}, fmt.Errorf("failed to parse UUID of profile %q: %w", s, err)
+	}
+	if parts[0] == "" || parts[2] == "" {
+		return profile{}, errors.New("profile name and access token must be set")
+	}
+	return profile{accessToken: parts[2], profile: auth.Profile{ID: id, Name: parts[0]}}, nil
+}
+
+func main() {
+	flag.Parse()
+
+	s := authtest.NewSessionServer()
+	for _, p := range profilesFlag {
+		s.AddProfile(p.accessToken, p.profile)
+		log.Printf("Added profile %s (%s)", p.profile.Name, p.profile.ID)
+	}
+
+	log.Printf("Serving session server on http://%s", *addrFlag)
+	log.Fatal(http.ListenAndServe(*addrFlag, s))
+}
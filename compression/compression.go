@@ -0,0 +1,217 @@
+// Package compression implements the compressed packet format.
+// https://wiki.vg/Protocol#With_compression
+//
+// Packets are written and read in the uncompressed format
+// (Length, Packet ID, Data) by the rest of the server;
+// the Writer and Reader in this package translate between that format
+// and the compressed format (Packet Length, Data Length, zlib body)
+// on the wire, the same way the crypto package wraps streams for encryption.
+package compression
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/write"
+)
+
+const (
+	// Disabled is the threshold value that disables compression.
+	Disabled = -1
+
+	// maxPacketLength is the maximum length of a packet on the wire,
+	// the largest a 3-byte VarInt can hold (2^21 - 1).
+	maxPacketLength = 1<<21 - 1
+	// maxDataLength is the maximum uncompressed length of a packet
+	// allowed by the Notchian client and server (8 MiB).
+	maxDataLength = 8 << 20
+)
+
+var (
+	errIncompleteVarInt = errors.New("incomplete varint")
+	errVarIntTooBig     = errors.New("varint is too big")
+)
+
+// NewWriter wraps an io.Writer, compressing packets written to it.
+// Packets with an uncompressed length of at least threshold
+// are zlib-compressed, smaller ones are sent with a Data Length of 0.
+func NewWriter(w io.Writer, threshold int) *Writer {
+	return &Writer{w: w, threshold: threshold}
+}
+
+// Writer compresses uncompressed-format packets written to it
+// and writes them to the underlying writer in the compressed format.
+//
+// Data is buffered until a complete packet has been written.
+// This is not thread safe.
+type Writer struct {
+	w         io.Writer
+	threshold int
+	buf       []byte
+}
+
+// Write writes uncompressed-format packet bytes to the writer.
+// Any complete packets in the buffered data are compressed and written
+// to the underlying writer.
+func (cw *Writer) Write(b []byte) (int, error) {
+	cw.buf = append(cw.buf, b...)
+
+	for {
+		length, n, err := peekVarInt(cw.buf)
+		if errors.Is(err, errIncompleteVarInt) {
+			return len(b), nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read packet length: %w", err)
+		}
+		if len(cw.buf) < n+int(length) {
+			return len(b), nil
+		}
+
+		body := cw.buf[n : n+int(length)]
+		if err := cw.writePacket(body); err != nil {
+			return 0, err
+		}
+		cw.buf = cw.buf[n+int(length):]
+	}
+}
+
+// writePacket writes the packet ID and data in body as a compressed packet.
+func (cw *Writer) writePacket(body []byte) error {
+	var payload bytes.Buffer
+	dataLength := int32(0)
+	if len(body) >= cw.threshold {
+		dataLength = int32(len(body))
+		zw := zlib.NewWriter(&payload)
+		if _, err := zw.Write(body); err != nil {
+			return fmt.Errorf("failed to compress packet: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to finish compressing packet: %w", err)
+		}
+	} else {
+		payload.Write(body)
+	}
+
+	var buf bytes.Buffer
+	packetLength := int32(write.VarIntLen(dataLength) + payload.Len())
+	if err := write.VarInt(&buf, packetLength); err != nil {
+		return fmt.Errorf("failed to write packet length (%d): %w", packetLength, err)
+	}
+	if err := write.VarInt(&buf, dataLength); err != nil {
+		return fmt.Errorf("failed to write data length (%d): %w", dataLength, err)
+	}
+	buf.Write(payload.Bytes())
+
+	if err := write.Bytes(cw.w, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write compressed packet: %w", err)
+	}
+	return nil
+}
+
+// NewReader wraps an io.Reader, decompressing packets read from it.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: r}
+}
+
+// Reader reads compressed-format packets from the underlying reader
+// and returns them in the uncompressed format.
+//
+// This is not thread safe.
+type Reader struct {
+	r   io.Reader
+	buf bytes.Buffer
+}
+
+// Read reads decompressed packet bytes into p.
+// A compressed packet is only read from the underlying reader
+// once all previously decompressed bytes have been consumed.
+func (cr *Reader) Read(p []byte) (int, error) {
+	if cr.buf.Len() == 0 {
+		if err := cr.readPacket(); err != nil {
+			return 0, err
+		}
+	}
+	return cr.buf.Read(p)
+}
+
+// readPacket reads a single compressed packet into the buffer,
+// in the uncompressed format.
+func (cr *Reader) readPacket() error {
+	packetLength, err := read.VarInt(cr.r)
+	if errors.Is(err, io.EOF) {
+		// Readers must return EOF itself, not wrapped.
+		return io.EOF
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read packet length: %w", err)
+	}
+	if packetLength == 0 {
+		return errors.New("packet length is 0")
+	}
+	// The length is checked before anything is allocated for the packet,
+	// so a client can't make the server allocate more than it sends.
+	if packetLength < 0 || packetLength > maxPacketLength {
+		return fmt.Errorf("packet length %d out of bounds (max=%d)", packetLength, maxPacketLength)
+	}
+
+	dataLength, err := read.VarInt(cr.r)
+	if err != nil {
+		return fmt.Errorf("failed to read data length: %w", err)
+	}
+	if dataLength < 0 || dataLength > maxDataLength {
+		return fmt.Errorf("data length %d out of bounds (max=%d)", dataLength, maxDataLength)
+	}
+
+	payloadLength := int(packetLength) - write.VarIntLen(dataLength)
+	if payloadLength < 0 {
+		return fmt.Errorf("packet length %d is shorter than its data length field", packetLength)
+	}
+	payload := make([]byte, payloadLength)
+	if _, err := io.ReadFull(cr.r, payload); err != nil {
+		return fmt.Errorf("failed to read packet payload: %w", err)
+	}
+
+	body := payload
+	if dataLength != 0 {
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to create zlib reader: %w", err)
+		}
+		defer zr.Close()
+		body, err = io.ReadAll(io.LimitReader(zr, int64(dataLength)+1))
+		if err != nil {
+			return fmt.Errorf("failed to decompress packet: %w", err)
+		}
+		if len(body) != int(dataLength) {
+			return fmt.Errorf("decompressed packet is %d bytes, expected %d", len(body), dataLength)
+		}
+	}
+
+	if err := write.VarInt(&cr.buf, int32(len(body))); err != nil {
+		return fmt.Errorf("failed to write packet length: %w", err)
+	}
+	cr.buf.Write(body)
+	return nil
+}
+
+// peekVarInt decodes a VarInt from the start of b
+// without consuming it, returning the value and its length in bytes.
+func peekVarInt(b []byte) (val int32, n int, err error) {
+	var pos uint
+	for i, c := range b {
+		val |= int32(c&0x7F) << pos
+		if c&0x80 == 0 {
+			return val, i + 1, nil
+		}
+		pos += 7
+		if pos >= 32 {
+			return 0, 0, errVarIntTooBig
+		}
+	}
+	return 0, 0, errIncompleteVarInt
+}
@@ -0,0 +1,228 @@
+package compression_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc      string
+		threshold int
+		input     []byte
+		want      []byte
+	}{
+		{
+			desc:      "below threshold",
+			threshold: 256,
+			input:     []byte{0x03, 0x01, 0xaa, 0xbb},
+			want:      []byte{0x04, 0x00, 0x01, 0xaa, 0xbb},
+		},
+		{
+			desc:      "multiple packets in one write",
+			threshold: 256,
+			input:     []byte{0x01, 0x02, 0x02, 0x03, 0xcc},
+			want:      []byte{0x02, 0x00, 0x02, 0x03, 0x00, 0x03, 0xcc},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			w := compression.NewWriter(&out, tc.threshold)
+			if _, err := w.Write(tc.input); err != nil {
+				t.Fatalf("Write() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, out.Bytes()); diff != "" {
+				t.Errorf("Write() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriterPartialWrites(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	w := compression.NewWriter(&out, 256)
+
+	for _, b := range []byte{0x03, 0x01, 0xaa, 0xbb} {
+		if _, err := w.Write([]byte{b}); err != nil {
+			t.Fatalf("Write() unexpected err: %v", err)
+		}
+	}
+
+	want := []byte{0x04, 0x00, 0x01, 0xaa, 0xbb}
+	if diff := cmp.Diff(want, out.Bytes()); diff != "" {
+		t.Errorf("Write() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	large := bytes.Repeat([]byte("mc-srv"), 100)
+
+	tests := []struct {
+		desc      string
+		threshold int
+		packets   [][]byte
+	}{
+		{
+			desc:      "uncompressed",
+			threshold: 256,
+			packets:   [][]byte{{0x01, 0x0a}, {0x02}},
+		},
+		{
+			desc:      "compressed",
+			threshold: 256,
+			packets:   [][]byte{slices.Concat([]byte{0x05}, large)},
+		},
+		{
+			desc:      "mixed",
+			threshold: 64,
+			packets: [][]byte{
+				{0x01, 0x0a},
+				slices.Concat([]byte{0x05}, large),
+				{0x02},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var uncompressed bytes.Buffer
+			for _, p := range tc.packets {
+				uncompressed.Write(frame(p))
+			}
+			want := slices.Clone(uncompressed.Bytes())
+
+			var wire bytes.Buffer
+			w := compression.NewWriter(&wire, tc.threshold)
+			if _, err := w.Write(uncompressed.Bytes()); err != nil {
+				t.Fatalf("Write() unexpected err: %v", err)
+			}
+
+			got, err := io.ReadAll(compression.NewReader(&wire))
+			if err != nil {
+				t.Fatalf("ReadAll() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("round trip diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// frame prefixes the packet with its length,
+// assuming the length fits in a 2-byte VarInt.
+func TestReaderEOF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		input   []byte
+		wantErr error
+	}{
+		{
+			desc:    "between packets",
+			input:   nil,
+			wantErr: io.EOF,
+		},
+		{
+			desc:    "in packet length",
+			input:   []byte{0x80},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			desc:    "in payload",
+			input:   []byte{0x03, 0x00, 0x01},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := compression.NewReader(bytes.NewReader(tc.input)).Read(make([]byte, 1))
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Read() err = %v, want %v", err, tc.wantErr)
+			}
+			// Readers must return EOF itself.
+			if tc.wantErr == io.EOF && err != io.EOF {
+				t.Errorf("Read() err = %#v, want io.EOF unwrapped", err)
+			}
+		})
+	}
+}
+
+func TestReaderOversized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input []byte
+	}{
+		{
+			desc: "packet length",
+			input: []byte{
+				// packet length (2^21)
+				0x80, 0x80, 0x80, 0x01,
+				// data length
+				0x00,
+			},
+		},
+		{
+			desc: "negative packet length",
+			input: []byte{
+				// packet length (-1)
+				0xff, 0xff, 0xff, 0xff, 0x0f,
+			},
+		},
+		{
+			desc: "data length",
+			input: []byte{
+				// packet length
+				0x05,
+				// data length (8 MiB + 1)
+				0x81, 0x80, 0x80, 0x04,
+				// compressed data
+				0x78,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := compression.NewReader(bytes.NewReader(tc.input)).Read(make([]byte, 1))
+			if err == nil || !strings.Contains(err.Error(), "out of bounds") {
+				t.Errorf("Read() err = %v, want an out of bounds error", err)
+			}
+		})
+	}
+}
+
+func frame(p []byte) []byte {
+	if len(p) < 0x80 {
+		return slices.Concat([]byte{byte(len(p))}, p)
+	}
+	return slices.Concat([]byte{byte(len(p)&0x7F) | 0x80, byte(len(p) >> 7)}, p)
+}
@@ -3,6 +3,7 @@ module github.com/airforce270/mc-srv
 go 1.25.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/google/go-cmp v0.7.0
 	github.com/google/uuid v1.6.0
 )
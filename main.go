@@ -1,77 +1,168 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
-	"fmt"
+	"io/fs"
 	"log"
-	"net"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/airforce270/mc-srv/server"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/serverconfig"
+	"github.com/airforce270/mc-srv/server/status"
+	"github.com/google/uuid"
 )
 
 var (
-	portFlag = flag.Int("port", 25565, "Port to listen on.")
+	configFlag                  = flag.String("config", "server.properties", "Config file, in server.properties or TOML (.toml) format. Defaults are used if it doesn't exist.")
+	portFlag                    = flag.Int("port", 25565, "Port to listen on. Overrides server-port from the config.")
+	compressionThresholdFlag    = flag.Int("compression-threshold", 256, "Minimum size of a packet, in bytes, before it is compressed. -1 disables compression. Overrides network-compression-threshold from the config.")
+	onlineModeFlag              = flag.Bool("online-mode", true, "Whether to authenticate players with Mojang and encrypt connections. Set to false to run without internet access. Overrides online-mode from the config.")
+	motdFlag                    = flag.String("motd", status.DefaultMOTD, "Message shown in the server list, as plain text or a JSON text component. Overrides motd from the config.")
+	maxPlayersFlag              = flag.Int("max-players", status.DefaultMaxPlayers, "Maximum number of players. Overrides max-players from the config.")
+	versionNameFlag             = flag.String("version-name", status.DefaultVersionName, "Name of the version shown in the server list. Overrides version-name from the config.")
+	sessionServerURLFlag        = flag.String("session-server-url", auth.DefaultSessionServerURL, "Base URL of the session server to authenticate players with in online mode.")
+	sessionServerTimeoutFlag    = flag.Duration("session-server-timeout", auth.DefaultTimeout, "How long to wait for the session server to authenticate a player.")
+	preventProxyConnectionsFlag = flag.Bool("prevent-proxy-connections", false, "Whether to reject players connecting from a different IP than they authenticated with the session server from.")
+	velocitySecretFileFlag      = flag.String("velocity-secret-file", "", "File containing the secret shared with a Velocity proxy. If set, players must connect through the proxy using modern forwarding.")
+	whitelistFileFlag           = flag.String("whitelist-file", serverconfig.DefaultWhitelistFile, "Players who can join if white-list is set in the config, in the vanilla whitelist.json format.")
+	faviconFlag                 = flag.String("favicon", "", "64x64 PNG shown in the server list.")
+	bungeeCordFlag              = flag.Bool("bungeecord", false, "Whether players must connect through a BungeeCord-style proxy with IP forwarding enabled.")
+	shutdownMessageFlag         = flag.String("shutdown-message", server.DefaultShutdownMessage, "Reason players are disconnected with when the server shuts down.")
+	shutdownTimeoutFlag         = flag.Duration("shutdown-timeout", 10*time.Second, "How long to wait for players to be disconnected when the server shuts down.")
+	bindFlag                    []string
 )
 
-func createListener(port int) (*net.TCPListener, error) {
-	addrStr := fmt.Sprintf("127.0.0.1:%d", port)
-	addr, err := net.ResolveTCPAddr("tcp", addrStr)
-	if err != nil {
-		return nil, fmt.Errorf("failed to resolve TCP address %s: %v", addrStr, err)
+func init() {
+	flag.Func("bind", "Address to listen on, as host:port (e.g. [::]:25565) or unix:path for a unix domain socket. May be repeated. Defaults to server-ip and server-port from the config.", func(s string) error {
+		bindFlag = append(bindFlag, s)
+		return nil
+	})
+}
+
+// loadConfig loads the config file at name,
+// or returns the default config if it doesn't exist.
+func loadConfig(name string) (serverconfig.Config, error) {
+	cfg, err := serverconfig.Load(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Config file %s doesn't exist, using defaults", name)
+		return serverconfig.Default(), nil
 	}
+	return cfg, err
+}
 
-	listener, err := net.ListenTCP("tcp", addr)
+// loadWhitelist loads the UUIDs of the players in the whitelist file at name.
+// Nobody is whitelisted if it doesn't exist.
+func loadWhitelist(name string) ([]uuid.UUID, error) {
+	entries, err := serverconfig.LoadWhitelist(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Whitelist file %s doesn't exist, so nobody can join", name)
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to listen on port %d: %v", port, err)
+		return nil, err
+	}
+	players := make([]uuid.UUID, 0, len(entries))
+	for _, e := range entries {
+		players = append(players, e.UUID)
 	}
+	return players, nil
+}
 
-	return listener, err
+// overrideConfig sets the fields of cfg from the flags that were set
+// on the command line, and validates the result.
+func overrideConfig(cfg *serverconfig.Config) error {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.ServerPort = *portFlag
+		case "compression-threshold":
+			cfg.NetworkCompressionThreshold = *compressionThresholdFlag
+		case "online-mode":
+			cfg.OnlineMode = *onlineModeFlag
+		case "motd":
+			cfg.MOTD = *motdFlag
+		case "max-players":
+			cfg.MaxPlayers = *maxPlayersFlag
+		case "version-name":
+			cfg.VersionName = *versionNameFlag
+		}
+	})
+	return cfg.Validate()
 }
 
 func main() {
 	flag.Parse()
 	log.SetFlags(log.Ltime | log.Lmicroseconds)
 
-	ctx := context.Background()
-	ctx, _ = signal.NotifyContext(ctx, os.Interrupt)
+	cfg, err := loadConfig(*configFlag)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if err := overrideConfig(&cfg); err != nil {
+		log.Fatalf("Invalid flags: %v", err)
+	}
 
-	listener, err := createListener(*portFlag)
+	opts := []server.Option{
+		server.WithConfig(cfg),
+		server.WithAuthenticator(auth.Mojang{
+			BaseURL:                 *sessionServerURLFlag,
+			Timeout:                 *sessionServerTimeoutFlag,
+			PreventProxyConnections: *preventProxyConnectionsFlag,
+		}),
+		server.WithFaviconFile(*faviconFlag),
+		server.WithShutdownMessage(types.TextComponent{Text: *shutdownMessageFlag}),
+	}
+	if cfg.WhiteList {
+		players, err := loadWhitelist(*whitelistFileFlag)
+		if err != nil {
+			log.Fatalf("Failed to load whitelist: %v", err)
+		}
+		opts = append(opts, server.WithWhitelist(players...))
+	}
+	if *velocitySecretFileFlag != "" {
+		b, err := os.ReadFile(*velocitySecretFileFlag)
+		if err != nil {
+			log.Fatalf("Failed to read Velocity secret: %v", err)
+		}
+		opts = append(opts, server.WithVelocitySecret(bytes.TrimSpace(b)))
+	}
+	if *bungeeCordFlag {
+		opts = append(opts, server.WithBungeeCord())
+	}
+
+	srv, err := server.New(opts...)
 	if err != nil {
-		log.Fatalf("Failed to create listener: %v", err)
+		log.Fatalf("Failed to create server: %v", err)
 	}
-	defer listener.Close()
-	log.Printf("Listening on port %d", *portFlag)
 
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
+	interrupted, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdownDone := make(chan struct{})
 	go func() {
-		<-interrupt
-		listener.Close()
-	}()
+		defer close(shutdownDone)
+		<-interrupted.Done()
+		// Let a second interrupt kill the process.
+		stop()
 
-	for {
-		conn, err := listener.AcceptTCP()
-		if err != nil {
-			log.Fatalf("Failed to get next connection on listener: %v", err)
+		log.Print("Shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeoutFlag)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Failed to shut down gracefully: %v", err)
 		}
-		conn.SetNoDelay(true)
-		conn.SetKeepAlive(true)
-		log.Printf("New connection from %s", conn.RemoteAddr().String())
+	}()
 
-		c, err := server.NewConn(conn)
-		if err != nil {
-			log.Printf("Failed to create connection handler: %v", err)
-			conn.Close()
-			continue
-		}
-		defer c.Close()
-		go func() {
-			connCtx, cancelConn := context.WithCancel(ctx)
-			c.Handle(connCtx)
-			cancelConn()
-		}()
+	if err := srv.ListenAndServe(bindFlag...); !errors.Is(err, server.ErrServerClosed) {
+		log.Fatalf("Failed to serve: %v", err)
 	}
+	<-shutdownDone
+	log.Print("Shut down")
 }
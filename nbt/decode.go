@@ -0,0 +1,551 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Unmarshal decodes NBT in the original (named) format into v,
+// which must be a non-nil pointer.
+// It returns the name of the root tag.
+func Unmarshal(data []byte, v any) (string, error) {
+	return NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// UnmarshalNetwork decodes network NBT, which has no root name, into v,
+// which must be a non-nil pointer.
+func UnmarshalNetwork(data []byte, v any) error {
+	_, err := NewNetworkDecoder(bytes.NewReader(data)).Decode(v)
+	return err
+}
+
+// A Decoder reads NBT values from an input stream.
+type Decoder struct {
+	r       io.Reader
+	network bool
+}
+
+// NewDecoder returns a new decoder that reads from r
+// in the original format, where the root tag is named.
+//
+// The decoder reads exactly the bytes of each value from r,
+// so r can continue to be used after decoding,
+// e.g. to read the rest of a packet.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// NewNetworkDecoder returns a new decoder that reads from r
+// in the network format, where the root tag has no name.
+func NewNetworkDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, network: true}
+}
+
+// Decode reads the next NBT value into v,
+// which must be a non-nil pointer.
+// It returns the name of the root tag, which is always empty
+// for network decoders.
+//
+// A TAG_End root (sent in place of an absent value) leaves v unchanged.
+func (d *Decoder) Decode(v any) (string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return "", fmt.Errorf("nbt: Decode requires a non-nil pointer, got %T", v)
+	}
+
+	dec := decoder{r: d.r}
+	typ, err := dec.readTagType()
+	if err != nil {
+		return "", fmt.Errorf("nbt: failed to read root tag type: %w", err)
+	}
+	if typ == TagEnd {
+		return "", nil
+	}
+
+	var name string
+	if !d.network {
+		if name, err = dec.readString(); err != nil {
+			return "", fmt.Errorf("nbt: failed to read root name: %w", err)
+		}
+	}
+
+	if err := dec.readPayload(typ, rv, 0); err != nil {
+		return name, err
+	}
+	return name, nil
+}
+
+// decoder decodes values from a reader.
+type decoder struct {
+	r       io.Reader
+	scratch [8]byte
+}
+
+var unmarshalerType = reflect.TypeFor[Unmarshaler]()
+
+// readPayload reads a payload of the given type into v.
+// If v is invalid, the payload is discarded.
+func (d *decoder) readPayload(typ TagType, v reflect.Value, depth int) error {
+	if !v.IsValid() {
+		return d.skipPayload(typ, depth)
+	}
+
+	// Allocate pointers and find Unmarshalers.
+	for {
+		if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+			var generic any
+			if err := d.readPayload(typ, reflect.ValueOf(&generic).Elem(), depth); err != nil {
+				return err
+			}
+			return v.Addr().Interface().(Unmarshaler).UnmarshalNBT(generic)
+		}
+		if v.Kind() != reflect.Pointer {
+			break
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.NumMethod() != 0 {
+			return fmt.Errorf("nbt: cannot decode %s into non-empty interface %s", typ, v.Type())
+		}
+		generic, err := d.readGeneric(typ, depth)
+		if err != nil {
+			return err
+		}
+		if generic != nil {
+			v.Set(reflect.ValueOf(generic))
+		}
+		return nil
+	}
+
+	switch typ {
+	case TagByte, TagShort, TagInt, TagLong:
+		n, err := d.readInt(typ)
+		if err != nil {
+			return err
+		}
+		return setInt(v, typ, n)
+	case TagFloat:
+		f, err := d.readFloat()
+		if err != nil {
+			return err
+		}
+		return setFloat(v, typ, float64(f))
+	case TagDouble:
+		f, err := d.readDouble()
+		if err != nil {
+			return err
+		}
+		return setFloat(v, typ, f)
+	case TagString:
+		s, err := d.readString()
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.String {
+			return mismatch(typ, v)
+		}
+		v.SetString(s)
+		return nil
+	case TagByteArray, TagIntArray, TagLongArray:
+		return d.readArray(typ, v)
+	case TagList:
+		return d.readList(v, depth+1)
+	case TagCompound:
+		return d.readCompound(v, depth+1)
+	default:
+		return fmt.Errorf("nbt: unknown tag type %d", typ)
+	}
+}
+
+func (d *decoder) readArray(typ TagType, v reflect.Value) error {
+	elemType := map[TagType]TagType{TagByteArray: TagByte, TagIntArray: TagInt, TagLongArray: TagLong}[typ]
+
+	n, err := d.readLength()
+	if err != nil {
+		return err
+	}
+
+	if typ == TagByteArray && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		b, err := d.readBytes(n)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 0, min(n, 1024)))
+		for range n {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.readPayload(elemType, elem, 0); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, elem))
+		}
+		return nil
+	case reflect.Array:
+		for i := range n {
+			var elem reflect.Value
+			if i < v.Len() {
+				elem = v.Index(i)
+			}
+			if err := d.readPayload(elemType, elem, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return mismatch(typ, v)
+	}
+}
+
+func (d *decoder) readList(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	elemType, err := d.readTagType()
+	if err != nil {
+		return err
+	}
+	n, err := d.readLength()
+	if err != nil {
+		return err
+	}
+	if elemType == TagEnd && n > 0 {
+		return fmt.Errorf("nbt: list of %d %s", n, elemType)
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 0, min(n, 1024)))
+		for i := range n {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.readPayload(elemType, elem, depth); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+			v.Set(reflect.Append(v, elem))
+		}
+		return nil
+	case reflect.Array:
+		for i := range n {
+			var elem reflect.Value
+			if i < v.Len() {
+				elem = v.Index(i)
+			}
+			if err := d.readPayload(elemType, elem, depth); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		return nil
+	default:
+		return mismatch(TagList, v)
+	}
+}
+
+func (d *decoder) readCompound(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	var fields map[string]field
+	switch v.Kind() {
+	case reflect.Struct:
+		fields = map[string]field{}
+		for _, f := range cachedFields(v.Type()) {
+			fields[f.name] = f
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("nbt: unsupported map key type %s", v.Type().Key())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+	default:
+		return mismatch(TagCompound, v)
+	}
+
+	for {
+		typ, err := d.readTagType()
+		if err != nil {
+			return err
+		}
+		if typ == TagEnd {
+			return nil
+		}
+		name, err := d.readString()
+		if err != nil {
+			return err
+		}
+
+		if v.Kind() == reflect.Map {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.readPayload(typ, elem, depth); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+			continue
+		}
+
+		var fv reflect.Value
+		if f, ok := fields[name]; ok {
+			fv = fieldByIndexAlloc(v, f.index)
+		}
+		if err := d.readPayload(typ, fv, depth); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+}
+
+// readGeneric reads a payload into the Go type it decodes to
+// when decoding into an interface value.
+func (d *decoder) readGeneric(typ TagType, depth int) (any, error) {
+	var v reflect.Value
+	switch typ {
+	case TagByte:
+		v = reflect.New(reflect.TypeFor[int8]())
+	case TagShort:
+		v = reflect.New(reflect.TypeFor[int16]())
+	case TagInt:
+		v = reflect.New(reflect.TypeFor[int32]())
+	case TagLong:
+		v = reflect.New(reflect.TypeFor[int64]())
+	case TagFloat:
+		v = reflect.New(reflect.TypeFor[float32]())
+	case TagDouble:
+		v = reflect.New(reflect.TypeFor[float64]())
+	case TagString:
+		v = reflect.New(reflect.TypeFor[string]())
+	case TagByteArray:
+		v = reflect.New(reflect.TypeFor[[]byte]())
+	case TagIntArray:
+		v = reflect.New(reflect.TypeFor[[]int32]())
+	case TagLongArray:
+		v = reflect.New(reflect.TypeFor[[]int64]())
+	case TagList:
+		v = reflect.New(reflect.TypeFor[[]any]())
+	case TagCompound:
+		v = reflect.New(reflect.TypeFor[map[string]any]())
+	default:
+		return nil, fmt.Errorf("nbt: unknown tag type %d", typ)
+	}
+	if err := d.readPayload(typ, v.Elem(), depth); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// skipPayload reads and discards a payload.
+func (d *decoder) skipPayload(typ TagType, depth int) error {
+	switch typ {
+	case TagByte:
+		return d.discard(1)
+	case TagShort:
+		return d.discard(2)
+	case TagInt, TagFloat:
+		return d.discard(4)
+	case TagLong, TagDouble:
+		return d.discard(8)
+	case TagString:
+		n, err := d.readUint16()
+		if err != nil {
+			return err
+		}
+		return d.discard(int64(n))
+	case TagByteArray, TagIntArray, TagLongArray:
+		n, err := d.readLength()
+		if err != nil {
+			return err
+		}
+		size := map[TagType]int64{TagByteArray: 1, TagIntArray: 4, TagLongArray: 8}[typ]
+		return d.discard(int64(n) * size)
+	case TagList:
+		if depth+1 > maxDepth {
+			return ErrMaxDepth
+		}
+		elemType, err := d.readTagType()
+		if err != nil {
+			return err
+		}
+		n, err := d.readLength()
+		if err != nil {
+			return err
+		}
+		for range n {
+			if err := d.skipPayload(elemType, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case TagCompound:
+		if depth+1 > maxDepth {
+			return ErrMaxDepth
+		}
+		for {
+			typ, err := d.readTagType()
+			if err != nil {
+				return err
+			}
+			if typ == TagEnd {
+				return nil
+			}
+			if _, err := d.readString(); err != nil {
+				return err
+			}
+			if err := d.skipPayload(typ, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("nbt: unknown tag type %d", typ)
+	}
+}
+
+func (d *decoder) discard(n int64) error {
+	copied, err := io.CopyN(io.Discard, d.r, n)
+	if err != nil {
+		return fmt.Errorf("nbt: failed to read %d bytes (read %d): %w", n, copied, err)
+	}
+	return nil
+}
+
+func (d *decoder) readTagType() (TagType, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:1]); err != nil {
+		return TagEnd, fmt.Errorf("nbt: failed to read tag type: %w", err)
+	}
+	typ := TagType(d.scratch[0])
+	if typ > TagLongArray {
+		return TagEnd, fmt.Errorf("nbt: unknown tag type %d", typ)
+	}
+	return typ, nil
+}
+
+func (d *decoder) readInt(typ TagType) (int64, error) {
+	size := map[TagType]int{TagByte: 1, TagShort: 2, TagInt: 4, TagLong: 8}[typ]
+	b := d.scratch[:size]
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		return 0, fmt.Errorf("nbt: failed to read %s: %w", typ, err)
+	}
+	switch typ {
+	case TagByte:
+		return int64(int8(b[0])), nil
+	case TagShort:
+		return int64(int16(binary.BigEndian.Uint16(b))), nil
+	case TagInt:
+		return int64(int32(binary.BigEndian.Uint32(b))), nil
+	default:
+		return int64(binary.BigEndian.Uint64(b)), nil
+	}
+}
+
+func (d *decoder) readUint16() (uint16, error) {
+	if _, err := io.ReadFull(d.r, d.scratch[:2]); err != nil {
+		return 0, fmt.Errorf("nbt: failed to read string length: %w", err)
+	}
+	return binary.BigEndian.Uint16(d.scratch[:2]), nil
+}
+
+func (d *decoder) readFloat() (float32, error) {
+	n, err := d.readInt(TagInt)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(uint32(n)), nil
+}
+
+func (d *decoder) readDouble() (float64, error) {
+	n, err := d.readInt(TagLong)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(uint64(n)), nil
+}
+
+func (d *decoder) readString() (string, error) {
+	n, err := d.readUint16()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.readBytes(int(n))
+	if err != nil {
+		return "", err
+	}
+	return decodeMUTF8(b), nil
+}
+
+// readLength reads the length of an array or list.
+func (d *decoder) readLength() (int, error) {
+	n, err := d.readInt(TagInt)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, errNegativeLength
+	}
+	return int(n), nil
+}
+
+// readBytes reads n bytes, without trusting n for the initial allocation.
+func (d *decoder) readBytes(n int) ([]byte, error) {
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d.r, int64(n))
+	if err != nil {
+		return nil, fmt.Errorf("nbt: failed to read %d bytes (read %d): %w", n, copied, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func setInt(v reflect.Value, typ TagType, n int64) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(n != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.OverflowInt(n) {
+			return fmt.Errorf("nbt: %s %d overflows %s", typ, n, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Unsigned types hold the bits of the signed value,
+		// e.g. a Byte of -1 decodes into a uint8 as 0xFF.
+		bits := uint64(n) & (1<<(8*v.Type().Size()) - 1)
+		if v.Type().Size() == 8 {
+			bits = uint64(n)
+		}
+		v.SetUint(bits)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(n))
+	default:
+		return mismatch(typ, v)
+	}
+	return nil
+}
+
+func setFloat(v reflect.Value, typ TagType, f float64) error {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(f)
+	default:
+		return mismatch(typ, v)
+	}
+	return nil
+}
+
+// ErrTypeMismatch is returned when a tag can't be decoded into a Go value.
+var ErrTypeMismatch = errors.New("nbt: type mismatch")
+
+func mismatch(typ TagType, v reflect.Value) error {
+	return fmt.Errorf("%w: cannot decode %s into %s", ErrTypeMismatch, typ, v.Type())
+}
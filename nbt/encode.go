@@ -0,0 +1,341 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Marshal returns the NBT encoding of v,
+// with an unnamed root tag in the original (named) format.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalNetwork returns the network NBT encoding of v,
+// which has no root name.
+func MarshalNetwork(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewNetworkEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// An Encoder writes NBT values to an output stream.
+type Encoder struct {
+	w       io.Writer
+	network bool
+}
+
+// NewEncoder returns a new encoder that writes to w
+// in the original format, where the root tag is named.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewNetworkEncoder returns a new encoder that writes to w
+// in the network format, where the root tag has no name.
+func NewNetworkEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, network: true}
+}
+
+// Encode writes the NBT encoding of v with an empty root name.
+func (e *Encoder) Encode(v any) error {
+	return e.EncodeNamed("", v)
+}
+
+// EncodeNamed writes the NBT encoding of v with the given root name.
+// The name is ignored by network encoders.
+func (e *Encoder) EncodeNamed(name string, v any) error {
+	var buf bytes.Buffer
+	enc := encoder{buf: &buf}
+
+	rv, err := enc.resolve(reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+	typ, err := tagTypeOf(rv, tagOptions{})
+	if err != nil {
+		return err
+	}
+
+	buf.WriteByte(byte(typ))
+	if !e.network {
+		if err := enc.writeString(name); err != nil {
+			return err
+		}
+	}
+	if err := enc.writePayload(rv, typ, tagOptions{}, 0); err != nil {
+		return err
+	}
+
+	if _, err := e.w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("nbt: failed to write: %w", err)
+	}
+	return nil
+}
+
+// encoder encodes values into a buffer.
+type encoder struct {
+	buf *bytes.Buffer
+}
+
+var marshalerType = reflect.TypeFor[Marshaler]()
+
+// resolve dereferences pointers and interfaces
+// and replaces Marshalers with the value they marshal to.
+// The returned value is invalid if v is nil.
+func (e *encoder) resolve(v reflect.Value) (reflect.Value, error) {
+	for v.IsValid() {
+		if v.Type().Implements(marshalerType) && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+			m, err := v.Interface().(Marshaler).MarshalNBT()
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("nbt: MarshalNBT on %s: %w", v.Type(), err)
+			}
+			v = reflect.ValueOf(m)
+			continue
+		}
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if v.IsNil() {
+				return reflect.Value{}, nil
+			}
+			v = v.Elem()
+		default:
+			return v, nil
+		}
+	}
+	return v, nil
+}
+
+// tagTypeOf returns the tag type a resolved value is encoded as.
+func tagTypeOf(v reflect.Value, opts tagOptions) (TagType, error) {
+	if !v.IsValid() {
+		return TagEnd, fmt.Errorf("nbt: cannot encode nil")
+	}
+	switch v.Kind() {
+	case reflect.Bool, reflect.Int8, reflect.Uint8:
+		return TagByte, nil
+	case reflect.Int16, reflect.Uint16:
+		return TagShort, nil
+	case reflect.Int32, reflect.Uint32:
+		return TagInt, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return TagLong, nil
+	case reflect.Float32:
+		return TagFloat, nil
+	case reflect.Float64:
+		return TagDouble, nil
+	case reflect.String:
+		return TagString, nil
+	case reflect.Slice, reflect.Array:
+		if opts.list {
+			return TagList, nil
+		}
+		switch v.Type().Elem().Kind() {
+		case reflect.Int8, reflect.Uint8:
+			return TagByteArray, nil
+		case reflect.Int32:
+			return TagIntArray, nil
+		case reflect.Int64:
+			return TagLongArray, nil
+		}
+		return TagList, nil
+	case reflect.Struct:
+		return TagCompound, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return TagEnd, fmt.Errorf("nbt: unsupported map key type %s", v.Type().Key())
+		}
+		return TagCompound, nil
+	default:
+		return TagEnd, fmt.Errorf("nbt: unsupported type %s", v.Type())
+	}
+}
+
+// writePayload writes the payload of a resolved value as the given tag type.
+func (e *encoder) writePayload(v reflect.Value, typ TagType, opts tagOptions, depth int) error {
+	switch typ {
+	case TagByte:
+		if v.Kind() == reflect.Bool {
+			if v.Bool() {
+				e.buf.WriteByte(1)
+			} else {
+				e.buf.WriteByte(0)
+			}
+			return nil
+		}
+		e.buf.WriteByte(byte(intValue(v)))
+	case TagShort:
+		e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(intValue(v))))
+	case TagInt:
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(intValue(v))))
+	case TagLong:
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, uint64(intValue(v))))
+	case TagFloat:
+		e.buf.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(float32(v.Float()))))
+	case TagDouble:
+		e.buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v.Float())))
+	case TagString:
+		return e.writeString(v.String())
+	case TagByteArray, TagIntArray, TagLongArray:
+		elemType := map[TagType]TagType{TagByteArray: TagByte, TagIntArray: TagInt, TagLongArray: TagLong}[typ]
+		if err := e.writeLength(v.Len()); err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			e.buf.Write(v.Bytes())
+			return nil
+		}
+		for i := range v.Len() {
+			if err := e.writePayload(v.Index(i), elemType, tagOptions{}, depth); err != nil {
+				return err
+			}
+		}
+	case TagList:
+		return e.writeList(v, depth+1)
+	case TagCompound:
+		if v.Kind() == reflect.Map {
+			return e.writeMap(v, depth+1)
+		}
+		return e.writeStruct(v, depth+1)
+	default:
+		return fmt.Errorf("nbt: cannot write payload of %s", typ)
+	}
+	return nil
+}
+
+func (e *encoder) writeList(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	elems := make([]reflect.Value, v.Len())
+	elemType := TagEnd
+	for i := range v.Len() {
+		elem, err := e.resolve(v.Index(i))
+		if err != nil {
+			return err
+		}
+		typ, err := tagTypeOf(elem, tagOptions{})
+		if err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+		if i == 0 {
+			elemType = typ
+		} else if typ != elemType {
+			return fmt.Errorf("nbt: list element %d is a %s, but the list is of %s", i, typ, elemType)
+		}
+		elems[i] = elem
+	}
+
+	e.buf.WriteByte(byte(elemType))
+	if err := e.writeLength(len(elems)); err != nil {
+		return err
+	}
+	for i, elem := range elems {
+		if err := e.writePayload(elem, elemType, tagOptions{}, depth); err != nil {
+			return fmt.Errorf("[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) writeMap(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	keys := v.MapKeys()
+	slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+	for _, k := range keys {
+		if err := e.writeNamedTag(k.String(), v.MapIndex(k), tagOptions{}, depth); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(byte(TagEnd))
+	return nil
+}
+
+func (e *encoder) writeStruct(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return ErrMaxDepth
+	}
+
+	for _, f := range cachedFields(v.Type()) {
+		fv, err := fieldByIndex(v, f.index)
+		if err != nil {
+			continue // nil embedded pointer
+		}
+		if f.opts.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if err := e.writeNamedTag(f.name, fv, f.opts, depth); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(byte(TagEnd))
+	return nil
+}
+
+// writeNamedTag writes a compound entry. Nil values are skipped.
+func (e *encoder) writeNamedTag(name string, v reflect.Value, opts tagOptions, depth int) error {
+	rv, err := e.resolve(v)
+	if err != nil {
+		return err
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	typ, err := tagTypeOf(rv, opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	e.buf.WriteByte(byte(typ))
+	if err := e.writeString(name); err != nil {
+		return err
+	}
+	if err := e.writePayload(rv, typ, opts, depth); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// writeString writes a string prefixed with its length as an unsigned short.
+func (e *encoder) writeString(s string) error {
+	b := encodeMUTF8(s)
+	if len(b) > math.MaxUint16 {
+		return fmt.Errorf("nbt: string is too long (%d bytes, max=%d)", len(b), math.MaxUint16)
+	}
+	e.buf.Write(binary.BigEndian.AppendUint16(nil, uint16(len(b))))
+	e.buf.Write(b)
+	return nil
+}
+
+// writeLength writes the length of an array or list as an int.
+func (e *encoder) writeLength(n int) error {
+	if n > math.MaxInt32 {
+		return fmt.Errorf("nbt: too many elements (%d)", n)
+	}
+	e.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	return nil
+}
+
+// intValue returns an integer value as an int64, regardless of signedness.
+func intValue(v reflect.Value) int64 {
+	if v.CanInt() {
+		return v.Int()
+	}
+	return int64(v.Uint())
+}
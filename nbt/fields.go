@@ -0,0 +1,110 @@
+package nbt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// tagOptions are the options in a field's struct tag.
+type tagOptions struct {
+	// omitEmpty is whether the field is skipped if it's the zero value.
+	omitEmpty bool
+	// list is whether an array-typed field is encoded as a list.
+	list bool
+}
+
+// field is an encodable struct field.
+type field struct {
+	name  string
+	index []int
+	opts  tagOptions
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields returns the encodable fields of a struct type.
+func cachedFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t, nil))
+	return f.([]field)
+}
+
+// typeFields returns the encodable fields of a struct type,
+// flattening untagged embedded structs.
+func typeFields(t reflect.Type, index []int) []field {
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("nbt")
+		if tag == "-" {
+			continue
+		}
+		name, rawOpts, _ := strings.Cut(tag, ",")
+		idx := append(append([]int{}, index...), i)
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, typeFields(ft, idx)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		var opts tagOptions
+		for _, o := range strings.Split(rawOpts, ",") {
+			switch o {
+			case "omitempty":
+				opts.omitEmpty = true
+			case "list":
+				opts.list = true
+			}
+		}
+		fields = append(fields, field{name: name, index: idx, opts: opts})
+	}
+	return fields
+}
+
+var errNilEmbedded = errors.New("nil embedded pointer")
+
+// fieldByIndex is like reflect.Value.FieldByIndex,
+// but returns an error instead of panicking on nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, errNilEmbedded
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// fieldByIndexAlloc is like fieldByIndex,
+// but allocates nil embedded pointers.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
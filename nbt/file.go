@@ -0,0 +1,60 @@
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Decompress returns a reader of the uncompressed NBT data in r,
+// detecting whether it's gzip-compressed (e.g. level.dat),
+// zlib-compressed (e.g. region file chunks) or uncompressed.
+func Decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("nbt: failed to detect compression: %w", err)
+	}
+
+	switch {
+	case magic[0] == 0x1f && magic[1] == 0x8b:
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("nbt: failed to create gzip reader: %w", err)
+		}
+		return zr, nil
+	case magic[0] == 0x78 && (uint16(magic[0])<<8|uint16(magic[1]))%31 == 0:
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("nbt: failed to create zlib reader: %w", err)
+		}
+		return zr, nil
+	default:
+		return br, nil
+	}
+}
+
+// ReadFile decodes the NBT file at name into v,
+// which must be a non-nil pointer.
+// The file may be gzip-compressed, zlib-compressed or uncompressed.
+// It returns the name of the root tag.
+func ReadFile(name string, v any) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("nbt: failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	r, err := Decompress(f)
+	if err != nil {
+		return "", fmt.Errorf("nbt: failed to read %s: %w", name, err)
+	}
+	rootName, err := NewDecoder(r).Decode(v)
+	if err != nil {
+		return "", fmt.Errorf("nbt: failed to decode %s: %w", name, err)
+	}
+	return rootName, nil
+}
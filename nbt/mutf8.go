@@ -0,0 +1,64 @@
+package nbt
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// NBT strings are encoded in Java's "modified UTF-8":
+// U+0000 is encoded as two bytes (0xC0 0x80)
+// and characters outside the BMP are encoded as a surrogate pair
+// of 3-byte sequences instead of a single 4-byte sequence.
+// https://docs.oracle.com/javase/8/docs/api/java/io/DataInput.html#modified-utf-8
+
+// encodeMUTF8 encodes a string as modified UTF-8.
+func encodeMUTF8(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == 0:
+			b = append(b, 0xC0, 0x80)
+		case r < utf8.RuneSelf:
+			b = append(b, byte(r))
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			b = appendMUTF8Char(b, r1)
+			b = appendMUTF8Char(b, r2)
+		default:
+			b = appendMUTF8Char(b, r)
+		}
+	}
+	return b
+}
+
+// appendMUTF8Char appends a 2 or 3 byte sequence for a UTF-16 char.
+func appendMUTF8Char(b []byte, r rune) []byte {
+	if r < 0x800 {
+		return append(b, 0xC0|byte(r>>6), 0x80|byte(r&0x3F))
+	}
+	return append(b, 0xE0|byte(r>>12), 0x80|byte(r>>6&0x3F), 0x80|byte(r&0x3F))
+}
+
+// decodeMUTF8 decodes a modified UTF-8 string.
+// Malformed sequences decode to U+FFFD.
+func decodeMUTF8(b []byte) string {
+	chars := make([]uint16, 0, len(b))
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c < 0x80:
+			chars = append(chars, uint16(c))
+			i++
+		case c&0xE0 == 0xC0 && i+1 < len(b) && b[i+1]&0xC0 == 0x80:
+			chars = append(chars, uint16(c&0x1F)<<6|uint16(b[i+1]&0x3F))
+			i += 2
+		case c&0xF0 == 0xE0 && i+2 < len(b) && b[i+1]&0xC0 == 0x80 && b[i+2]&0xC0 == 0x80:
+			chars = append(chars, uint16(c&0x0F)<<12|uint16(b[i+1]&0x3F)<<6|uint16(b[i+2]&0x3F))
+			i += 3
+		default:
+			chars = append(chars, utf8.RuneError)
+			i++
+		}
+	}
+	return string(utf16.Decode(chars))
+}
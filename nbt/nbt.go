@@ -0,0 +1,119 @@
+// Package nbt encodes and decodes the Named Binary Tag format.
+// https://wiki.vg/NBT
+//
+// Go values map to tags as follows:
+//
+//	bool, int8, uint8      Byte
+//	int16, uint16          Short
+//	int32, uint32          Int
+//	int, int64, uint64     Long
+//	float32                Float
+//	float64                Double
+//	string                 String
+//	[]byte, []int8         ByteArray
+//	[]int32                IntArray
+//	[]int64                LongArray
+//	other slices/arrays    List
+//	structs, map[string]T  Compound
+//
+// Struct fields are encoded using the field name,
+// unless overridden with an `nbt:"name"` struct tag.
+// Like encoding/json, the tag can also contain the "omitempty" option,
+// and fields tagged `nbt:"-"` are ignored.
+// The "list" option encodes a []byte, []int32 or []int64 field
+// as a List instead of an array tag.
+//
+// When decoding into an interface value, tags are decoded
+// into the Go type they're listed next to above
+// (with []any for lists and map[string]any for compounds).
+//
+// Two variants of the format exist:
+// the original, where the root tag has a name (used in files),
+// and the network format used since 1.20.2,
+// where the root tag has no name.
+package nbt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TagType is the type of an NBT tag.
+type TagType byte
+
+// Tag types.
+// https://wiki.vg/NBT#Specification
+const (
+	TagEnd       TagType = 0
+	TagByte      TagType = 1
+	TagShort     TagType = 2
+	TagInt       TagType = 3
+	TagLong      TagType = 4
+	TagFloat     TagType = 5
+	TagDouble    TagType = 6
+	TagByteArray TagType = 7
+	TagString    TagType = 8
+	TagList      TagType = 9
+	TagCompound  TagType = 10
+	TagIntArray  TagType = 11
+	TagLongArray TagType = 12
+)
+
+// maxDepth is the maximum nesting depth of lists and compounds,
+// the same as the Notchian implementation.
+const maxDepth = 512
+
+var (
+	// ErrMaxDepth is returned when lists and compounds are nested too deeply.
+	ErrMaxDepth = fmt.Errorf("nbt: exceeded max depth of %d", maxDepth)
+
+	errNegativeLength = errors.New("nbt: negative length")
+)
+
+func (t TagType) String() string {
+	switch t {
+	case TagEnd:
+		return "TAG_End"
+	case TagByte:
+		return "TAG_Byte"
+	case TagShort:
+		return "TAG_Short"
+	case TagInt:
+		return "TAG_Int"
+	case TagLong:
+		return "TAG_Long"
+	case TagFloat:
+		return "TAG_Float"
+	case TagDouble:
+		return "TAG_Double"
+	case TagByteArray:
+		return "TAG_Byte_Array"
+	case TagString:
+		return "TAG_String"
+	case TagList:
+		return "TAG_List"
+	case TagCompound:
+		return "TAG_Compound"
+	case TagIntArray:
+		return "TAG_Int_Array"
+	case TagLongArray:
+		return "TAG_Long_Array"
+	default:
+		return fmt.Sprintf("TAG_Unknown(%d)", byte(t))
+	}
+}
+
+// Marshaler is implemented by types that encode themselves
+// as a different value, e.g. a text component encoding itself
+// as either a String or a Compound.
+type Marshaler interface {
+	// MarshalNBT returns the value to encode in place of the receiver.
+	MarshalNBT() (any, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves.
+type Unmarshaler interface {
+	// UnmarshalNBT decodes the receiver from the tag's value,
+	// as it would be decoded into an interface value.
+	UnmarshalNBT(v any) error
+}
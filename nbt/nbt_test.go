@@ -0,0 +1,480 @@
+package nbt_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/google/go-cmp/cmp"
+)
+
+// helloWorld is hello_world.nbt from https://wiki.vg/NBT#Examples
+var helloWorld = slices.Concat(
+	// TAG_Compound("hello world")
+	[]byte{0x0a, 0x00, 0x0b},
+	[]byte("hello world"),
+	// TAG_String("name")
+	[]byte{0x08, 0x00, 0x04},
+	[]byte("name"),
+	// "Bananrama"
+	[]byte{0x00, 0x09},
+	[]byte("Bananrama"),
+	// TAG_End
+	[]byte{0x00},
+)
+
+type helloWorldStruct struct {
+	Name string `nbt:"name"`
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input any
+		want  []byte
+	}{
+		{
+			desc:  "byte",
+			input: int8(-1),
+			want:  []byte{0x01, 0x00, 0x00, 0xff},
+		},
+		{
+			desc:  "bool",
+			input: true,
+			want:  []byte{0x01, 0x00, 0x00, 0x01},
+		},
+		{
+			desc:  "short",
+			input: int16(1024),
+			want:  []byte{0x02, 0x00, 0x00, 0x04, 0x00},
+		},
+		{
+			desc:  "int",
+			input: int32(-123456789),
+			want:  []byte{0x03, 0x00, 0x00, 0xf8, 0xa4, 0x32, 0xeb},
+		},
+		{
+			desc:  "long",
+			input: int64(1234),
+			want:  []byte{0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0xd2},
+		},
+		{
+			desc:  "float",
+			input: float32(0.5),
+			want:  []byte{0x05, 0x00, 0x00, 0x3f, 0x00, 0x00, 0x00},
+		},
+		{
+			desc:  "double",
+			input: 0.5,
+			want:  []byte{0x06, 0x00, 0x00, 0x3f, 0xe0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			desc:  "byte array",
+			input: []byte{0x01, 0x02},
+			want:  []byte{0x07, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x01, 0x02},
+		},
+		{
+			desc:  "string",
+			input: "hi",
+			want:  []byte{0x08, 0x00, 0x00, 0x00, 0x02, 'h', 'i'},
+		},
+		{
+			desc:  "modified utf-8 string",
+			input: "\x00😀",
+			want:  []byte{0x08, 0x00, 0x00, 0x00, 0x08, 0xc0, 0x80, 0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80},
+		},
+		{
+			desc:  "list",
+			input: []int16{1, 2},
+			want:  []byte{0x09, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x02, 0x00, 0x01, 0x00, 0x02},
+		},
+		{
+			desc:  "empty list",
+			input: []string{},
+			want:  []byte{0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			desc:  "compound",
+			input: map[string]any{"a": int8(1), "b": "c"},
+			want:  []byte{0x0a, 0x00, 0x00, 0x01, 0x00, 0x01, 'a', 0x01, 0x08, 0x00, 0x01, 'b', 0x00, 0x01, 'c', 0x00},
+		},
+		{
+			desc:  "int array",
+			input: []int32{1},
+			want:  []byte{0x0b, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01},
+		},
+		{
+			desc:  "long array",
+			input: []int64{1},
+			want:  []byte{0x0c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		},
+		{
+			desc: "struct tags",
+			input: struct {
+				Renamed   int8   `nbt:"r"`
+				Omitted   string `nbt:"o,omitempty"`
+				Ignored   int8   `nbt:"-"`
+				AsList    []byte `nbt:"l,list"`
+				NilPtr    *int8  `nbt:"p"`
+				unexpored int8
+			}{Renamed: 1, Ignored: 2, AsList: []byte{3}, unexpored: 4},
+			want: slices.Concat(
+				[]byte{0x0a, 0x00, 0x00},
+				[]byte{0x01, 0x00, 0x01, 'r', 0x01},
+				[]byte{0x09, 0x00, 0x01, 'l', 0x01, 0x00, 0x00, 0x00, 0x01, 0x03},
+				[]byte{0x00},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := nbt.Marshal(tc.input)
+			if err != nil {
+				t.Fatalf("Marshal() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Marshal() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMarshalNetwork(t *testing.T) {
+	t.Parallel()
+
+	got, err := nbt.MarshalNetwork(helloWorldStruct{Name: "Bananrama"})
+	if err != nil {
+		t.Fatalf("MarshalNetwork() unexpected err: %v", err)
+	}
+
+	// Same as hello_world.nbt, without the root name.
+	want := slices.Concat(helloWorld[:1], helloWorld[3+len("hello world"):])
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MarshalNetwork() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEncodeNamed(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := nbt.NewEncoder(&buf).EncodeNamed("hello world", helloWorldStruct{Name: "Bananrama"}); err != nil {
+		t.Fatalf("EncodeNamed() unexpected err: %v", err)
+	}
+
+	if diff := cmp.Diff(helloWorld, buf.Bytes()); diff != "" {
+		t.Errorf("EncodeNamed() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	var got helloWorldStruct
+	name, err := nbt.Unmarshal(helloWorld, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal() unexpected err: %v", err)
+	}
+
+	if name != "hello world" {
+		t.Errorf("Unmarshal() root name = %q, want %q", name, "hello world")
+	}
+	if diff := cmp.Diff(helloWorldStruct{Name: "Bananrama"}, got); diff != "" {
+		t.Errorf("Unmarshal() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestUnmarshalGeneric(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"byte":      int8(1),
+		"short":     int16(2),
+		"int":       int32(3),
+		"long":      int64(4),
+		"float":     float32(5),
+		"double":    float64(6),
+		"bytes":     []byte{7},
+		"string":    "8",
+		"list":      []any{"9"},
+		"compound":  map[string]any{"10": int32(10)},
+		"ints":      []int32{11},
+		"longs":     []int64{12},
+		"emptyList": []any{},
+	}
+
+	data, err := nbt.MarshalNetwork(input)
+	if err != nil {
+		t.Fatalf("MarshalNetwork() unexpected err: %v", err)
+	}
+
+	var got any
+	if err := nbt.UnmarshalNetwork(data, &got); err != nil {
+		t.Fatalf("UnmarshalNetwork() unexpected err: %v", err)
+	}
+
+	if diff := cmp.Diff(any(input), got); diff != "" {
+		t.Errorf("UnmarshalNetwork() diff (-want, +got):\n%s", diff)
+	}
+}
+
+type roundTrip struct {
+	Bool     bool             `nbt:"bool"`
+	Byte     uint8            `nbt:"byte"`
+	Short    int16            `nbt:"short"`
+	Int      int32            `nbt:"int"`
+	Long     int64            `nbt:"long"`
+	Float    float32          `nbt:"float"`
+	Double   float64          `nbt:"double"`
+	Bytes    []byte           `nbt:"bytes"`
+	String   string           `nbt:"string"`
+	Strings  []string         `nbt:"strings"`
+	Nested   []roundTripChild `nbt:"nested"`
+	Ints     []int32          `nbt:"ints"`
+	Longs    []int64          `nbt:"longs"`
+	Map      map[string]int32 `nbt:"map"`
+	Optional *roundTripChild  `nbt:"optional,omitempty"`
+	roundTripEmbedded
+}
+
+type roundTripChild struct {
+	Name string `nbt:"name"`
+}
+
+type roundTripEmbedded struct {
+	Embedded int32 `nbt:"embedded"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input roundTrip
+	}{
+		{
+			desc: "all fields",
+			input: roundTrip{
+				Bool:              true,
+				Byte:              0xff,
+				Short:             -2,
+				Int:               3,
+				Long:              -4,
+				Float:             5.5,
+				Double:            -6.25,
+				Bytes:             []byte{7, 8},
+				String:            "nine",
+				Strings:           []string{"ten", "eleven"},
+				Nested:            []roundTripChild{{Name: "twelve"}},
+				Ints:              []int32{13},
+				Longs:             []int64{14},
+				Map:               map[string]int32{"fifteen": 15},
+				Optional:          &roundTripChild{Name: "sixteen"},
+				roundTripEmbedded: roundTripEmbedded{Embedded: 17},
+			},
+		},
+		{
+			desc:  "zero",
+			input: roundTrip{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			for _, network := range []bool{false, true} {
+				var buf bytes.Buffer
+				var err error
+				if network {
+					err = nbt.NewNetworkEncoder(&buf).Encode(tc.input)
+				} else {
+					err = nbt.NewEncoder(&buf).Encode(tc.input)
+				}
+				if err != nil {
+					t.Fatalf("Encode(network=%t) unexpected err: %v", network, err)
+				}
+
+				var got roundTrip
+				if network {
+					_, err = nbt.NewNetworkDecoder(&buf).Decode(&got)
+				} else {
+					_, err = nbt.NewDecoder(&buf).Decode(&got)
+				}
+				if err != nil {
+					t.Fatalf("Decode(network=%t) unexpected err: %v", network, err)
+				}
+
+				opts := cmp.AllowUnexported(roundTrip{})
+				want := tc.input
+				if want.Bytes == nil {
+					want.Bytes = []byte{}
+				}
+				if want.Strings == nil {
+					want.Strings = []string{}
+				}
+				if want.Nested == nil {
+					want.Nested = []roundTripChild{}
+				}
+				if want.Ints == nil {
+					want.Ints = []int32{}
+				}
+				if want.Longs == nil {
+					want.Longs = []int64{}
+				}
+				if want.Map == nil {
+					want.Map = map[string]int32{}
+				}
+				if diff := cmp.Diff(want, got, opts); diff != "" {
+					t.Errorf("round trip (network=%t) diff (-want, +got):\n%s", network, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		input   []byte
+		into    any
+		wantErr error
+	}{
+		{
+			desc:    "type mismatch",
+			input:   []byte{0x08, 0x00, 0x00, 0x00, 0x00},
+			into:    new(int32),
+			wantErr: nbt.ErrTypeMismatch,
+		},
+		{
+			desc:    "too deep",
+			input:   slices.Concat([]byte{0x09, 0x00, 0x00}, bytes.Repeat([]byte{0x09, 0x00, 0x00, 0x00, 0x01}, 600)),
+			into:    new(any),
+			wantErr: nbt.ErrMaxDepth,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := nbt.Unmarshal(tc.input, tc.into)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Unmarshal() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	t.Parallel()
+
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(helloWorld)
+	gw.Close()
+
+	var zlibbed bytes.Buffer
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write(helloWorld)
+	zw.Close()
+
+	tests := []struct {
+		desc  string
+		input []byte
+	}{
+		{"uncompressed", helloWorld},
+		{"gzip", gzipped.Bytes()},
+		{"zlib", zlibbed.Bytes()},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "hello_world.nbt")
+			if err := os.WriteFile(path, tc.input, 0o644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			var got helloWorldStruct
+			name, err := nbt.ReadFile(path, &got)
+			if err != nil {
+				t.Fatalf("ReadFile() unexpected err: %v", err)
+			}
+
+			if name != "hello world" {
+				t.Errorf("ReadFile() root name = %q, want %q", name, "hello world")
+			}
+			if diff := cmp.Diff(helloWorldStruct{Name: "Bananrama"}, got); diff != "" {
+				t.Errorf("ReadFile() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	f.Add(helloWorld)
+	f.Add([]byte{0x09, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 'x'})
+	f.Add([]byte{0x0c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var v any
+		if _, err := nbt.Unmarshal(data, &v); err != nil || v == nil {
+			return
+		}
+
+		// Anything that decodes must re-encode and decode to the same value.
+		encoded, err := nbt.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal() of decoded value unexpected err: %v", err)
+		}
+		var got any
+		if _, err := nbt.Unmarshal(encoded, &got); err != nil {
+			t.Fatalf("Unmarshal() of re-encoded value unexpected err: %v", err)
+		}
+		if diff := cmp.Diff(v, got); diff != "" {
+			t.Errorf("round trip diff (-want, +got):\n%s", diff)
+		}
+	})
+}
+
+func FuzzString(f *testing.F) {
+	f.Add("hello world")
+	f.Add("\x00")
+	f.Add("😀")
+
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			return
+		}
+
+		data, err := nbt.MarshalNetwork(s)
+		if err != nil {
+			return // too long
+		}
+
+		var got string
+		if err := nbt.UnmarshalNetwork(data, &got); err != nil {
+			t.Fatalf("UnmarshalNetwork() unexpected err: %v", err)
+		}
+		if got != s {
+			t.Errorf("round trip = %q, want %q", got, s)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+// Package codec encodes and decodes packet fields
+// according to their Go types and struct tags,
+// so packets don't need hand-written Read and Write functions.
+//
+// Fields are encoded in order. Each field's type determines its encoding:
+//
+//	bool                 Boolean
+//	int8, uint8          Byte
+//	int16                Short
+//	uint16               Unsigned Short
+//	int32                Int
+//	int64                Long
+//	float32              Float
+//	float64              Double
+//	string               String
+//	uuid.UUID            UUID
+//	types.Identifier     Identifier
+//	types.Position       Position
+//	types.Angle          Angle
+//	types.BitSet         BitSet
+//	types.Slot           Slot
+//	types.TextComponent  Text Component, as network NBT
+//	map[string]any       NBT, as network NBT
+//	[]byte               Byte Array, prefixed with its length as a VarInt
+//	[]T                  Array of T, prefixed with its length as a VarInt
+//	*T                   Optional T, prefixed with a Boolean
+//	struct               each of its fields
+//
+// The encoding can be changed with the field's "mc" struct tag:
+//
+//	mc:"varint"    an int8, uint8 or int32 is a VarInt
+//	mc:"varlong"   an int64 is a VarLong
+//	mc:"prefixed"  a slice is prefixed with its length as a VarInt (the default)
+//	mc:"rest"      a []byte is the rest of the packet; it must be the last field
+//	mc:"json"      the value is marshalled as JSON and encoded as a String
+//	mc:"max=N"     a []byte is at most N bytes long, checked before it's read
+//	mc:"-"         the field is skipped
+//	mc:"since=N"   the field is only present in protocol version N and later
+//	mc:"until=N"   the field is only present before protocol version N
+//
+// Options on slice and pointer fields apply to their elements,
+// e.g. a []int32 field tagged mc:"varint" is an array of VarInts.
+// Embedded packet.Header fields and unexported fields are skipped.
+//
+// Encode and Decode use a packet's oldest layout,
+// skipping fields with a since option.
+// EncodeVersion and DecodeVersion use its layout in a given protocol version.
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/writepacket"
+)
+
+// ErrUnsupportedType is returned when encoding or decoding a type
+// that has no encoding.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// Encode writes the fields of v, a struct or pointer to a struct, to w,
+// in their oldest layout.
+func Encode(w io.Writer, v any) error {
+	return EncodeVersion(w, v, 0)
+}
+
+// EncodeVersion writes the fields of v, a struct or pointer to a struct, to w,
+// in their layout in the given protocol version.
+func EncodeVersion(w io.Writer, v any, version int32) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("codec: Encode requires a struct, got %T", v)
+	}
+	c, err := cachedCoder(rv.Type())
+	if err != nil {
+		return err
+	}
+	return c.encode(w, rv, version)
+}
+
+// Decode reads the fields of v, a non-nil pointer to a struct, from r,
+// in their oldest layout.
+func Decode(r io.Reader, v any) error {
+	return DecodeVersion(r, v, 0)
+}
+
+// DecodeVersion reads the fields of v, a non-nil pointer to a struct, from r,
+// in their layout in the given protocol version.
+func DecodeVersion(r io.Reader, v any, version int32) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("codec: Decode requires a non-nil pointer to a struct, got %T", v)
+	}
+	c, err := cachedCoder(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return c.decode(r, rv.Elem(), version)
+}
+
+// WritePacket writes v as the payload of a packet with the given ID,
+// in its oldest layout.
+func WritePacket(w io.Writer, id id.ID, v any) error {
+	return WritePacketVersion(w, id, v, 0)
+}
+
+// WritePacketVersion writes v as the payload of a packet with the given ID,
+// in its layout in the given protocol version.
+func WritePacketVersion(w io.Writer, id id.ID, v any, version int32) error {
+	var buf bytes.Buffer
+	if err := EncodeVersion(&buf, v, version); err != nil {
+		return fmt.Errorf("failed to encode %T: %w", v, err)
+	}
+	if err := writepacket.Write(w, id, &buf); err != nil {
+		return fmt.Errorf("failed to write %T packet: %w", v, err)
+	}
+	return nil
+}
+
+// coder encodes and decodes values of one type.
+// The version is the protocol version whose layout is used.
+type coder struct {
+	encode func(w io.Writer, v reflect.Value, version int32) error
+	decode func(r io.Reader, v reflect.Value, version int32) error
+}
+
+var coderCache sync.Map // map[reflect.Type]coder
+
+// cachedCoder returns the coder of a struct type.
+func cachedCoder(t reflect.Type) (coder, error) {
+	if c, ok := coderCache.Load(t); ok {
+		return c.(coder), nil
+	}
+	c, err := structCoder(t)
+	if err != nil {
+		return coder{}, err
+	}
+	cc, _ := coderCache.LoadOrStore(t, c)
+	return cc.(coder), nil
+}
+
+// tagOptions are the options in a field's struct tag.
+type tagOptions struct {
+	varint  bool
+	varlong bool
+	rest    bool
+	json    bool
+	// since and until are the protocol versions the field was added
+	// and removed in, or 0 if it always was or still is present.
+	since int32
+	until int32
+	// max is the maximum length of a []byte, or 0 if it's unbounded.
+	max int
+}
+
+func parseTag(tag string) (opts tagOptions, skip bool, err error) {
+	if tag == "" {
+		return opts, false, nil
+	}
+	for _, o := range strings.Split(tag, ",") {
+		switch o {
+		case "-":
+			skip = true
+		case "varint":
+			opts.varint = true
+		case "varlong":
+			opts.varlong = true
+		case "prefixed":
+			// The default for slices.
+		case "rest":
+			opts.rest = true
+		case "json":
+			opts.json = true
+		default:
+			if v, ok := strings.CutPrefix(o, "since="); ok {
+				if opts.since, err = parseVersion(v); err != nil {
+					return opts, false, err
+				}
+				continue
+			}
+			if v, ok := strings.CutPrefix(o, "max="); ok {
+				m, err := strconv.Atoi(v)
+				if err != nil || m <= 0 {
+					return opts, false, fmt.Errorf("codec: invalid max %q", v)
+				}
+				opts.max = m
+				continue
+			}
+			if v, ok := strings.CutPrefix(o, "until="); ok {
+				if opts.until, err = parseVersion(v); err != nil {
+					return opts, false, err
+				}
+				continue
+			}
+			return opts, false, fmt.Errorf("codec: unknown tag option %q", o)
+		}
+	}
+	return opts, skip, nil
+}
+
+func parseVersion(s string) (int32, error) {
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("codec: invalid protocol version %q", s)
+	}
+	return int32(v), nil
+}
+
+var headerType = reflect.TypeFor[packet.Header]()
+
+// field is an encoded struct field.
+type field struct {
+	name  string
+	index int
+	since int32
+	until int32
+	coder coder
+}
+
+// in returns whether the field is present in the given protocol version.
+func (f field) in(version int32) bool {
+	return version >= f.since && (f.until == 0 || version < f.until)
+}
+
+// structCoder returns the coder of a struct type.
+func structCoder(t reflect.Type) (coder, error) {
+	var fields []field
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() || (sf.Anonymous && sf.Type == headerType) {
+			continue
+		}
+		opts, skip, err := parseTag(sf.Tag.Get("mc"))
+		if err != nil {
+			return coder{}, fmt.Errorf("%s.%s: %w", t, sf.Name, err)
+		}
+		if skip {
+			continue
+		}
+		if opts.rest && i != t.NumField()-1 {
+			return coder{}, fmt.Errorf("codec: %s.%s: rest must be the last field", t, sf.Name)
+		}
+		c, err := typeCoder(sf.Type, opts)
+		if err != nil {
+			return coder{}, fmt.Errorf("codec: %s.%s: %w", t, sf.Name, err)
+		}
+		fields = append(fields, field{name: sf.Name, index: i, since: opts.since, until: opts.until, coder: c})
+	}
+
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, version int32) error {
+			for _, f := range fields {
+				if !f.in(version) {
+					continue
+				}
+				if err := f.coder.encode(w, v.Field(f.index), version); err != nil {
+					return fmt.Errorf("failed to write %s: %w", f.name, err)
+				}
+			}
+			return nil
+		},
+		decode: func(r io.Reader, v reflect.Value, version int32) error {
+			for _, f := range fields {
+				if !f.in(version) {
+					continue
+				}
+				if err := f.coder.decode(r, v.Field(f.index), version); err != nil {
+					return fmt.Errorf("failed to read %s: %w", f.name, err)
+				}
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,415 @@
+package codec_test
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+type enum uint8
+
+type inner struct {
+	Name  string
+	Count int32 `mc:"varint"`
+}
+
+type allTypes struct {
+	packet.Header
+	Bool       bool
+	Byte       int8
+	Enum       enum `mc:"varint"`
+	Short      int16
+	Port       uint16
+	Int        int32
+	VarInt     int32 `mc:"varint"`
+	Long       int64
+	VarLong    int64 `mc:"varlong"`
+	Float      float32
+	Double     float64
+	String     string
+	UUID       uuid.UUID
+	Identifier types.Identifier
+	Position   types.Position
+	Angle      types.Angle
+	BitSet     types.BitSet
+	Slot       types.Slot
+	Text       types.TextComponent
+	JSON       types.TextComponent `mc:"json"`
+	Bytes      []byte
+	VarInts    []int32 `mc:"varint"`
+	Inner      inner
+	Inners     []inner
+	Optional   *string
+	Absent     *inner
+	Skipped    string `mc:"-"`
+	unexported string
+	Rest       []byte `mc:"rest"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	optional := "opt"
+	input := allTypes{
+		Bool:       true,
+		Byte:       -2,
+		Enum:       200,
+		Short:      -300,
+		Port:       25565,
+		Int:        -70000,
+		VarInt:     300,
+		Long:       -5000000000,
+		VarLong:    5000000000,
+		Float:      1.5,
+		Double:     -2.25,
+		String:     "hello",
+		UUID:       uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+		Identifier: "minecraft:stone",
+		Position:   types.Position{X: 1, Y: -2, Z: 3},
+		Angle:      types.AngleFromDegrees(90),
+		BitSet:     types.BitSet{1, 2},
+		Slot:       types.Slot{ItemID: 1, Count: 2},
+		Text:       types.TextComponent{Text: "nbt", Color: types.ColorRed},
+		JSON:       types.TextComponent{Text: "json", Bold: types.Bool(true)},
+		Bytes:      []byte{0x01, 0x02},
+		VarInts:    []int32{1, 128},
+		Inner:      inner{Name: "a", Count: 1},
+		Inners:     []inner{{Name: "b", Count: 2}, {Name: "c", Count: 300}},
+		Optional:   &optional,
+		Rest:       []byte{0xca, 0xfe},
+	}
+
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, input); err != nil {
+		t.Fatalf("Encode() unexpected err: %v", err)
+	}
+
+	var got allTypes
+	if err := codec.Decode(&buf, &got); err != nil {
+		t.Fatalf("Decode() unexpected err: %v", err)
+	}
+	if diff := cmp.Diff(input, got, cmp.AllowUnexported(allTypes{})); diff != "" {
+		t.Errorf("Decode(Encode()) diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	type login struct {
+		packet.Header
+		ServerID    string
+		PublicKey   []byte `mc:"prefixed"`
+		VerifyToken []byte `mc:"prefixed"`
+	}
+	type tags struct {
+		Registry string
+		Entries  []int32 `mc:"varint"`
+	}
+	type optional struct {
+		Before    bool
+		Signature *string
+		After     int32 `mc:"varint"`
+	}
+	type plugin struct {
+		MessageID int32  `mc:"varint"`
+		Data      []byte `mc:"rest"`
+	}
+	type reason struct {
+		Reason types.TextComponent `mc:"json"`
+	}
+	sig := "sig"
+
+	tests := []struct {
+		desc  string
+		input any
+		want  []byte
+	}{
+		{
+			desc: "prefixed byte arrays",
+			input: login{
+				PublicKey:   []byte{0x01, 0x02, 0x03},
+				VerifyToken: []byte{0x04},
+			},
+			want: slices.Concat(
+				// server id
+				[]byte{0x00},
+				// public key
+				[]byte{0x03, 0x01, 0x02, 0x03},
+				// verify token
+				[]byte{0x01, 0x04},
+			),
+		},
+		{
+			desc:  "array of varints",
+			input: &tags{Registry: "a", Entries: []int32{1, 300}},
+			want: slices.Concat(
+				// registry
+				[]byte{0x01, 'a'},
+				// entries
+				[]byte{0x02, 0x01, 0xac, 0x02},
+			),
+		},
+		{
+			desc:  "optional present",
+			input: optional{Signature: &sig, After: 1},
+			want:  []byte{0x00, 0x01, 0x03, 's', 'i', 'g', 0x01},
+		},
+		{
+			desc:  "optional absent",
+			input: optional{Before: true, After: 1},
+			want:  []byte{0x01, 0x00, 0x01},
+		},
+		{
+			desc:  "rest",
+			input: plugin{MessageID: 2, Data: []byte{0x01, 0x02}},
+			want:  []byte{0x02, 0x01, 0x02},
+		},
+		{
+			desc:  "json",
+			input: reason{Reason: types.TextComponent{Text: "hi"}},
+			want:  slices.Concat([]byte{0x0d}, []byte(`{"text":"hi"}`)),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := codec.Encode(&buf, tc.input); err != nil {
+				t.Fatalf("Encode() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, buf.Bytes()); diff != "" {
+				t.Errorf("Encode() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	t.Parallel()
+
+	type unknownTag struct {
+		A int32 `mc:"varshort"`
+	}
+	type restNotLast struct {
+		A []byte `mc:"rest"`
+		B int32
+	}
+	type unsupported struct {
+		A map[int]int
+	}
+	type invalidVersion struct {
+		A int32 `mc:"since=1.20"`
+	}
+	type invalidMax struct {
+		A []byte `mc:"max=-1"`
+	}
+	type maxNotBytes struct {
+		A int32 `mc:"max=2"`
+	}
+	type overMax struct {
+		A []byte `mc:"max=2"`
+	}
+
+	tests := []struct {
+		desc    string
+		input   any
+		wantErr error
+	}{
+		{desc: "not a struct", input: 1},
+		{desc: "unknown tag", input: unknownTag{}},
+		{desc: "rest not last", input: restNotLast{}},
+		{desc: "unsupported type", input: unsupported{}, wantErr: codec.ErrUnsupportedType},
+		{desc: "invalid version", input: invalidVersion{}},
+		{desc: "invalid max", input: invalidMax{}},
+		{desc: "max not on bytes", input: maxNotBytes{}, wantErr: codec.ErrUnsupportedType},
+		{desc: "over max", input: overMax{A: []byte{0x01, 0x02, 0x03}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := codec.Encode(&bytes.Buffer{}, tc.input)
+			if err == nil {
+				t.Fatal("Encode() err = nil, want an error")
+			}
+			if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+				t.Errorf("Encode() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	t.Parallel()
+
+	type versioned struct {
+		Name    string `mc:"until=766"`
+		ID      int32  `mc:"varint,since=766"`
+		Both    bool
+		Secure  bool     `mc:"since=766"`
+		Removed *float32 `mc:"since=700,until=766"`
+	}
+	input := versioned{Name: "a", ID: 3, Both: true, Secure: true}
+
+	tests := []struct {
+		desc    string
+		version int32
+		want    []byte
+		wantOut versioned
+	}{
+		{
+			desc:    "oldest",
+			version: 0,
+			want: []byte{
+				0x01, 'a', // Name
+				0x01, // Both
+			},
+			wantOut: versioned{Name: "a", Both: true},
+		},
+		{
+			desc:    "before since",
+			version: 765,
+			want: []byte{
+				0x01, 'a', // Name
+				0x01, // Both
+				0x00, // Removed, absent
+			},
+			wantOut: versioned{Name: "a", Both: true},
+		},
+		{
+			desc:    "since",
+			version: 766,
+			want: []byte{
+				0x03, // ID
+				0x01, // Both
+				0x01, // Secure
+			},
+			wantOut: versioned{ID: 3, Both: true, Secure: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := codec.EncodeVersion(&buf, input, tc.version); err != nil {
+				t.Fatalf("EncodeVersion() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, buf.Bytes()); diff != "" {
+				t.Errorf("EncodeVersion() diff (-want +got):\n%s", diff)
+			}
+
+			var got versioned
+			if err := codec.DecodeVersion(&buf, &got, tc.version); err != nil {
+				t.Fatalf("DecodeVersion() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantOut, got); diff != "" {
+				t.Errorf("DecodeVersion() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	t.Parallel()
+
+	type p struct {
+		A int32 `mc:"varint"`
+		B string
+	}
+
+	var got p
+	err := codec.Decode(bytes.NewReader([]byte{0x01, 0x05, 'a'}), &got)
+	if err == nil {
+		t.Fatalf("Decode() err = nil, want an error")
+	}
+}
+
+func TestDecodeMax(t *testing.T) {
+	t.Parallel()
+
+	type p struct {
+		A *[]byte `mc:"max=2"`
+	}
+
+	tests := []struct {
+		desc    string
+		input   []byte
+		want    p
+		wantErr bool
+	}{
+		{
+			desc:  "at max",
+			input: []byte{0x01, 0x02, 0xaa, 0xbb},
+			want:  p{A: &[]byte{0xaa, 0xbb}},
+		},
+		{
+			desc:    "over max",
+			input:   []byte{0x01, 0x03, 0xaa, 0xbb, 0xcc},
+			wantErr: true,
+		},
+		{
+			// Length of 2^31-1 with no data, which mustn't be allocated.
+			desc:    "huge",
+			input:   []byte{0x01, 0xff, 0xff, 0xff, 0xff, 0x07},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got p
+			err := codec.Decode(bytes.NewReader(tc.input), &got)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Decode() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Decode() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWritePacket(t *testing.T) {
+	t.Parallel()
+
+	type keepAlive struct {
+		packet.Header
+		KeepAliveID int64
+	}
+
+	var buf bytes.Buffer
+	if err := codec.WritePacket(&buf, id.ClientboundKeepAlive, keepAlive{KeepAliveID: 5}); err != nil {
+		t.Fatalf("WritePacket() unexpected err: %v", err)
+	}
+
+	want := slices.Concat(
+		// header
+		[]byte{0x09, 0x03},
+		// keepalive id
+		[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05},
+	)
+	if diff := cmp.Diff(want, buf.Bytes()); diff != "" {
+		t.Errorf("WritePacket() diff (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,325 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/write"
+	"github.com/google/uuid"
+)
+
+// typeCoder returns the coder of a type with the given options.
+func typeCoder(t reflect.Type, opts tagOptions) (coder, error) {
+	if opts.json {
+		return jsonCoder(), nil
+	}
+	if opts.rest {
+		if t != reflect.TypeFor[[]byte]() {
+			return coder{}, fmt.Errorf("rest must be a []byte, got %s: %w", t, ErrUnsupportedType)
+		}
+		return restCoder(), nil
+	}
+
+	switch t {
+	case reflect.TypeFor[uuid.UUID]():
+		return valueCoder(write.UUID, read.UUID), nil
+	case reflect.TypeFor[types.Identifier]():
+		return valueCoder(write.Identifier, read.Identifier), nil
+	case reflect.TypeFor[types.Position]():
+		return valueCoder(write.Position, read.Position), nil
+	case reflect.TypeFor[types.Angle]():
+		return valueCoder(write.Angle, read.Angle), nil
+	case reflect.TypeFor[types.BitSet]():
+		return valueCoder(write.BitSet, read.BitSet), nil
+	case reflect.TypeFor[types.Slot]():
+		return valueCoder(write.Slot, read.Slot), nil
+	case reflect.TypeFor[types.TextComponent](), reflect.TypeFor[map[string]any]():
+		return nbtCoder(), nil
+	case reflect.TypeFor[[]byte]():
+		if opts.max > 0 {
+			return maxByteArrayCoder(opts.max), nil
+		}
+		return valueCoder(write.ByteArray, read.ByteArray), nil
+	}
+	if opts.max > 0 && t.Kind() != reflect.Slice && t.Kind() != reflect.Pointer {
+		return coder{}, fmt.Errorf("max must be on a []byte, got %s: %w", t, ErrUnsupportedType)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return valueCoder(write.Bool, read.Bool), nil
+	case reflect.Int8, reflect.Uint8:
+		if opts.varint {
+			return intCoder(write.VarInt, read.VarInt), nil
+		}
+		return intCoder(write.Byte, read.Byte), nil
+	case reflect.Int16:
+		return intCoder(write.Short, read.Short), nil
+	case reflect.Uint16:
+		return intCoder(write.UnsignedShort, read.UnsignedShort), nil
+	case reflect.Int32:
+		if opts.varint {
+			return intCoder(write.VarInt, read.VarInt), nil
+		}
+		return intCoder(write.Int, read.Int), nil
+	case reflect.Int64:
+		if opts.varlong {
+			return intCoder(write.VarLong, read.VarLong), nil
+		}
+		return intCoder(write.Long, read.Long), nil
+	case reflect.Float32:
+		return floatCoder(write.Float, read.Float), nil
+	case reflect.Float64:
+		return floatCoder(write.Double, read.Double), nil
+	case reflect.String:
+		return stringCoder(), nil
+	case reflect.Slice:
+		return sliceCoder(t, opts)
+	case reflect.Pointer:
+		return optionalCoder(t, opts)
+	case reflect.Struct:
+		return cachedCoder(t)
+	}
+	return coder{}, fmt.Errorf("%s: %w", t, ErrUnsupportedType)
+}
+
+// valueCoder returns a coder of values of exactly type T.
+func valueCoder[T any](w func(io.Writer, T) error, r func(io.Reader) (T, error)) coder {
+	return coder{
+		encode: func(wr io.Writer, v reflect.Value, _ int32) error {
+			return w(wr, v.Interface().(T))
+		},
+		decode: func(rd io.Reader, v reflect.Value, _ int32) error {
+			val, err := r(rd)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(val))
+			return nil
+		},
+	}
+}
+
+// maxByteArrayCoder returns a coder of byte arrays at most max bytes long.
+func maxByteArrayCoder(max int) coder {
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, _ int32) error {
+			if v.Len() > max {
+				return fmt.Errorf("byte array of %d bytes is longer than the maximum of %d", v.Len(), max)
+			}
+			return write.ByteArray(w, v.Bytes())
+		},
+		decode: func(r io.Reader, v reflect.Value, _ int32) error {
+			b, err := read.ByteArrayMax(r, max)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		},
+	}
+}
+
+// integer is an integer type read and written by the read and write packages.
+type integer interface {
+	~int8 | ~uint8 | ~int16 | ~uint16 | ~int32 | ~int64
+}
+
+// intCoder returns a coder of integers of any type of the same kind,
+// e.g. enums.
+func intCoder[T integer](w func(io.Writer, T) error, r func(io.Reader) (T, error)) coder {
+	return coder{
+		encode: func(wr io.Writer, v reflect.Value, _ int32) error {
+			if v.CanInt() {
+				return w(wr, T(v.Int()))
+			}
+			return w(wr, T(v.Uint()))
+		},
+		decode: func(rd io.Reader, v reflect.Value, _ int32) error {
+			val, err := r(rd)
+			if err != nil {
+				return err
+			}
+			if v.CanInt() {
+				v.SetInt(int64(val))
+			} else {
+				v.SetUint(uint64(val))
+			}
+			return nil
+		},
+	}
+}
+
+// floatCoder returns a coder of floats of any type of the same kind.
+func floatCoder[T float32 | float64](w func(io.Writer, T) error, r func(io.Reader) (T, error)) coder {
+	return coder{
+		encode: func(wr io.Writer, v reflect.Value, _ int32) error {
+			return w(wr, T(v.Float()))
+		},
+		decode: func(rd io.Reader, v reflect.Value, _ int32) error {
+			val, err := r(rd)
+			if err != nil {
+				return err
+			}
+			v.SetFloat(float64(val))
+			return nil
+		},
+	}
+}
+
+// stringCoder returns a coder of strings of any type.
+func stringCoder() coder {
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, _ int32) error {
+			return write.String(w, v.String())
+		},
+		decode: func(r io.Reader, v reflect.Value, _ int32) error {
+			s, err := read.String(r)
+			if err != nil {
+				return err
+			}
+			v.SetString(s)
+			return nil
+		},
+	}
+}
+
+// sliceCoder returns a coder of slices prefixed with their length.
+func sliceCoder(t reflect.Type, opts tagOptions) (coder, error) {
+	elem, err := typeCoder(t.Elem(), opts)
+	if err != nil {
+		return coder{}, err
+	}
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, version int32) error {
+			if err := write.VarInt(w, int32(v.Len())); err != nil {
+				return fmt.Errorf("failed to write length (%d): %w", v.Len(), err)
+			}
+			for i := range v.Len() {
+				if err := elem.encode(w, v.Index(i), version); err != nil {
+					return fmt.Errorf("failed to write element %d: %w", i, err)
+				}
+			}
+			return nil
+		},
+		decode: func(r io.Reader, v reflect.Value, version int32) error {
+			length, err := read.VarInt(r)
+			if err != nil {
+				return fmt.Errorf("failed to read length: %w", err)
+			}
+			if length < 0 {
+				return fmt.Errorf("negative length %d", length)
+			}
+			// Don't trust the length to preallocate; it may be bogus.
+			s := reflect.Zero(t)
+			for i := range int(length) {
+				e := reflect.New(t.Elem()).Elem()
+				if err := elem.decode(r, e, version); err != nil {
+					return fmt.Errorf("failed to read element %d of %d: %w", i, length, err)
+				}
+				s = reflect.Append(s, e)
+			}
+			v.Set(s)
+			return nil
+		},
+	}, nil
+}
+
+// optionalCoder returns a coder of pointers prefixed with whether they're set.
+func optionalCoder(t reflect.Type, opts tagOptions) (coder, error) {
+	elem, err := typeCoder(t.Elem(), opts)
+	if err != nil {
+		return coder{}, err
+	}
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, version int32) error {
+			if err := write.Bool(w, !v.IsNil()); err != nil {
+				return fmt.Errorf("failed to write whether present: %w", err)
+			}
+			if v.IsNil() {
+				return nil
+			}
+			return elem.encode(w, v.Elem(), version)
+		},
+		decode: func(r io.Reader, v reflect.Value, version int32) error {
+			present, err := read.Bool(r)
+			if err != nil {
+				return fmt.Errorf("failed to read whether present: %w", err)
+			}
+			if !present {
+				v.SetZero()
+				return nil
+			}
+			e := reflect.New(t.Elem())
+			if err := elem.decode(r, e.Elem(), version); err != nil {
+				return err
+			}
+			v.Set(e)
+			return nil
+		},
+	}, nil
+}
+
+// restCoder returns a coder of byte slices that are the rest of the packet.
+func restCoder() coder {
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, _ int32) error {
+			return write.Bytes(w, v.Bytes())
+		},
+		decode: func(r io.Reader, v reflect.Value, _ int32) error {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if len(b) == 0 {
+				b = nil
+			}
+			v.SetBytes(b)
+			return nil
+		},
+	}
+}
+
+// jsonCoder returns a coder of values marshalled as JSON strings.
+func jsonCoder() coder {
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, _ int32) error {
+			b, err := json.Marshal(v.Interface())
+			if err != nil {
+				return fmt.Errorf("failed to marshal JSON: %w", err)
+			}
+			return write.String(w, string(b))
+		},
+		decode: func(r io.Reader, v reflect.Value, _ int32) error {
+			s, err := read.String(r)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(s), v.Addr().Interface()); err != nil {
+				return fmt.Errorf("failed to unmarshal JSON: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// nbtCoder returns a coder of values encoded as network NBT.
+func nbtCoder() coder {
+	return coder{
+		encode: func(w io.Writer, v reflect.Value, _ int32) error {
+			if v.Kind() == reflect.Map && v.IsNil() {
+				return write.Byte(w, byte(nbt.TagEnd))
+			}
+			return nbt.NewNetworkEncoder(w).Encode(v.Interface())
+		},
+		decode: func(r io.Reader, v reflect.Value, _ int32) error {
+			_, err := nbt.NewNetworkDecoder(r).Decode(v.Addr().Interface())
+			return err
+		},
+	}
+}
@@ -1,17 +1,14 @@
 package config
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
 
 	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/codec"
 	"github.com/airforce270/mc-srv/packet/id"
 	"github.com/airforce270/mc-srv/packet/types"
-	"github.com/airforce270/mc-srv/packet/writepacket"
-	"github.com/airforce270/mc-srv/read"
-	"github.com/airforce270/mc-srv/write"
+	"github.com/airforce270/mc-srv/registry"
 	"github.com/google/uuid"
 )
 
@@ -41,7 +38,7 @@ type ConfigClientInformation struct {
 	ViewDistance byte
 	// Player's chat mode.
 	// See https://wiki.vg/Chat#Client_chat_mode for more info.
-	ChatMode ChatMode
+	ChatMode ChatMode `mc:"varint"`
 	// "Colors" multiplayer setting. Whether the chat can be colored.
 	ChatColorsEnabled bool
 	// Displayed skin parts.
@@ -56,7 +53,7 @@ type ConfigClientInformation struct {
 	// The most significant bit (bit 7, 0x80) appears to be unused.
 	DisplayedSkinParts byte
 	// Player's main hand.
-	MainHand MainHand
+	MainHand MainHand `mc:"varint"`
 	// Enables filtering of text on signs and written book titles.
 	// Currently always false (i.e. the filtering is disabled)
 	EnableTextFiltering bool
@@ -80,60 +77,21 @@ func (ConfigClientInformation) Name() string { return "ClientInformation(config)
 // https://wiki.vg/Protocol#Client_Information_.28configuration.29
 func ReadConfigClientInformation(r io.Reader, header packet.Header) (ConfigClientInformation, error) {
 	p := ConfigClientInformation{Header: header}
-
-	var err error
-
-	p.Locale, err = read.String(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read locale: %w", err)
-	}
-
-	p.ViewDistance, err = read.Byte(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read view distance: %w", err)
-	}
-
-	chatMode, err := read.VarInt(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read chat mode: %w", err)
-	}
-	p.ChatMode = ChatMode(chatMode)
-
-	p.ChatColorsEnabled, err = read.Bool(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read chat colors enabled: %w", err)
-	}
-
-	p.DisplayedSkinParts, err = read.Byte(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read displayed skin parts: %w", err)
-	}
-
-	mainHand, err := read.VarInt(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read main hand: %w", err)
-	}
-	p.MainHand = MainHand(mainHand)
-
-	p.EnableTextFiltering, err = read.Bool(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read enable text filtering: %w", err)
-	}
-
-	p.AllowServerListings, err = read.Bool(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read allow server listings: %w", err)
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read client information: %w", err)
 	}
-
 	return p, nil
 }
 
 // Packet for mods and plugins to send data client->server.
 type ConfigServerboundPlugin struct {
 	packet.Header
+	// Name of the plugin channel used to send the data.
+	Channel types.Identifier
 	// Any data, depending on the channel.
 	// `minecraft:` channels are documented here: https://wiki.vg/Plugin_channel
-	Data []byte
+	// and decoded by package plugin.
+	Data []byte `mc:"rest"`
 }
 
 func (ConfigServerboundPlugin) Name() string { return "ServerboundPlugin(config)" }
@@ -143,17 +101,27 @@ func (ConfigServerboundPlugin) Name() string { return "ServerboundPlugin(config)
 // https://wiki.vg/Protocol#Serverbound_Plugin_.28configuration.29
 func ReadConfigServerboundPlugin(r io.Reader, header packet.Header) (ConfigServerboundPlugin, error) {
 	p := ConfigServerboundPlugin{Header: header}
-
-	var err error
-
-	p.Data, err = io.ReadAll(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read data: %w", err)
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read serverbound plugin: %w", err)
 	}
+	return p, nil
+}
 
-	// TODO: convert/decode the data
+// Packet for mods and plugins to send data server->client.
+// https://wiki.vg/Protocol#Clientbound_Plugin_Message_.28configuration.29
+type ClientboundPlugin struct {
+	packet.Header
+	// Name of the plugin channel used to send the data.
+	Channel types.Identifier
+	// Any data, depending on the channel.
+	Data []byte `mc:"rest"`
+}
 
-	return p, nil
+func (ClientboundPlugin) Name() string { return "ClientboundPlugin(config)" }
+
+// Write writes the ClientboundPlugin to the writer.
+func (p *ClientboundPlugin) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.ClientboundPlugin, p)
 }
 
 // Packet sent by the server to notify the client they should disconnect.
@@ -168,21 +136,122 @@ func (Disconnect) Name() string { return "Disconnect(config)" }
 
 // Write writes the Disconnect to the writer.
 func (p *Disconnect) Write(w io.Writer) error {
-	var buf bytes.Buffer
+	return codec.WritePacket(w, id.ConfigDisconnect, p)
+}
 
-	reason, err := json.Marshal(p.Reason)
-	if err != nil {
-		return fmt.Errorf("failed to marshal disconnect reason: %w", err)
-	}
-	if err := write.String(&buf, string(reason)); err != nil {
-		return fmt.Errorf("failed to write disconnect reason: %w", err)
-	}
+// Packet sent by the server containing the registries
+// the client can't know ahead of time, such as dimension types and biomes.
+// https://wiki.vg/Protocol#Registry_Data
+type RegistryData struct {
+	packet.Header
+	// The registries, as a network NBT compound
+	// (see registry.Codec).
+	RegistryCodec []byte `mc:"rest"`
+}
+
+func (RegistryData) Name() string { return "RegistryData" }
+
+// Write writes the RegistryData to the writer.
+func (p *RegistryData) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.RegistryData, p)
+}
+
+// Packet sent by the server containing the entries of one registry.
+// Replaced RegistryData in 1.20.5.
+// https://wiki.vg/Protocol#Registry_Data
+type Registry struct {
+	packet.Header
+	// Name of the registry, e.g. "minecraft:dimension_type".
+	RegistryID string
+	// Entries in the registry.
+	// An entry's index is its network ID.
+	Entries []RegistryEntry
+}
+
+// A RegistryEntry is an entry sent in the Registry packet.
+type RegistryEntry struct {
+	// Name of the entry, e.g. "minecraft:overworld".
+	ID string
+	// The entry's data, as a network NBT compound.
+	// Nil if the client already has it from a known pack.
+	Data *map[string]any
+}
+
+func (Registry) Name() string { return "Registry" }
+
+// Packet sent by the server to tell the client which data packs
+// it has, so the client can use its own copy of their registry data.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Clientbound_Known_Packs
+type ClientboundKnownPacks struct {
+	packet.Header
+	// The server's packs.
+	Packs []KnownPack
+}
+
+// A KnownPack is a data pack known by the client or server.
+type KnownPack struct {
+	// Namespace of the pack, e.g. "minecraft".
+	Namespace string
+	// ID of the pack, e.g. "core".
+	ID string
+	// Version of the pack, e.g. "1.21".
+	Version string
+}
 
-	if err := writepacket.Write(w, id.ConfigDisconnect, &buf); err != nil {
-		return fmt.Errorf("failed to write disconnect packet: %w", err)
+func (ClientboundKnownPacks) Name() string { return "ClientboundKnownPacks" }
+
+// Packet sent by the client in response to ClientboundKnownPacks
+// with the server's packs it also has.
+// Added in 1.20.5.
+type ServerboundKnownPacks struct {
+	packet.Header
+	// The packs the client has.
+	Packs []KnownPack
+}
+
+func (ServerboundKnownPacks) Name() string { return "ServerboundKnownPacks" }
+
+// ReadServerboundKnownPacks reads a Serverbound Known Packs packet
+// from the reader.
+// https://wiki.vg/Protocol#Serverbound_Known_Packs
+func ReadServerboundKnownPacks(r io.Reader, header packet.Header) (ServerboundKnownPacks, error) {
+	p := ServerboundKnownPacks{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read serverbound known packs: %w", err)
 	}
+	return p, nil
+}
 
-	return nil
+// Packet sent by the server to enable feature flags on the client,
+// e.g. experimental features.
+// https://wiki.vg/Protocol#Feature_Flags
+type FeatureFlags struct {
+	packet.Header
+	// Identifiers of the enabled feature flags, e.g. "minecraft:vanilla".
+	Flags []string
+}
+
+func (FeatureFlags) Name() string { return "FeatureFlags" }
+
+// Write writes the FeatureFlags to the writer.
+func (p *FeatureFlags) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.FeatureFlags, p)
+}
+
+// Packet sent by the server to set the tags of registries.
+// https://wiki.vg/Protocol#Update_Tags_.28configuration.29
+type UpdateTags struct {
+	packet.Header
+	// Tags for each registry.
+	Tags []registry.RegistryTags
+}
+
+func (UpdateTags) Name() string { return "UpdateTags(config)" }
+
+// Write writes the UpdateTags to the writer.
+func (p *UpdateTags) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.ConfigUpdateTags, p)
 }
 
 // Packet sent by the server to notify the client
@@ -195,13 +264,7 @@ func (FinishConfiguration) Name() string { return "FinishConfiguration" }
 
 // Write writes the FinishConfiguration to the writer.
 func (p *FinishConfiguration) Write(w io.Writer) error {
-	var buf bytes.Buffer
-
-	if err := writepacket.Write(w, id.FinishConfiguration, &buf); err != nil {
-		return fmt.Errorf("failed to write finish configuration packet: %w", err)
-	}
-
-	return nil
+	return codec.WritePacket(w, id.FinishConfiguration, p)
 }
 
 // Packet sent by the client to notify the server
@@ -213,6 +276,13 @@ type AcknowledgeFinishConfiguration struct {
 
 func (AcknowledgeFinishConfiguration) Name() string { return "AcknowledgeFinishConfiguration" }
 
+// ReadAcknowledgeFinishConfiguration reads an Acknowledge Finish Configuration
+// packet from the reader.
+// https://wiki.vg/Protocol#Acknowledge_Finish_Configuration
+func ReadAcknowledgeFinishConfiguration(r io.Reader, header packet.Header) (AcknowledgeFinishConfiguration, error) {
+	return AcknowledgeFinishConfiguration{Header: header}, nil
+}
+
 // Server->client ping indicating the server is still alive.
 // If the client doesn't receive a keepalive at least every 20 seconds,
 // it will disconnect.
@@ -228,17 +298,7 @@ func (ClientboundKeepAlive) Name() string { return "ClientboundKeepAlive" }
 
 // Write writes the ClientboundKeepAlive to the writer.
 func (p *ClientboundKeepAlive) Write(w io.Writer) error {
-	var buf bytes.Buffer
-
-	if err := write.Long(&buf, p.KeepAliveID); err != nil {
-		return fmt.Errorf("failed to write clientbound keepalive id %d: %w", p.KeepAliveID, err)
-	}
-
-	if err := writepacket.Write(w, id.ClientboundKeepAlive, &buf); err != nil {
-		return fmt.Errorf("failed to write clientbound keepalive packet: %w", err)
-	}
-
-	return nil
+	return codec.WritePacket(w, id.ClientboundKeepAlive, p)
 }
 
 // Client->server response to the server->client keep alive packets.
@@ -255,14 +315,9 @@ func (ServerboundKeepAlive) Name() string { return "ServerboundKeepAlive" }
 // https://wiki.vg/Protocol#Serverbound_Keep_Alive_.28configuration.29
 func ReadServerboundKeepAlive(r io.Reader, header packet.Header) (ServerboundKeepAlive, error) {
 	p := ServerboundKeepAlive{Header: header}
-
-	var err error
-
-	p.KeepAliveID, err = read.Long(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read ID: %w", err)
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read serverbound keepalive: %w", err)
 	}
-
 	return p, nil
 }
 
@@ -277,17 +332,7 @@ func (ConfigPing) Name() string { return "Ping(config)" }
 
 // Write writes the ConfigPing to the writer.
 func (p *ConfigPing) Write(w io.Writer) error {
-	var buf bytes.Buffer
-
-	if err := write.Int(&buf, p.PingID); err != nil {
-		return fmt.Errorf("failed to write config ping ID: %w", err)
-	}
-
-	if err := writepacket.Write(w, id.Ping, &buf); err != nil {
-		return fmt.Errorf("failed to write config ping packet: %w", err)
-	}
-
-	return nil
+	return codec.WritePacket(w, id.Ping, p)
 }
 
 // Client->server response to the server->client ping packets.
@@ -303,15 +348,56 @@ func (ConfigPong) Name() string { return "Pong(config)" }
 // https://wiki.vg/Protocol#Pong_.28configuration.29
 func ReadConfigPong(r io.Reader, header packet.Header) (ConfigPong, error) {
 	p := ConfigPong{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read pong: %w", err)
+	}
+	return p, nil
+}
 
-	var err error
+// Packet sent by the server to ask the client to apply a resource pack.
+// https://wiki.vg/Protocol#Add_Resource_Pack_.28configuration.29
+type ConfigAddResourcePack struct {
+	packet.Header
+	// The unique identifier of the resource pack,
+	// sent back in ConfigResourcePackResponse.
+	UUID uuid.UUID
+	// The URL to the resource pack.
+	URL string
+	// A 40 character hexadecimal, case-insensitive SHA-1 hash
+	// of the resource pack file.
+	// If it's not a 40 character hexadecimal string,
+	// the client will not use it for hash verification
+	// and likely waste bandwidth.
+	Hash string
+	// The Notchian client will be forced to use the resource pack
+	// from the server. If they decline, they will be kicked from the server.
+	Forced bool
+	// Shown in the prompt making the client accept or decline
+	// the resource pack, if set.
+	PromptMessage *types.TextComponent
+}
 
-	p.PingID, err = read.Int(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read ID: %w", err)
-	}
+func (ConfigAddResourcePack) Name() string { return "AddResourcePack(config)" }
 
-	return p, nil
+// Write writes the ConfigAddResourcePack to the writer.
+func (p *ConfigAddResourcePack) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.ConfigAddResourcePack, p)
+}
+
+// Packet sent by the server to ask the client to remove a resource pack.
+// https://wiki.vg/Protocol#Remove_Resource_Pack_.28configuration.29
+type ConfigRemoveResourcePack struct {
+	packet.Header
+	// The UUID of the resource pack to be removed,
+	// or nil to remove all of them.
+	UUID *uuid.UUID
+}
+
+func (ConfigRemoveResourcePack) Name() string { return "RemoveResourcePack(config)" }
+
+// Write writes the ConfigRemoveResourcePack to the writer.
+func (p *ConfigRemoveResourcePack) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.ConfigRemoveResourcePack, p)
 }
 
 // Resource pack result ID, for ConfigResourcePackResponse.
@@ -328,14 +414,29 @@ const (
 	ResourcePackResultDiscarded              ResourcePackResult = 7
 )
 
-// Resource pack information client->server (?)
+// Final returns whether the result is the last one
+// the client sends for the resource pack,
+// i.e. it's not still being downloaded or applied.
+func (r ResourcePackResult) Final() bool {
+	return r != ResourcePackResultAccepted && r != ResourcePackResultDownloaded
+}
+
+// Succeeded returns whether the client applied the resource pack.
+func (r ResourcePackResult) Succeeded() bool {
+	return r == ResourcePackResultSuccessfullyDownloaded
+}
+
+// Packet sent by the client with the progress of
+// applying a resource pack from ConfigAddResourcePack.
+// It's sent several times per pack, e.g. accepted, downloaded and then
+// successfully downloaded.
 type ConfigResourcePackResponse struct {
 	packet.Header
 	// The unique identifier of the resource pack
 	// received in ConfigAddResourcePack.
 	ResourcePackUUID uuid.UUID
 	// The result ID.
-	Result ResourcePackResult
+	Result ResourcePackResult `mc:"varint"`
 }
 
 func (ConfigResourcePackResponse) Name() string { return "ResourcePackResponse(config)" }
@@ -345,19 +446,75 @@ func (ConfigResourcePackResponse) Name() string { return "ResourcePackResponse(c
 // https://wiki.vg/Protocol#Resource_Pack_Response_.28configuration.29
 func ReadConfigResourcePackResponse(r io.Reader, header packet.Header) (ConfigResourcePackResponse, error) {
 	p := ConfigResourcePackResponse{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read resource pack response: %w", err)
+	}
+	return p, nil
+}
 
-	var err error
+// Packet sent by the server to request a cookie
+// the client stored for it, e.g. before a transfer.
+// Same as login.CookieRequest, but for the configuration state.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Cookie_Request_.28configuration.29
+type CookieRequest struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+}
 
-	p.ResourcePackUUID, err = read.UUID(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read resource pack uuid: %w", err)
-	}
+func (CookieRequest) Name() string { return "CookieRequest(config)" }
 
-	result, err := read.VarInt(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read resource pack result: %w", err)
-	}
-	p.Result = ResourcePackResult(result)
+// Packet sent by the client in response to a CookieRequest.
+// Same as login.CookieResponse, but for the configuration state.
+// Added in 1.20.5.
+type CookieResponse struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+	// The data of the cookie, at most 5120 bytes,
+	// or nil if the client doesn't have it.
+	Payload *[]byte `mc:"max=5120"`
+}
 
+func (CookieResponse) Name() string { return "CookieResponse(config)" }
+
+// ReadCookieResponse reads a Cookie Response (configuration) packet from the reader.
+// https://wiki.vg/Protocol#Cookie_Response_.28configuration.29
+func ReadCookieResponse(r io.Reader, header packet.Header) (CookieResponse, error) {
+	p := CookieResponse{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read cookie response: %w", err)
+	}
 	return p, nil
 }
+
+// Packet sent by the server to store a cookie on the client.
+// The client keeps its cookies across transfers,
+// so servers can pass data to the server a player is transferred to.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Store_Cookie_.28configuration.29
+type StoreCookie struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+	// The data of the cookie, at most 5120 bytes.
+	Payload []byte `mc:"max=5120"`
+}
+
+func (StoreCookie) Name() string { return "StoreCookie(config)" }
+
+// Packet sent by the server to tell the client
+// to connect to another server.
+// The client only connects if the other server accepts transfers.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Transfer_.28configuration.29
+type Transfer struct {
+	packet.Header
+	// The hostname or IP of the server.
+	Host string
+	// The port of the server.
+	Port int32 `mc:"varint"`
+}
+
+func (Transfer) Name() string { return "Transfer(config)" }
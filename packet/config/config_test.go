@@ -2,15 +2,17 @@ package config_test
 
 import (
 	"bytes"
-	"encoding/json"
+	"slices"
 	"testing"
 
+	"github.com/airforce270/mc-srv/nbt"
 	"github.com/airforce270/mc-srv/packet"
 	"github.com/airforce270/mc-srv/packet/config"
 	"github.com/airforce270/mc-srv/packet/config/configtest"
 	"github.com/airforce270/mc-srv/packet/id"
 	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/registry"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 )
@@ -78,11 +80,9 @@ func TestReadConfigServerboundPlugin(t *testing.T) {
 			desc:  "notchian example",
 			input: configtest.NotchianServerboundPlugin,
 			want: config.ConfigServerboundPlugin{
-				Header: inHeader,
-				Data: []byte{
-					15, 109, 105, 110, 101, 99, 114, 97, 102, 116, 58, 98,
-					114, 97, 110, 100, 7, 118, 97, 110, 105, 108, 108, 97,
-				},
+				Header:  inHeader,
+				Channel: "minecraft:brand",
+				Data:    []byte{7, 118, 97, 110, 105, 108, 108, 97},
 			},
 		},
 	}
@@ -118,18 +118,13 @@ func TestWriteDisconnect(t *testing.T) {
 		t.Fatalf("Disconnect.Write() unexpected error reading header: %v", err)
 	}
 
-	const wantLength = 25
+	const wantLength = 24
 	if h.Length != wantLength {
 		t.Errorf("Disconnect.Write() header length = %d, want %d", h.Length, wantLength)
 	}
 
-	reasonStr, err := read.String(&buf)
-	if err != nil {
-		t.Fatalf("Disconnect.Write() unexpected error reading reason: %v", err)
-	}
-
 	var gotReason types.TextComponent
-	if err := json.Unmarshal([]byte(reasonStr), &gotReason); err != nil {
+	if err := nbt.UnmarshalNetwork(buf.Bytes(), &gotReason); err != nil {
 		t.Fatalf("Disconnect.Write() unexpected error unmarshaling reason: %v", err)
 	}
 
@@ -138,6 +133,165 @@ func TestWriteDisconnect(t *testing.T) {
 	}
 }
 
+func TestWriteClientboundPlugin(t *testing.T) {
+	p := config.ClientboundPlugin{Channel: "minecraft:brand", Data: []byte{0x06, 'm', 'c', '-', 's', 'r', 'v'}}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("ClientboundPlugin.Write() unexpected error writing: %v", err)
+	}
+
+	want := []byte{
+		// length
+		0x18,
+		// packet ID
+		0x00,
+		// channel
+		0x0f, 'm', 'i', 'n', 'e', 'c', 'r', 'a', 'f', 't', ':', 'b', 'r', 'a', 'n', 'd',
+		// data
+		0x06, 'm', 'c', '-', 's', 'r', 'v',
+	}
+	if diff := cmp.Diff(want, buf.Bytes()); diff != "" {
+		t.Errorf("ClientboundPlugin.Write() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWriteConfigAddResourcePack(t *testing.T) {
+	t.Parallel()
+
+	packUUID := uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8")
+	tests := []struct {
+		desc string
+		in   config.ConfigAddResourcePack
+		want []byte
+	}{
+		{
+			desc: "without prompt",
+			in: config.ConfigAddResourcePack{
+				UUID:   packUUID,
+				URL:    "http://a/p.zip",
+				Hash:   "",
+				Forced: true,
+			},
+			want: []byte{
+				// length
+				0x23,
+				// packet ID
+				0x07,
+				// UUID
+				0x89, 0x96, 0xcb, 0x86, 0xcb, 0x63, 0x4c, 0x2d,
+				0x8b, 0x45, 0x7c, 0xdf, 0xd7, 0xb5, 0x42, 0xc8,
+				// URL
+				0x0e, 'h', 't', 't', 'p', ':', '/', '/', 'a', '/', 'p', '.', 'z', 'i', 'p',
+				// hash
+				0x00,
+				// forced
+				0x01,
+				// has prompt message
+				0x00,
+			},
+		},
+		{
+			desc: "with prompt",
+			in: config.ConfigAddResourcePack{
+				UUID:          packUUID,
+				URL:           "http://a/p.zip",
+				Hash:          "",
+				Forced:        false,
+				PromptMessage: &types.TextComponent{Text: "hi"},
+			},
+			want: []byte{
+				// length
+				0x30,
+				// packet ID
+				0x07,
+				// UUID
+				0x89, 0x96, 0xcb, 0x86, 0xcb, 0x63, 0x4c, 0x2d,
+				0x8b, 0x45, 0x7c, 0xdf, 0xd7, 0xb5, 0x42, 0xc8,
+				// URL
+				0x0e, 'h', 't', 't', 'p', ':', '/', '/', 'a', '/', 'p', '.', 'z', 'i', 'p',
+				// hash
+				0x00,
+				// forced
+				0x00,
+				// has prompt message
+				0x01,
+				// prompt message: compound with the string "text": "hi"
+				0x0a,
+				0x08, 0x00, 0x04, 't', 'e', 'x', 't', 0x00, 0x02, 'h', 'i',
+				0x00,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := tc.in.Write(&buf); err != nil {
+				t.Fatalf("ConfigAddResourcePack.Write() unexpected error writing: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, buf.Bytes()); diff != "" {
+				t.Errorf("ConfigAddResourcePack.Write() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteConfigRemoveResourcePack(t *testing.T) {
+	t.Parallel()
+
+	packUUID := uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8")
+	tests := []struct {
+		desc string
+		in   config.ConfigRemoveResourcePack
+		want []byte
+	}{
+		{
+			desc: "one",
+			in:   config.ConfigRemoveResourcePack{UUID: &packUUID},
+			want: []byte{
+				// length
+				0x12,
+				// packet ID
+				0x06,
+				// has UUID
+				0x01,
+				// UUID
+				0x89, 0x96, 0xcb, 0x86, 0xcb, 0x63, 0x4c, 0x2d,
+				0x8b, 0x45, 0x7c, 0xdf, 0xd7, 0xb5, 0x42, 0xc8,
+			},
+		},
+		{
+			desc: "all",
+			in:   config.ConfigRemoveResourcePack{},
+			want: []byte{
+				// length
+				0x02,
+				// packet ID
+				0x06,
+				// has UUID
+				0x00,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := tc.in.Write(&buf); err != nil {
+				t.Fatalf("ConfigRemoveResourcePack.Write() unexpected error writing: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, buf.Bytes()); diff != "" {
+				t.Errorf("ConfigRemoveResourcePack.Write() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestWriteFinishConfiguration(t *testing.T) {
 	p := config.FinishConfiguration{}
 
@@ -157,6 +311,66 @@ func TestWriteFinishConfiguration(t *testing.T) {
 	}
 }
 
+func TestWriteFeatureFlags(t *testing.T) {
+	p := config.FeatureFlags{Flags: []string{"a:b", "c:d"}}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("FeatureFlags.Write() unexpected error writing: %v", err)
+	}
+
+	want := slices.Concat(
+		// header
+		[]byte{0x0a, 0x08},
+		// flag count
+		[]byte{0x02},
+		// flags
+		[]byte{0x03, 'a', ':', 'b'},
+		[]byte{0x03, 'c', ':', 'd'},
+	)
+	if diff := cmp.Diff(want, buf.Bytes()); diff != "" {
+		t.Errorf("FeatureFlags.Write() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWriteUpdateTags(t *testing.T) {
+	p := config.UpdateTags{
+		Tags: []registry.RegistryTags{
+			{
+				Registry: "a:b",
+				Tags: []registry.Tag{
+					{Name: "c:d", Entries: []int32{1, 300}},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("UpdateTags.Write() unexpected error writing: %v", err)
+	}
+
+	want := slices.Concat(
+		// header
+		[]byte{0x0f, 0x09},
+		// registry count
+		[]byte{0x01},
+		// registry
+		[]byte{0x03, 'a', ':', 'b'},
+		// tag count
+		[]byte{0x01},
+		// tag name
+		[]byte{0x03, 'c', ':', 'd'},
+		// entry count
+		[]byte{0x02},
+		// entries
+		[]byte{0x01, 0xac, 0x02},
+	)
+	if diff := cmp.Diff(want, buf.Bytes()); diff != "" {
+		t.Errorf("UpdateTags.Write() diff (-want, +got):\n%s", diff)
+	}
+}
+
 func TestWriteClientboundKeepAlive(t *testing.T) {
 	var keepAliveID int64 = 1234
 	p := config.ClientboundKeepAlive{KeepAliveID: keepAliveID}
@@ -9,6 +9,10 @@ import (
 	"github.com/airforce270/mc-srv/write"
 )
 
+// MaxLength is the maximum length of a packet,
+// the largest a 3-byte VarInt can hold.
+const MaxLength = 1<<21 - 1
+
 // Common fields that every packet has.
 type Header struct {
 	// Length is the length of the PacketID and following data in the packet.
@@ -41,6 +45,10 @@ func ReadHeader(r io.Reader) (Header, error) {
 	if h.Length == 0 {
 		return h, nil
 	}
+	// Checked before reading further, since it may be bogus.
+	if h.Length < 0 || h.Length > MaxLength {
+		return h, fmt.Errorf("packet length %d out of bounds (max=%d)", h.Length, MaxLength)
+	}
 
 	packetID, err := read.VarInt(r)
 	if err != nil {
@@ -2,6 +2,7 @@ package packet
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/airforce270/mc-srv/packet/headertest"
@@ -50,3 +51,32 @@ func TestReadHeader(t *testing.T) {
 		})
 	}
 }
+
+func TestReadHeaderOutOfBounds(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input []byte
+	}{
+		{
+			desc:  "too long",
+			input: []byte{0x80, 0x80, 0x80, 0x01}, // 2^21
+		},
+		{
+			desc:  "negative",
+			input: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, // -1
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// No packet ID follows, so it mustn't be read.
+			if got, err := ReadHeader(bytes.NewReader(tc.input)); err == nil || !strings.Contains(err.Error(), "out of bounds") {
+				t.Errorf("ReadHeader() = %+v, %v, want an out of bounds error", got, err)
+			}
+		})
+	}
+}
@@ -7,11 +7,15 @@ import (
 )
 
 // ID is a packet ID.
+// The IDs below are those of protocol 765 (1.20.4);
+// other versions' IDs are registered in package protocol.
 type ID int32
 
 // Len returns the length of the packet ID, in serialized bytes.
 func (i ID) Len() int {
+	idLengthCacheMtx.RLock()
 	length, ok := idLengthCache[i]
+	idLengthCacheMtx.RUnlock()
 	if ok {
 		return length
 	}
@@ -30,8 +34,10 @@ const (
 	HandshakePing ID = 0x01
 
 	// Login
-	LoginStart         ID = 0x00
-	EncryptionResponse ID = 0x01
+	LoginStart           ID = 0x00
+	EncryptionResponse   ID = 0x01
+	LoginPluginResponse  ID = 0x02
+	LoginAcknowledgement ID = 0x03
 
 	// Configuration
 	ClientInformation    ID = 0x00
@@ -42,6 +48,9 @@ const (
 	ResourcePackResponse ID = 0x05
 
 	// Play
+	PlayChangeDifficulty     ID = 0x0B
+	PlayServerboundPlugin    ID = 0x10
+	PlayServerboundKeepAlive ID = 0x15
 )
 
 // Response (Server->Client) packet IDs.
@@ -51,9 +60,11 @@ const (
 	HandshakePong  ID = 0x01
 
 	// Login
-	EncryptionRequest    ID = 0x01
-	LoginSuccess         ID = 0x02
-	LoginAcknowledgement ID = 0x03
+	LoginDisconnect    ID = 0x00
+	EncryptionRequest  ID = 0x01
+	LoginSuccess       ID = 0x02
+	SetCompression     ID = 0x03
+	LoginPluginRequest ID = 0x04
 
 	// Configuration
 	ClientboundPlugin        ID = 0x00
@@ -63,14 +74,18 @@ const (
 	Ping                     ID = 0x04
 	RegistryData             ID = 0x05
 	ConfigRemoveResourcePack ID = 0x06
-	ConfigAddResourcePack    ID = 0x06
+	ConfigAddResourcePack    ID = 0x07
 	FeatureFlags             ID = 0x08
 	ConfigUpdateTags         ID = 0x09
 
 	// Play
+	PlayClientboundPlugin    ID = 0x18
+	PlayDisconnect           ID = 0x1B
+	PlayClientboundKeepAlive ID = 0x24
+	PlayLogin                ID = 0x29
 )
 
 var (
 	idLengthCache    = map[ID]int{}
-	idLengthCacheMtx sync.Mutex
+	idLengthCacheMtx sync.RWMutex
 )
@@ -1,19 +1,33 @@
 package login
 
 import (
-	"bytes"
 	"fmt"
 	"io"
-	"log"
 
 	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/codec"
 	"github.com/airforce270/mc-srv/packet/id"
-	"github.com/airforce270/mc-srv/packet/writepacket"
-	"github.com/airforce270/mc-srv/read"
-	"github.com/airforce270/mc-srv/write"
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/google/uuid"
 )
 
+// Packet sent by the server to notify the client they should disconnect
+// during login.
+type Disconnect struct {
+	packet.Header
+
+	// The reason the client was disconnected.
+	// Unlike later states, it's written as JSON.
+	Reason types.TextComponent `mc:"json"`
+}
+
+func (Disconnect) Name() string { return "Disconnect(login)" }
+
+// Write writes the Disconnect to the writer.
+func (p Disconnect) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.LoginDisconnect, p)
+}
+
 // Packet sent to initiate login.
 type LoginStart struct {
 	packet.Header
@@ -29,19 +43,9 @@ func (LoginStart) Name() string { return "LoginStart" }
 // https://wiki.vg/Protocol#Login_Start
 func ReadLoginStart(r io.Reader, header packet.Header) (LoginStart, error) {
 	p := LoginStart{Header: header}
-
-	var err error
-
-	p.PlayerName, err = read.String(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read player name: %w", err)
-	}
-
-	p.PlayerUUID, err = read.UUID(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read player UUID: %w", err)
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read login start: %w", err)
 	}
-
 	return p, nil
 }
 
@@ -50,57 +54,31 @@ func ReadLoginStart(r io.Reader, header packet.Header) (LoginStart, error) {
 type EncryptionRequest struct {
 	// Server ID - is usually empty.
 	ServerID string
-	// Length of Public Key.
-	PublicKeyLength int32
 	// The server's public key, in bytes.
-	PublicKey []byte
-	// Length of Verify Token. Always 4 for Notchian servers.
-	VerifyTokenLength int32
+	PublicKey []byte `mc:"prefixed"`
 	// A sequence of random bytes generated by the server.
-	VerifyToken []byte
+	// Always 4 bytes for Notchian servers.
+	VerifyToken []byte `mc:"prefixed"`
+	// Whether the client should authenticate with Mojang's servers.
+	// Added in 1.20.5.
+	ShouldAuthenticate bool `mc:"since=766"`
 }
 
 func (EncryptionRequest) Name() string { return "EncryptionRequest" }
 
 // Write writes the EncryptionRequest to the writer.
 func (r EncryptionRequest) Write(w io.Writer) error {
-	var buf bytes.Buffer
-
-	if err := write.String(&buf, r.ServerID); err != nil {
-		return fmt.Errorf("failed to write server ID: %w", err)
-	}
-	if err := write.VarInt(&buf, r.PublicKeyLength); err != nil {
-		return fmt.Errorf("failed to write public key length: %w", err)
-	}
-	if err := write.Bytes(&buf, r.PublicKey); err != nil {
-		return fmt.Errorf("failed to write public key: %w", err)
-	}
-	if err := write.VarInt(&buf, r.VerifyTokenLength); err != nil {
-		return fmt.Errorf("failed to write verify token length: %w", err)
-	}
-	if err := write.Bytes(&buf, r.VerifyToken); err != nil {
-		return fmt.Errorf("failed to write verify token: %w", err)
-	}
-
-	if err := writepacket.Write(w, id.EncryptionRequest, &buf); err != nil {
-		return fmt.Errorf("failed to write packet: %w", err)
-	}
-
-	return nil
+	return codec.WritePacket(w, id.EncryptionRequest, r)
 }
 
 // Packet sent to complete encryption.
 type EncryptionResponse struct {
 	packet.Header
-	// Length of Shared Secret.
-	SharedSecretLength int32
 	// Shared Secret value, encrypted with the server's public key.
-	SharedSecret []byte
-	// Length of Verify Token.
-	VerifyTokenLength int32
+	SharedSecret []byte `mc:"prefixed"`
 	// Verify Token value,
 	// encrypted with the same public key as the shared secret.
-	VerifyToken []byte
+	VerifyToken []byte `mc:"prefixed"`
 }
 
 func (EncryptionResponse) Name() string { return "EncryptionResponse" }
@@ -109,29 +87,67 @@ func (EncryptionResponse) Name() string { return "EncryptionResponse" }
 // https://wiki.vg/Protocol#Encryption_Response
 func ReadEncryptionResponse(r io.Reader, header packet.Header) (EncryptionResponse, error) {
 	p := EncryptionResponse{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read encryption response: %w", err)
+	}
+	return p, nil
+}
 
-	var err error
+// Packet to the client to enable compression.
+// All packets after this one use the compressed packet format.
+// https://wiki.vg/Protocol#Set_Compression
+type SetCompression struct {
+	// Maximum size of a packet before it is compressed.
+	Threshold int32 `mc:"varint"`
+}
 
-	p.SharedSecretLength, err = read.VarInt(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read shared secret length: %w", err)
-	}
+func (SetCompression) Name() string { return "SetCompression" }
 
-	p.SharedSecret, err = read.Bytes(r, int(p.SharedSecretLength))
-	if err != nil {
-		return p, fmt.Errorf("failed to read shared secret: %w", err)
-	}
+// Write writes the SetCompression to the writer.
+func (s SetCompression) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.SetCompression, s)
+}
 
-	p.VerifyTokenLength, err = read.VarInt(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read verify token length: %w", err)
-	}
+// Packet to the client to request custom data during login,
+// e.g. player info from a proxy.
+// https://wiki.vg/Protocol#Login_Plugin_Request
+type LoginPluginRequest struct {
+	// Generated by the server, unique to the connection.
+	MessageID int32 `mc:"varint"`
+	// Name of the plugin channel used to send the data.
+	Channel string
+	// Any data, depending on the channel.
+	Data []byte `mc:"rest"`
+}
 
-	p.VerifyToken, err = read.Bytes(r, int(p.VerifyTokenLength))
-	if err != nil {
-		return p, fmt.Errorf("failed to read verify token: %w", err)
-	}
+func (LoginPluginRequest) Name() string { return "LoginPluginRequest" }
+
+// Write writes the LoginPluginRequest to the writer.
+func (r LoginPluginRequest) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.LoginPluginRequest, r)
+}
+
+// Packet sent by the client in response to a LoginPluginRequest.
+type LoginPluginResponse struct {
+	packet.Header
+	// Should match ID from server.
+	MessageID int32 `mc:"varint"`
+	// Whether the client understood the request.
+	Successful bool
+	// Any data, depending on the channel.
+	// Only present if Successful is true.
+	Data []byte `mc:"rest"`
+}
 
+func (LoginPluginResponse) Name() string { return "LoginPluginResponse" }
+
+// ReadLoginPluginResponse reads a Login Plugin Response packet from the reader.
+// https://wiki.vg/Protocol#Login_Plugin_Response
+func ReadLoginPluginResponse(r io.Reader, header packet.Header) (LoginPluginResponse, error) {
+	p := LoginPluginResponse{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read login plugin response: %w", err)
+	}
 	return p, nil
 }
 
@@ -142,10 +158,13 @@ type LoginSuccess struct {
 	UUID uuid.UUID
 	// Player's username (?)
 	Username string
-	// Number of elements in Properties.
-	PropertyCount int32
-	// Properties contains some Properties (?)
+	// Properties of the player's profile,
+	// e.g. the "textures" property containing their skin.
 	Properties []LoginSuccessProperty
+	// Whether the client should disconnect on packets it fails to read,
+	// rather than ignoring them.
+	// Added in 1.20.5.
+	StrictErrorHandling bool `mc:"since=766"`
 }
 
 // A LoginSuccessProperty is a property sent in the LoginSuccess packet.
@@ -154,49 +173,15 @@ type LoginSuccessProperty struct {
 	Name string
 	// Property value.
 	Value string
-	// Whether the property is signed (?)
-	IsSigned bool
-	// Only if IsSigned is true
+	// Signature of the property, if it's signed.
 	Signature *string
 }
 
 func (LoginSuccess) Name() string { return "LoginSuccess" }
 
 // Write writes the LoginSuccess to the writer.
-func (s LoginSuccess) Write(w io.Writer, logger *log.Logger) error {
-	var buf bytes.Buffer
-
-	if err := write.UUID(&buf, s.UUID); err != nil {
-		return fmt.Errorf("failed to write uuid: %w", err)
-	}
-	if err := write.String(&buf, s.Username); err != nil {
-		return fmt.Errorf("failed to write username: %w", err)
-	}
-	if err := write.VarInt(&buf, s.PropertyCount); err != nil {
-		return fmt.Errorf("failed to write property count: %w", err)
-	}
-
-	for _, p := range s.Properties {
-		if err := write.String(&buf, p.Name); err != nil {
-			return fmt.Errorf("failed to write property.name: %w", err)
-		}
-		if err := write.String(&buf, p.Value); err != nil {
-			return fmt.Errorf("failed to write property.value: %w", err)
-		}
-		if err := write.Bool(&buf, p.IsSigned); err != nil {
-			return fmt.Errorf("failed to write property.is signed: %w", err)
-		}
-		if p.IsSigned {
-			if err := write.String(&buf, *p.Signature); err != nil {
-				return fmt.Errorf("failed to write property.signature: %w", err)
-			}
-		}
-	}
-
-	if err := writepacket.Write(w, id.LoginSuccess, &buf); err != nil {
-		return fmt.Errorf("failed to write packet: %w", err)
-	}
-	return nil
+func (s LoginSuccess) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.LoginSuccess, s)
 }
 
 // Packet sent by the client to acknowledge login success..
@@ -205,3 +190,44 @@ type LoginAcknowledgement struct {
 }
 
 func (LoginAcknowledgement) Name() string { return "LoginAcknowledgement" }
+
+// ReadLoginAcknowledgement reads a Login Acknowledged packet from the reader.
+// https://wiki.vg/Protocol#Login_Acknowledged
+func ReadLoginAcknowledgement(r io.Reader, header packet.Header) (LoginAcknowledgement, error) {
+	return LoginAcknowledgement{Header: header}, nil
+}
+
+// Packet sent by the server to request a cookie
+// the client stored for it, e.g. before a transfer.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Cookie_Request_.28login.29
+type CookieRequest struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+}
+
+func (CookieRequest) Name() string { return "CookieRequest(login)" }
+
+// Packet sent by the client in response to a CookieRequest.
+// Added in 1.20.5.
+type CookieResponse struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+	// The data of the cookie, at most 5120 bytes,
+	// or nil if the client doesn't have it.
+	Payload *[]byte `mc:"max=5120"`
+}
+
+func (CookieResponse) Name() string { return "CookieResponse(login)" }
+
+// ReadCookieResponse reads a Cookie Response (login) packet from the reader.
+// https://wiki.vg/Protocol#Cookie_Response_.28login.29
+func ReadCookieResponse(r io.Reader, header packet.Header) (CookieResponse, error) {
+	p := CookieResponse{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read cookie response: %w", err)
+	}
+	return p, nil
+}
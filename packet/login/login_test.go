@@ -9,10 +9,50 @@ import (
 	"github.com/airforce270/mc-srv/packet/id"
 	"github.com/airforce270/mc-srv/packet/login"
 	"github.com/airforce270/mc-srv/packet/login/logintest"
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 )
 
+func TestWriteDisconnect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input login.Disconnect
+		want  []byte
+	}{
+		{
+			desc:  "text",
+			input: login.Disconnect{Reason: types.TextComponent{Text: "hi"}},
+			want: slices.Concat(
+				// header
+				[]byte{0x0f, 0x00},
+				// reason
+				[]byte{0x0d}, []byte(`{"text":"hi"}`),
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteDisconnect() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteDisconnect() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestReadLoginStart(t *testing.T) {
 	t.Parallel()
 
@@ -64,11 +104,9 @@ func TestWriteEncryptionRequest(t *testing.T) {
 		{
 			desc: "standard",
 			input: login.EncryptionRequest{
-				ServerID:          "",
-				PublicKeyLength:   3,
-				PublicKey:         []byte{0x01, 0x02, 0x03},
-				VerifyTokenLength: 5,
-				VerifyToken:       []byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				ServerID:    "",
+				PublicKey:   []byte{0x01, 0x02, 0x03},
+				VerifyToken: []byte{0x01, 0x02, 0x03, 0x04, 0x05},
 			},
 			want: slices.Concat(
 				// header
@@ -126,11 +164,9 @@ func TestReadEncryptionResponse(t *testing.T) {
 			desc:  "notchian example",
 			input: logintest.NotchianEncryptionResponse,
 			want: login.EncryptionResponse{
-				Header:             inHeader,
-				SharedSecretLength: 5,
-				SharedSecret:       []byte{0x01, 0x02, 0x03, 0x04, 0x05},
-				VerifyTokenLength:  3,
-				VerifyToken:        []byte{0x01, 0x02, 0x03},
+				Header:       inHeader,
+				SharedSecret: []byte{0x01, 0x02, 0x03, 0x04, 0x05},
+				VerifyToken:  []byte{0x01, 0x02, 0x03},
 			},
 		},
 	}
@@ -150,3 +186,284 @@ func TestReadEncryptionResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteSetCompression(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input login.SetCompression
+		want  []byte
+	}{
+		{
+			desc:  "vanilla default threshold",
+			input: login.SetCompression{Threshold: 256},
+			want: slices.Concat(
+				// header
+				[]byte{0x03, 0x03},
+				// threshold
+				[]byte{0x80, 0x02},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteSetCompression() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteSetCompression() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteLoginPluginRequest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input login.LoginPluginRequest
+		want  []byte
+	}{
+		{
+			desc: "velocity player info",
+			input: login.LoginPluginRequest{
+				MessageID: 7,
+				Channel:   "velocity:player_info",
+				Data:      []byte{0x01},
+			},
+			want: slices.Concat(
+				// header
+				[]byte{0x18, 0x04},
+				// message ID
+				[]byte{0x07},
+				// channel
+				[]byte{0x14},
+				[]byte("velocity:player_info"),
+				// data
+				[]byte{0x01},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteLoginPluginRequest() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteLoginPluginRequest() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadLoginPluginResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		header packet.Header
+		input  []byte
+		want   login.LoginPluginResponse
+	}{
+		{
+			desc:   "successful",
+			header: packet.Header{Length: 6, PacketID: id.LoginPluginResponse},
+			input:  logintest.NotchianLoginPluginResponse,
+			want: login.LoginPluginResponse{
+				Header:     packet.Header{Length: 6, PacketID: id.LoginPluginResponse},
+				MessageID:  7,
+				Successful: true,
+				Data:       []byte{0x01, 0x02, 0x03},
+			},
+		},
+		{
+			desc:   "unsuccessful",
+			header: packet.Header{Length: 3, PacketID: id.LoginPluginResponse},
+			input:  logintest.NotchianLoginPluginResponseUnsuccessful,
+			want: login.LoginPluginResponse{
+				Header:     packet.Header{Length: 3, PacketID: id.LoginPluginResponse},
+				MessageID:  7,
+				Successful: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := login.ReadLoginPluginResponse(bytes.NewReader(tc.input), tc.header)
+			if err != nil {
+				t.Fatalf("ReadLoginPluginResponse() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReadLoginPluginResponse() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteLoginSuccess(t *testing.T) {
+	t.Parallel()
+
+	signature := "sig"
+
+	tests := []struct {
+		desc  string
+		input login.LoginSuccess
+		want  []byte
+	}{
+		{
+			desc: "no properties",
+			input: login.LoginSuccess{
+				UUID:     uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+				Username: "airfors",
+			},
+			want: slices.Concat(
+				// header
+				[]byte{0x1a, 0x02},
+				// UUID
+				[]byte{
+					0x89, 0x96, 0xcb, 0x86, 0xcb, 0x63, 0x4c, 0x2d,
+					0x8b, 0x45, 0x7c, 0xdf, 0xd7, 0xb5, 0x42, 0xc8,
+				},
+				// username
+				[]byte{0x07, 'a', 'i', 'r', 'f', 'o', 'r', 's'},
+				// property count
+				[]byte{0x00},
+			),
+		},
+		{
+			desc: "properties",
+			input: login.LoginSuccess{
+				UUID:     uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+				Username: "airfors",
+				Properties: []login.LoginSuccessProperty{
+					{Name: "textures", Value: "abc", Signature: &signature},
+					{Name: "x", Value: "y"},
+				},
+			},
+			want: slices.Concat(
+				// header
+				[]byte{0x31, 0x02},
+				// UUID
+				[]byte{
+					0x89, 0x96, 0xcb, 0x86, 0xcb, 0x63, 0x4c, 0x2d,
+					0x8b, 0x45, 0x7c, 0xdf, 0xd7, 0xb5, 0x42, 0xc8,
+				},
+				// username
+				[]byte{0x07, 'a', 'i', 'r', 'f', 'o', 'r', 's'},
+				// property count
+				[]byte{0x02},
+				// textures name
+				[]byte{0x08, 't', 'e', 'x', 't', 'u', 'r', 'e', 's'},
+				// textures value
+				[]byte{0x03, 'a', 'b', 'c'},
+				// textures is signed
+				[]byte{0x01},
+				// textures signature
+				[]byte{0x03, 's', 'i', 'g'},
+				// x name
+				[]byte{0x01, 'x'},
+				// x value
+				[]byte{0x01, 'y'},
+				// x is signed
+				[]byte{0x00},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteLoginSuccess() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteLoginSuccess() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadCookieResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		input   []byte
+		want    login.CookieResponse
+		wantErr bool
+	}{
+		{
+			desc: "has cookie",
+			input: []byte{
+				0x05, 'a', ':', 'b', '/', 'c', // key
+				0x01,       // has payload
+				0x02,       // payload length
+				0x68, 0x69, // payload
+			},
+			want: login.CookieResponse{Key: "a:b/c", Payload: &[]byte{0x68, 0x69}},
+		},
+		{
+			desc: "no cookie",
+			input: []byte{
+				0x05, 'a', ':', 'b', '/', 'c', // key
+				0x00, // has payload
+			},
+			want: login.CookieResponse{Key: "a:b/c"},
+		},
+		{
+			desc: "too large",
+			input: append([]byte{
+				0x05, 'a', ':', 'b', '/', 'c', // key
+				0x01,       // has payload
+				0x81, 0x28, // payload length (5121)
+			}, make([]byte, 5121)...),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := login.ReadCookieResponse(bytes.NewReader(tc.input), packet.Header{})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ReadCookieResponse() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReadCookieResponse() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
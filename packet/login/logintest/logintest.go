@@ -22,4 +22,22 @@ var (
 		0x01, 0x02, 0x03,
 	}
 	NotchianEncryptionResponseHeader = []byte{0x11, 0x00}
+
+	NotchianLoginPluginResponse = []byte{
+		// message ID
+		0x07,
+		// successful
+		0x01,
+		// data
+		0x01, 0x02, 0x03,
+	}
+	NotchianLoginPluginResponseHeader = []byte{0x06, 0x02}
+
+	NotchianLoginPluginResponseUnsuccessful = []byte{
+		// message ID
+		0x07,
+		// successful
+		0x00,
+	}
+	NotchianLoginPluginResponseUnsuccessfulHeader = []byte{0x03, 0x02}
 )
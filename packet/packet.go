@@ -2,6 +2,8 @@
 package packet
 
 import (
+	"fmt"
+
 	"github.com/airforce270/mc-srv/packet/id"
 )
 
@@ -12,3 +14,13 @@ type Packet interface {
 	// Name returns the human-readable display name of the packet.
 	Name() string
 }
+
+// UnknownPacket is a packet with an ID that isn't known
+// in the state and protocol version it was received in.
+type UnknownPacket struct {
+	Header
+	// Data is the packet's fields, undecoded.
+	Data []byte
+}
+
+func (p UnknownPacket) Name() string { return fmt.Sprintf("Unknown(0x%02x)", int32(p.PacketID)) }
@@ -0,0 +1,299 @@
+// Package play contains packets for the Play state.
+// https://wiki.vg/Protocol#Play
+package play
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/types"
+)
+
+// Player's game mode, for Login.
+type GameMode uint8
+
+const (
+	GameModeSurvival  GameMode = 0
+	GameModeCreative  GameMode = 1
+	GameModeAdventure GameMode = 2
+	GameModeSpectator GameMode = 3
+	// Only valid for Login.PreviousGameMode.
+	GameModeUndefined GameMode = 0xFF
+)
+
+// Difficulty of the world, for ChangeDifficulty.
+type Difficulty uint8
+
+const (
+	DifficultyPeaceful Difficulty = 0
+	DifficultyEasy     Difficulty = 1
+	DifficultyNormal   Difficulty = 2
+	DifficultyHard     Difficulty = 3
+)
+
+// Packet sent to the client to join the game,
+// after the configuration state has finished.
+// https://wiki.vg/Protocol#Login_.28play.29
+type Login struct {
+	// The player's Entity ID (EID).
+	EntityID int32
+	// Whether the world is in hardcore mode.
+	IsHardcore bool
+	// Identifiers for all dimensions on the server.
+	DimensionNames []string
+	// Was once used by the client to draw the player list,
+	// but now is ignored.
+	MaxPlayers int32 `mc:"varint"`
+	// Render distance (2-32).
+	ViewDistance int32 `mc:"varint"`
+	// The distance that the client will process specific things,
+	// such as entities.
+	SimulationDistance int32 `mc:"varint"`
+	// If true, a Notchian client shows reduced information
+	// on the debug screen.
+	// For servers in development, this should almost always be false.
+	ReducedDebugInfo bool
+	// Set to false when the doImmediateRespawn gamerule is true.
+	EnableRespawnScreen bool
+	// Whether players can only craft recipes they have already unlocked.
+	// Currently unused by the client.
+	DoLimitedCrafting bool
+	// The type of dimension in the minecraft:dimension_type registry,
+	// defined by the Registry Data packet.
+	// Replaced by DimensionTypeID in 1.20.5.
+	DimensionType string `mc:"until=766"`
+	// The network ID of the dimension type
+	// in the minecraft:dimension_type registry.
+	// Added in 1.20.5.
+	DimensionTypeID int32 `mc:"varint,since=766"`
+	// Name of the dimension being spawned into.
+	DimensionName string
+	// First 8 bytes of the SHA-256 hash of the world's seed.
+	// Used client side for biome noise.
+	// See HashSeed.
+	HashedSeed int64
+	// The player's game mode.
+	GameMode GameMode
+	// The player's previous game mode.
+	// Used by the debug screen's game mode switcher.
+	PreviousGameMode GameMode
+	// True if the world is a debug mode world;
+	// debug mode worlds cannot be modified and have predefined blocks.
+	IsDebug bool
+	// True if the world is a superflat world;
+	// flat worlds have different void fog and a horizon at y=0 instead of y=63.
+	IsFlat bool
+	// Where the player died, if they have died.
+	DeathLocation *DeathLocation
+	// The number of ticks until the player can use the portal again.
+	PortalCooldown int32 `mc:"varint"`
+	// Whether the server enforces secure chat.
+	// Added in 1.20.5.
+	EnforcesSecureChat bool `mc:"since=766"`
+}
+
+// DeathLocation is the location a player last died at.
+type DeathLocation struct {
+	// Name of the dimension the player died in.
+	DimensionName string
+	// Coordinates the player died at.
+	Location types.Position
+}
+
+func (Login) Name() string { return "Login(play)" }
+
+// Write writes the Login to the writer.
+func (l Login) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.PlayLogin, l)
+}
+
+// HashSeed hashes a world seed for Login.HashedSeed,
+// the same way the Notchian server does.
+func HashSeed(seed int64) int64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(seed))
+	sum := sha256.Sum256(b[:])
+	return int64(binary.LittleEndian.Uint64(sum[:8]))
+}
+
+// Packet sent by the server to set the world's difficulty,
+// shown in the client's options.
+// https://wiki.vg/Protocol#Change_Difficulty
+type ChangeDifficulty struct {
+	packet.Header
+
+	Difficulty Difficulty
+	// Whether the difficulty can't be changed from the client's options.
+	Locked bool
+}
+
+func (ChangeDifficulty) Name() string { return "ChangeDifficulty" }
+
+// Write writes the ChangeDifficulty to the writer.
+func (p ChangeDifficulty) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.PlayChangeDifficulty, p)
+}
+
+// Packet sent by the server to notify the client they should disconnect.
+// Same as config.Disconnect, but for the play state.
+type Disconnect struct {
+	packet.Header
+
+	// The reason the client was disconnected.
+	Reason types.TextComponent
+}
+
+func (Disconnect) Name() string { return "Disconnect(play)" }
+
+// Write writes the Disconnect to the writer.
+func (p Disconnect) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.PlayDisconnect, p)
+}
+
+// Server->client ping indicating the server is still alive.
+// Same as config.ClientboundKeepAlive, but for the play state.
+type ClientboundKeepAlive struct {
+	packet.Header
+	// Should be the same number that the server sent in its keep alive packet.
+	KeepAliveID int64
+}
+
+func (ClientboundKeepAlive) Name() string { return "ClientboundKeepAlive(play)" }
+
+// Write writes the ClientboundKeepAlive to the writer.
+func (p *ClientboundKeepAlive) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.PlayClientboundKeepAlive, p)
+}
+
+// Client->server response to the server->client keep alive packets.
+type ServerboundKeepAlive struct {
+	packet.Header
+	// Should be the same number that the server sent in its keep alive packet.
+	KeepAliveID int64
+}
+
+func (ServerboundKeepAlive) Name() string { return "ServerboundKeepAlive(play)" }
+
+// ReadServerboundKeepAlive reads a Serverbound Keep Alive (play) packet
+// from the reader.
+// https://wiki.vg/Protocol#Serverbound_Keep_Alive_.28play.29
+func ReadServerboundKeepAlive(r io.Reader, header packet.Header) (ServerboundKeepAlive, error) {
+	p := ServerboundKeepAlive{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read serverbound keepalive: %w", err)
+	}
+	return p, nil
+}
+
+// Packet for mods and plugins to send data client->server.
+// Same as config.ConfigServerboundPlugin, but for the play state.
+// https://wiki.vg/Protocol#Serverbound_Plugin_Message_.28play.29
+type ServerboundPlugin struct {
+	packet.Header
+	// Name of the plugin channel used to send the data.
+	Channel types.Identifier
+	// Any data, depending on the channel.
+	Data []byte `mc:"rest"`
+}
+
+func (ServerboundPlugin) Name() string { return "ServerboundPlugin(play)" }
+
+// ReadServerboundPlugin reads a Serverbound Plugin Message (play) packet
+// from the reader.
+func ReadServerboundPlugin(r io.Reader, header packet.Header) (ServerboundPlugin, error) {
+	p := ServerboundPlugin{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read serverbound plugin: %w", err)
+	}
+	return p, nil
+}
+
+// Packet for mods and plugins to send data server->client.
+// Same as config.ClientboundPlugin, but for the play state.
+// https://wiki.vg/Protocol#Clientbound_Plugin_Message_.28play.29
+type ClientboundPlugin struct {
+	packet.Header
+	// Name of the plugin channel used to send the data.
+	Channel types.Identifier
+	// Any data, depending on the channel.
+	Data []byte `mc:"rest"`
+}
+
+func (ClientboundPlugin) Name() string { return "ClientboundPlugin(play)" }
+
+// Write writes the ClientboundPlugin to the writer.
+func (p *ClientboundPlugin) Write(w io.Writer) error {
+	return codec.WritePacket(w, id.PlayClientboundPlugin, p)
+}
+
+// Packet sent by the server to request a cookie
+// the client stored for it, e.g. before a transfer.
+// Same as login.CookieRequest, but for the play state.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Cookie_Request_.28play.29
+type CookieRequest struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+}
+
+func (CookieRequest) Name() string { return "CookieRequest(play)" }
+
+// Packet sent by the client in response to a CookieRequest.
+// Same as login.CookieResponse, but for the play state.
+// Added in 1.20.5.
+type CookieResponse struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+	// The data of the cookie, at most 5120 bytes,
+	// or nil if the client doesn't have it.
+	Payload *[]byte `mc:"max=5120"`
+}
+
+func (CookieResponse) Name() string { return "CookieResponse(play)" }
+
+// ReadCookieResponse reads a Cookie Response (play) packet from the reader.
+// https://wiki.vg/Protocol#Cookie_Response_.28play.29
+func ReadCookieResponse(r io.Reader, header packet.Header) (CookieResponse, error) {
+	p := CookieResponse{Header: header}
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read cookie response: %w", err)
+	}
+	return p, nil
+}
+
+// Packet sent by the server to store a cookie on the client.
+// The client keeps its cookies across transfers,
+// so servers can pass data to the server a player is transferred to.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Store_Cookie_.28play.29
+type StoreCookie struct {
+	packet.Header
+	// The identifier of the cookie.
+	Key types.Identifier
+	// The data of the cookie, at most 5120 bytes.
+	Payload []byte `mc:"max=5120"`
+}
+
+func (StoreCookie) Name() string { return "StoreCookie(play)" }
+
+// Packet sent by the server to tell the client
+// to connect to another server.
+// The client only connects if the other server accepts transfers.
+// Added in 1.20.5.
+// https://wiki.vg/Protocol#Transfer_.28play.29
+type Transfer struct {
+	packet.Header
+	// The hostname or IP of the server.
+	Host string
+	// The port of the server.
+	Port int32 `mc:"varint"`
+}
+
+func (Transfer) Name() string { return "Transfer(play)" }
@@ -0,0 +1,222 @@
+package play_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteLogin(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input play.Login
+		want  []byte
+	}{
+		{
+			desc: "standard",
+			input: play.Login{
+				EntityID:            1,
+				IsHardcore:          false,
+				DimensionNames:      []string{"a:b"},
+				MaxPlayers:          20,
+				ViewDistance:        10,
+				SimulationDistance:  8,
+				ReducedDebugInfo:    false,
+				EnableRespawnScreen: true,
+				DoLimitedCrafting:   false,
+				DimensionType:       "a:b",
+				DimensionName:       "a:b",
+				HashedSeed:          -1,
+				GameMode:            play.GameModeCreative,
+				PreviousGameMode:    play.GameModeUndefined,
+				IsDebug:             false,
+				IsFlat:              true,
+				DeathLocation: &play.DeathLocation{
+					DimensionName: "a:b",
+					Location:      types.Position{X: 18357644, Y: 831, Z: -20882616},
+				},
+				PortalCooldown: 0,
+			},
+			want: slices.Concat(
+				// header
+				[]byte{0x33, 0x29},
+				// entity id
+				[]byte{0x00, 0x00, 0x00, 0x01},
+				// is hardcore
+				[]byte{0x00},
+				// dimension count + names
+				[]byte{0x01, 0x03, 'a', ':', 'b'},
+				// max players, view distance, simulation distance
+				[]byte{0x14, 0x0a, 0x08},
+				// reduced debug info, enable respawn screen, do limited crafting
+				[]byte{0x00, 0x01, 0x00},
+				// dimension type
+				[]byte{0x03, 'a', ':', 'b'},
+				// dimension name
+				[]byte{0x03, 'a', ':', 'b'},
+				// hashed seed
+				[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+				// game mode, previous game mode
+				[]byte{0x01, 0xff},
+				// is debug, is flat
+				[]byte{0x00, 0x01},
+				// has death location + dimension name
+				[]byte{0x01, 0x03, 'a', ':', 'b'},
+				// death location
+				// example from https://wiki.vg/Protocol#Position
+				[]byte{0x46, 0x07, 0x63, 0x2c, 0x15, 0xb4, 0x83, 0x3f},
+				// portal cooldown
+				[]byte{0x00},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteLogin() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteLogin() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteDisconnect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input play.Disconnect
+		want  []byte
+	}{
+		{
+			desc:  "text",
+			input: play.Disconnect{Reason: types.TextComponent{Text: "hi"}},
+			want: slices.Concat(
+				// header
+				[]byte{0x0e, 0x1b},
+				// reason: compound
+				[]byte{0x0a},
+				// string "text"
+				[]byte{0x08, 0x00, 0x04, 't', 'e', 'x', 't'},
+				[]byte{0x00, 0x02, 'h', 'i'},
+				// end
+				[]byte{0x00},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteDisconnect() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteDisconnect() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteChangeDifficulty(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input play.ChangeDifficulty
+		want  []byte
+	}{
+		{
+			desc:  "hard",
+			input: play.ChangeDifficulty{Difficulty: play.DifficultyHard},
+			// header, difficulty, locked
+			want: []byte{0x03, 0x0b, 0x03, 0x00},
+		},
+		{
+			desc:  "locked",
+			input: play.ChangeDifficulty{Difficulty: play.DifficultyPeaceful, Locked: true},
+			want:  []byte{0x03, 0x0b, 0x00, 0x01},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteChangeDifficulty() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteChangeDifficulty() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadServerboundKeepAlive(t *testing.T) {
+	t.Parallel()
+
+	inHeader := packet.Header{
+		Length:   9,
+		PacketID: id.PlayServerboundKeepAlive,
+	}
+
+	tests := []struct {
+		desc  string
+		input []byte
+		want  play.ServerboundKeepAlive
+	}{
+		{
+			desc:  "standard",
+			input: []byte{0x0, 0x0, 0x0, 0x0, 0x66, 0x5b, 0xaa, 0x58},
+			want: play.ServerboundKeepAlive{
+				Header:      inHeader,
+				KeepAliveID: 1717283416,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := play.ReadServerboundKeepAlive(bytes.NewReader(tc.input), inHeader)
+			if err != nil {
+				t.Fatalf("ReadServerboundKeepAlive() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReadServerboundKeepAlive() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package plugin encodes and decodes the data of
+// the plugin channels the Notchian client and server use.
+// https://wiki.vg/Plugin_channel
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/write"
+)
+
+const (
+	// BrandChannel is the channel the client and server
+	// announce their brand on, e.g. "vanilla".
+	BrandChannel types.Identifier = "minecraft:brand"
+	// RegisterChannel is the channel the client and server
+	// register the channels they listen on with.
+	RegisterChannel types.Identifier = "minecraft:register"
+	// UnregisterChannel is the channel the client and server
+	// unregister the channels they no longer listen on with.
+	UnregisterChannel types.Identifier = "minecraft:unregister"
+)
+
+// ReadBrand reads the brand from the data of a minecraft:brand message.
+// https://wiki.vg/Plugin_channel#minecraft:brand
+func ReadBrand(data []byte) (string, error) {
+	brand, err := read.String(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to read brand: %w", err)
+	}
+	return brand, nil
+}
+
+// Brand returns the data of a minecraft:brand message with the brand.
+func Brand(brand string) []byte {
+	var buf bytes.Buffer
+	write.String(&buf, brand) // Writes to a bytes.Buffer can't fail.
+	return buf.Bytes()
+}
+
+// ReadChannels reads the channels from the data of
+// a minecraft:register or minecraft:unregister message.
+// https://wiki.vg/Plugin_channel#minecraft:register
+func ReadChannels(data []byte) ([]types.Identifier, error) {
+	var channels []types.Identifier
+	// The channels are separated by null bytes,
+	// and some clients end the list with one.
+	for _, s := range strings.Split(string(data), "\x00") {
+		if s == "" {
+			continue
+		}
+		channel, err := types.ParseIdentifier(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse channel: %w", err)
+		}
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// Channels returns the data of a minecraft:register or minecraft:unregister
+// message with the channels.
+func Channels(channels []types.Identifier) []byte {
+	s := make([]string, len(channels))
+	for i, channel := range channels {
+		s[i] = channel.String()
+	}
+	return []byte(strings.Join(s, "\x00"))
+}
@@ -0,0 +1,124 @@
+package plugin_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet/plugin"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBrand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		brand string
+		data  []byte
+	}{
+		{
+			desc:  "vanilla",
+			brand: "vanilla",
+			data: []byte{
+				// length
+				0x07,
+				// "vanilla"
+				0x76, 0x61, 0x6e, 0x69, 0x6c, 0x6c, 0x61,
+			},
+		},
+		{
+			desc:  "empty",
+			brand: "",
+			data:  []byte{0x00},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.data, plugin.Brand(tc.brand)); diff != "" {
+				t.Errorf("Brand() diff (-want +got):\n%s", diff)
+			}
+
+			got, err := plugin.ReadBrand(tc.data)
+			if err != nil {
+				t.Fatalf("ReadBrand() unexpected err: %v", err)
+			}
+			if got != tc.brand {
+				t.Errorf("ReadBrand() = %q, want %q", got, tc.brand)
+			}
+		})
+	}
+}
+
+func TestReadBrandInvalid(t *testing.T) {
+	t.Parallel()
+
+	// The length is longer than the data.
+	if _, err := plugin.ReadBrand([]byte{0x07, 0x76}); err == nil {
+		t.Error("ReadBrand() err = nil, want an error")
+	}
+}
+
+func TestReadChannels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		data    []byte
+		want    []types.Identifier
+		wantErr error
+	}{
+		{
+			desc: "several",
+			data: []byte("fabric:registry/sync\x00mc-srv:hub"),
+			want: []types.Identifier{"fabric:registry/sync", "mc-srv:hub"},
+		},
+		{
+			desc: "trailing null",
+			data: []byte("mc-srv:hub\x00"),
+			want: []types.Identifier{"mc-srv:hub"},
+		},
+		{
+			desc: "default namespace",
+			data: []byte("brand"),
+			want: []types.Identifier{"minecraft:brand"},
+		},
+		{
+			desc: "empty",
+			data: nil,
+			want: nil,
+		},
+		{
+			desc:    "invalid",
+			data:    []byte("mc-srv:Hub"),
+			wantErr: types.ErrInvalidIdentifier,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := plugin.ReadChannels(tc.data)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("ReadChannels() err = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReadChannels() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChannels(t *testing.T) {
+	t.Parallel()
+
+	got := plugin.Channels([]types.Identifier{"mc-srv:hub", "minecraft:brand"})
+	want := []byte("mc-srv:hub\x00minecraft:brand")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Channels() diff (-want +got):\n%s", diff)
+	}
+}
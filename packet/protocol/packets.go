@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/slp"
+)
+
+// Default is the registry of every supported packet of every supported version.
+var Default = newDefault()
+
+func newDefault() *Registry {
+	r := NewRegistry()
+
+	// Handshake and status, for all versions.
+	Register(r, Key{State: Handshake, Direction: Serverbound, ID: id.Handshake}, slp.ReadHandshake)
+	Register(r, Key{State: Status, Direction: Serverbound, ID: id.StatusRequest}, slp.ReadStatusRequest)
+	Register(r, Key{State: Status, Direction: Serverbound, ID: id.HandshakePing}, slp.ReadHandshakePingRequest)
+	Register[slp.StatusResponse](r, Key{State: Status, Direction: Clientbound, ID: id.StatusResponse}, nil)
+	Register[slp.HandshakePingResponse](r, Key{State: Status, Direction: Clientbound, ID: id.HandshakePong}, nil)
+
+	register1_20_4(r)
+	register1_21(r)
+
+	return r
+}
+
+// keys returns functions making the keys of v's
+// serverbound and clientbound packets.
+func keys(v Version) (serverbound, clientbound func(State, id.ID) Key) {
+	serverbound = func(state State, i id.ID) Key {
+		return Key{Version: v, State: state, Direction: Serverbound, ID: i}
+	}
+	clientbound = func(state State, i id.ID) Key {
+		return Key{Version: v, State: state, Direction: Clientbound, ID: i}
+	}
+	return serverbound, clientbound
+}
+
+// registerLogin registers the login packets of v.
+// Their IDs haven't changed since 1.20.2,
+// though their layouts have.
+func registerLogin(r *Registry, v Version) {
+	serverbound, clientbound := keys(v)
+
+	Register(r, serverbound(Login, id.LoginStart), login.ReadLoginStart)
+	Register(r, serverbound(Login, id.EncryptionResponse), login.ReadEncryptionResponse)
+	Register(r, serverbound(Login, id.LoginPluginResponse), login.ReadLoginPluginResponse)
+	Register(r, serverbound(Login, id.LoginAcknowledgement), login.ReadLoginAcknowledgement)
+	Register[login.Disconnect](r, clientbound(Login, id.LoginDisconnect), nil)
+	Register[login.EncryptionRequest](r, clientbound(Login, id.EncryptionRequest), nil)
+	Register[login.LoginSuccess](r, clientbound(Login, id.LoginSuccess), nil)
+	Register[login.SetCompression](r, clientbound(Login, id.SetCompression), nil)
+	Register[login.LoginPluginRequest](r, clientbound(Login, id.LoginPluginRequest), nil)
+}
+
+// register1_20_4 registers the packets of 1.20.4,
+// whose IDs are the constants in package id.
+func register1_20_4(r *Registry) {
+	serverbound, clientbound := keys(V1_20_4)
+
+	registerLogin(r, V1_20_4)
+
+	Register(r, serverbound(Configuration, id.ClientInformation), config.ReadConfigClientInformation)
+	Register(r, serverbound(Configuration, id.ServerboundPlugin), config.ReadConfigServerboundPlugin)
+	Register(r, serverbound(Configuration, id.AcknowledgeFinish), config.ReadAcknowledgeFinishConfiguration)
+	Register(r, serverbound(Configuration, id.ServerboundKeepAlive), config.ReadServerboundKeepAlive)
+	Register(r, serverbound(Configuration, id.Pong), config.ReadConfigPong)
+	Register(r, serverbound(Configuration, id.ResourcePackResponse), config.ReadConfigResourcePackResponse)
+	Register[config.ClientboundPlugin](r, clientbound(Configuration, id.ClientboundPlugin), nil)
+	Register[config.Disconnect](r, clientbound(Configuration, id.ConfigDisconnect), nil)
+	Register[config.FinishConfiguration](r, clientbound(Configuration, id.FinishConfiguration), nil)
+	Register[config.ClientboundKeepAlive](r, clientbound(Configuration, id.ClientboundKeepAlive), nil)
+	Register[config.ConfigPing](r, clientbound(Configuration, id.Ping), nil)
+	Register[config.RegistryData](r, clientbound(Configuration, id.RegistryData), nil)
+	Register[config.ConfigRemoveResourcePack](r, clientbound(Configuration, id.ConfigRemoveResourcePack), nil)
+	Register[config.ConfigAddResourcePack](r, clientbound(Configuration, id.ConfigAddResourcePack), nil)
+	Register[config.FeatureFlags](r, clientbound(Configuration, id.FeatureFlags), nil)
+	Register[config.UpdateTags](r, clientbound(Configuration, id.ConfigUpdateTags), nil)
+
+	Register(r, serverbound(Play, id.PlayServerboundPlugin), play.ReadServerboundPlugin)
+	Register(r, serverbound(Play, id.PlayServerboundKeepAlive), play.ReadServerboundKeepAlive)
+	Register[play.ChangeDifficulty](r, clientbound(Play, id.PlayChangeDifficulty), nil)
+	Register[play.ClientboundPlugin](r, clientbound(Play, id.PlayClientboundPlugin), nil)
+	Register[play.Disconnect](r, clientbound(Play, id.PlayDisconnect), nil)
+	Register[play.ClientboundKeepAlive](r, clientbound(Play, id.PlayClientboundKeepAlive), nil)
+	Register[play.Login](r, clientbound(Play, id.PlayLogin), nil)
+}
+
+// register1_21 registers the packets of 1.21.
+// 1.20.5 added cookies and known packs to configuration,
+// shifting most IDs after them.
+func register1_21(r *Registry) {
+	serverbound, clientbound := keys(V1_21)
+
+	registerLogin(r, V1_21)
+	Register(r, serverbound(Login, 0x04), login.ReadCookieResponse)
+	Register[login.CookieRequest](r, clientbound(Login, 0x05), nil)
+
+	Register(r, serverbound(Configuration, 0x00), config.ReadConfigClientInformation)
+	Register(r, serverbound(Configuration, 0x01), config.ReadCookieResponse)
+	Register(r, serverbound(Configuration, 0x02), config.ReadConfigServerboundPlugin)
+	Register(r, serverbound(Configuration, 0x03), config.ReadAcknowledgeFinishConfiguration)
+	Register(r, serverbound(Configuration, 0x04), config.ReadServerboundKeepAlive)
+	Register(r, serverbound(Configuration, 0x05), config.ReadConfigPong)
+	Register(r, serverbound(Configuration, 0x06), config.ReadConfigResourcePackResponse)
+	Register(r, serverbound(Configuration, 0x07), config.ReadServerboundKnownPacks)
+	Register[config.CookieRequest](r, clientbound(Configuration, 0x00), nil)
+	Register[config.ClientboundPlugin](r, clientbound(Configuration, 0x01), nil)
+	Register[config.Disconnect](r, clientbound(Configuration, 0x02), nil)
+	Register[config.FinishConfiguration](r, clientbound(Configuration, 0x03), nil)
+	Register[config.ClientboundKeepAlive](r, clientbound(Configuration, 0x04), nil)
+	Register[config.ConfigPing](r, clientbound(Configuration, 0x05), nil)
+	Register[config.Registry](r, clientbound(Configuration, 0x07), nil)
+	Register[config.ConfigRemoveResourcePack](r, clientbound(Configuration, 0x08), nil)
+	Register[config.ConfigAddResourcePack](r, clientbound(Configuration, 0x09), nil)
+	Register[config.StoreCookie](r, clientbound(Configuration, 0x0A), nil)
+	Register[config.Transfer](r, clientbound(Configuration, 0x0B), nil)
+	Register[config.FeatureFlags](r, clientbound(Configuration, 0x0C), nil)
+	Register[config.UpdateTags](r, clientbound(Configuration, 0x0D), nil)
+	Register[config.ClientboundKnownPacks](r, clientbound(Configuration, 0x0E), nil)
+
+	Register(r, serverbound(Play, 0x11), play.ReadCookieResponse)
+	Register(r, serverbound(Play, 0x12), play.ReadServerboundPlugin)
+	Register(r, serverbound(Play, 0x18), play.ReadServerboundKeepAlive)
+	Register[play.ChangeDifficulty](r, clientbound(Play, 0x0B), nil)
+	Register[play.CookieRequest](r, clientbound(Play, 0x16), nil)
+	Register[play.ClientboundPlugin](r, clientbound(Play, 0x19), nil)
+	Register[play.Disconnect](r, clientbound(Play, 0x1D), nil)
+	Register[play.ClientboundKeepAlive](r, clientbound(Play, 0x26), nil)
+	Register[play.Login](r, clientbound(Play, 0x2B), nil)
+	Register[play.StoreCookie](r, clientbound(Play, 0x6B), nil)
+	Register[play.Transfer](r, clientbound(Play, 0x73), nil)
+}
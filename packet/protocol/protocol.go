@@ -0,0 +1,248 @@
+// Package protocol maps the packet IDs of each protocol version
+// to the packets they identify.
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/server/serverstate"
+)
+
+// Version is a protocol version, e.g. 765 for 1.20.4.
+// https://wiki.vg/Protocol_version_numbers
+type Version int32
+
+// Supported protocol versions.
+const (
+	// V1_20_4 is used by 1.20.3 and 1.20.4.
+	V1_20_4 Version = 765
+	// V1_21 is used by 1.21 and 1.21.1.
+	V1_21 Version = 767
+
+	// Oldest is the oldest supported protocol version.
+	Oldest = V1_20_4
+	// Latest is the latest supported protocol version.
+	Latest = V1_21
+)
+
+// versionNames are the names of the supported versions,
+// after the latest release using each.
+var versionNames = map[Version]string{
+	V1_20_4: "1.20.4",
+	V1_21:   "1.21.1",
+}
+
+// SupportedRange returns the names of the oldest and latest
+// supported versions, e.g. "1.20.4-1.21.1".
+func SupportedRange() string {
+	return Oldest.Name() + "-" + Latest.Name()
+}
+
+// Name returns the name of the version, e.g. "1.20.4",
+// or its number if it isn't supported.
+func (v Version) Name() string {
+	if name, ok := versionNames[v]; ok {
+		return name
+	}
+	return strconv.Itoa(int(v))
+}
+
+// State is a state of the protocol.
+// Unlike serverstate.State, it doesn't track progress within a state.
+type State uint8
+
+const (
+	Handshake State = iota
+	Status
+	Login
+	Configuration
+	Play
+)
+
+func (s State) String() string {
+	switch s {
+	case Handshake:
+		return "handshake"
+	case Status:
+		return "status"
+	case Login:
+		return "login"
+	case Configuration:
+		return "configuration"
+	case Play:
+		return "play"
+	default:
+		return fmt.Sprintf("State(%d)", uint8(s))
+	}
+}
+
+// StateOf returns the protocol state the client is in
+// when the server is in the given state.
+func StateOf(s serverstate.State) State {
+	switch {
+	case s == serverstate.PreHandshake:
+		return Handshake
+	case s == serverstate.ClientRequestingStatus:
+		return Status
+	case s <= serverstate.LoginCompletePendingAcknowledgement:
+		return Login
+	case s <= serverstate.ConfigurationCompletePendingAcknowledgement:
+		return Configuration
+	default:
+		return Play
+	}
+}
+
+// Direction is the direction a packet is sent in.
+type Direction uint8
+
+const (
+	// Serverbound packets are sent from the client to the server.
+	Serverbound Direction = iota
+	// Clientbound packets are sent from the server to the client.
+	Clientbound
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Serverbound:
+		return "serverbound"
+	case Clientbound:
+		return "clientbound"
+	default:
+		return fmt.Sprintf("Direction(%d)", uint8(d))
+	}
+}
+
+// Key identifies a packet.
+type Key struct {
+	Version   Version
+	State     State
+	Direction Direction
+	ID        id.ID
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s %s 0x%02x (protocol %d)", k.State, k.Direction, int32(k.ID), k.Version)
+}
+
+// Decoder reads the fields of a packet with the given header.
+type Decoder func(r io.Reader, h packet.Header) (packet.Packet, error)
+
+// Entry is a registered packet.
+type Entry struct {
+	// Type is the Go type of the packet.
+	Type reflect.Type
+	// Decode reads the packet.
+	// It's nil for clientbound packets, which the server only writes.
+	Decode Decoder
+}
+
+// ErrNotRegistered is returned when writing a packet
+// that isn't registered in the client's protocol version.
+var ErrNotRegistered = errors.New("packet not registered")
+
+// Registry maps packet keys to packets.
+// It's not safe to register packets concurrently with lookups.
+type Registry struct {
+	entries  map[Key]Entry
+	versions map[Version]bool
+	// ids are the IDs of clientbound packets, by version and type.
+	ids map[typeKey]id.ID
+}
+
+// typeKey identifies a clientbound packet by its Go type.
+type typeKey struct {
+	version Version
+	typ     reflect.Type
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		entries:  map[Key]Entry{},
+		versions: map[Version]bool{},
+		ids:      map[typeKey]id.ID{},
+	}
+}
+
+// Register registers the packet of type T under k, decoded with dec.
+// dec is nil for clientbound packets, which the server only writes.
+// Handshake and status packets haven't changed across versions,
+// so they're registered for every version, regardless of k.Version.
+// It panics if k is already registered,
+// if dec is set and T isn't a packet.Packet,
+// or if T is clientbound and already registered in k.Version.
+func Register[T any](r *Registry, k Key, dec func(io.Reader, packet.Header) (T, error)) {
+	k = normalize(k)
+	if e, ok := r.entries[k]; ok {
+		panic(fmt.Sprintf("protocol: %s is already registered to %s", k, e.Type))
+	}
+
+	e := Entry{Type: reflect.TypeFor[T]()}
+	if dec != nil {
+		if !e.Type.Implements(reflect.TypeFor[packet.Packet]()) {
+			panic(fmt.Sprintf("protocol: %s is decoded, but isn't a packet.Packet", e.Type))
+		}
+		e.Decode = func(rd io.Reader, h packet.Header) (packet.Packet, error) {
+			p, err := dec(rd, h)
+			if err != nil {
+				return nil, err
+			}
+			return any(p).(packet.Packet), nil
+		}
+	}
+	if k.Direction == Clientbound {
+		tk := typeKey{version: k.Version, typ: e.Type}
+		if i, ok := r.ids[tk]; ok {
+			panic(fmt.Sprintf("protocol: %s is already registered to 0x%02x (protocol %d)", e.Type, int32(i), k.Version))
+		}
+		r.ids[tk] = k.ID
+	}
+	r.entries[k] = e
+	if k.State != Handshake && k.State != Status {
+		r.versions[k.Version] = true
+	}
+}
+
+// Lookup returns the packet registered under k.
+func (r *Registry) Lookup(k Key) (Entry, bool) {
+	e, ok := r.entries[normalize(k)]
+	return e, ok
+}
+
+// Write writes the clientbound packet p to w
+// with its ID and layout in protocol version v.
+func (r *Registry) Write(w io.Writer, v Version, p any) error {
+	t := reflect.Indirect(reflect.ValueOf(p)).Type()
+	i, ok := r.ids[typeKey{version: v, typ: t}]
+	if !ok {
+		// Handshake and status packets are registered for every version.
+		i, ok = r.ids[typeKey{typ: t}]
+	}
+	if !ok {
+		return fmt.Errorf("%s in protocol %d: %w", t, v, ErrNotRegistered)
+	}
+	return codec.WritePacketVersion(w, i, p, int32(v))
+}
+
+// Supports returns whether any login, configuration or play packets
+// are registered for v.
+func (r *Registry) Supports(v Version) bool {
+	return r.versions[v]
+}
+
+// normalize returns the key packets are registered under.
+func normalize(k Key) Key {
+	if k.State == Handshake || k.State == Status {
+		k.Version = 0
+	}
+	return k
+}
@@ -0,0 +1,220 @@
+package protocol_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/server/serverstate"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestStateOf(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input serverstate.State
+		want  protocol.State
+	}{
+		{serverstate.PreHandshake, protocol.Handshake},
+		{serverstate.ClientRequestingStatus, protocol.Status},
+		{serverstate.ClientRequestingLogin, protocol.Login},
+		{serverstate.EncryptionRequested, protocol.Login},
+		{serverstate.LoginCompletePendingAcknowledgement, protocol.Login},
+		{serverstate.LoginComplete, protocol.Configuration},
+		{serverstate.ConfigurationCompletePendingAcknowledgement, protocol.Configuration},
+		{serverstate.ConfigurationComplete, protocol.Play},
+		{serverstate.Play, protocol.Play},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want.String(), func(t *testing.T) {
+			t.Parallel()
+
+			if got := protocol.StateOf(tc.input); got != tc.want {
+				t.Errorf("StateOf(%d) = %s, want %s", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDefaultLookup(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc        string
+		key         protocol.Key
+		want        reflect.Type
+		wantOK      bool
+		wantDecoder bool
+	}{
+		{
+			desc:        "handshake of any version",
+			key:         protocol.Key{Version: 47, State: protocol.Handshake, Direction: protocol.Serverbound, ID: id.Handshake},
+			want:        reflect.TypeFor[slp.Handshake](),
+			wantOK:      true,
+			wantDecoder: true,
+		},
+		{
+			desc:        "serverbound login",
+			key:         protocol.Key{Version: protocol.V1_20_4, State: protocol.Login, Direction: protocol.Serverbound, ID: id.LoginStart},
+			want:        reflect.TypeFor[login.LoginStart](),
+			wantOK:      true,
+			wantDecoder: true,
+		},
+		{
+			desc:   "clientbound login with the same ID",
+			key:    protocol.Key{Version: protocol.V1_20_4, State: protocol.Login, Direction: protocol.Clientbound, ID: id.LoginDisconnect},
+			want:   reflect.TypeFor[login.Disconnect](),
+			wantOK: true,
+		},
+		{
+			desc: "unsupported version",
+			key:  protocol.Key{Version: 47, State: protocol.Login, Direction: protocol.Serverbound, ID: id.LoginStart},
+		},
+		{
+			desc: "unknown ID",
+			key:  protocol.Key{Version: protocol.V1_20_4, State: protocol.Play, Direction: protocol.Serverbound, ID: 0x7f},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := protocol.Default.Lookup(tc.key)
+			if ok != tc.wantOK {
+				t.Fatalf("Lookup(%s) ok = %t, want %t", tc.key, ok, tc.wantOK)
+			}
+			if got.Type != tc.want {
+				t.Errorf("Lookup(%s) type = %v, want %v", tc.key, got.Type, tc.want)
+			}
+			if gotDecoder := got.Decode != nil; gotDecoder != tc.wantDecoder {
+				t.Errorf("Lookup(%s) has decoder = %t, want %t", tc.key, gotDecoder, tc.wantDecoder)
+			}
+		})
+	}
+}
+
+func TestSupports(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []protocol.Version{protocol.V1_20_4, protocol.V1_21} {
+		if !protocol.Default.Supports(v) {
+			t.Errorf("Supports(%d) = false, want true", v)
+		}
+	}
+	for _, v := range []protocol.Version{47, 766, 768} {
+		if protocol.Default.Supports(v) {
+			t.Errorf("Supports(%d) = true, want false", v)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc    string
+		version protocol.Version
+		input   any
+		want    []byte
+		wantErr error
+	}{
+		{
+			desc:    "1.20.4",
+			version: protocol.V1_20_4,
+			input:   config.FinishConfiguration{},
+			want:    []byte{0x01, 0x02},
+		},
+		{
+			desc:    "1.21",
+			version: protocol.V1_21,
+			input:   &config.FinishConfiguration{},
+			want:    []byte{0x01, 0x03},
+		},
+		{
+			desc:    "without field added in 1.20.5",
+			version: protocol.V1_20_4,
+			input:   login.EncryptionRequest{ShouldAuthenticate: true},
+			want:    []byte{0x04, 0x01, 0x00, 0x00, 0x00},
+		},
+		{
+			desc:    "with field added in 1.20.5",
+			version: protocol.V1_21,
+			input:   login.EncryptionRequest{ShouldAuthenticate: true},
+			want:    []byte{0x05, 0x01, 0x00, 0x00, 0x00, 0x01},
+		},
+		{
+			desc:    "status in any version",
+			version: 47,
+			input:   slp.HandshakePingResponse{Payload: 1},
+			want:    []byte{0x09, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01},
+		},
+		{
+			desc:    "not in version",
+			version: protocol.V1_20_4,
+			input:   config.ClientboundKnownPacks{},
+			wantErr: protocol.ErrNotRegistered,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := protocol.Default.Write(&buf, tc.version, tc.input)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Write() err = %v, want %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, buf.Bytes(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Write() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVersionName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input protocol.Version
+		want  string
+	}{
+		{protocol.V1_20_4, "1.20.4"},
+		{protocol.V1_21, "1.21.1"},
+		{766, "766"},
+	}
+
+	for _, tc := range tests {
+		if got := tc.input.Name(); got != tc.want {
+			t.Errorf("Version(%d).Name() = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+	if got, want := protocol.SupportedRange(), "1.20.4-1.21.1"; got != want {
+		t.Errorf("SupportedRange() = %q, want %q", got, want)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	t.Parallel()
+
+	r := protocol.NewRegistry()
+	k := protocol.Key{Version: protocol.V1_20_4, State: protocol.Login, Direction: protocol.Serverbound, ID: id.LoginStart}
+	protocol.Register(r, k, login.ReadLoginStart)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Register() of a duplicate key didn't panic")
+		}
+	}()
+	protocol.Register(r, k, func(io.Reader, packet.Header) (login.LoginAcknowledgement, error) {
+		return login.LoginAcknowledgement{}, nil
+	})
+}
@@ -3,25 +3,30 @@ package readpacket
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 
 	"github.com/airforce270/mc-srv/flags"
 	"github.com/airforce270/mc-srv/packet"
-	"github.com/airforce270/mc-srv/packet/config"
-	"github.com/airforce270/mc-srv/packet/id"
-	"github.com/airforce270/mc-srv/packet/login"
-	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/protocol"
 	"github.com/airforce270/mc-srv/server/serverstate"
 	"github.com/airforce270/mc-srv/write"
 )
 
-// Read reads the next packet from the reader.
-func Read(r io.Reader, state serverstate.State, logger *log.Logger) (packet.Packet, error) {
+// ErrFraming is returned when a packet's length or bytes can't be read,
+// after which the reader has lost track of where packets start
+// and no more can be read from it.
+var ErrFraming = errors.New("packet framing lost")
+
+// Read reads the next packet from the reader,
+// decoding it as the packet it is in the given protocol version and state.
+// Packets that aren't known are returned as a packet.UnknownPacket.
+func Read(r io.Reader, version protocol.Version, state serverstate.State, logger *log.Logger) (packet.Packet, error) {
 	h, err := packet.ReadHeader(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read header: %w", err)
+		return nil, fmt.Errorf("failed to read header: %w %w", err, ErrFraming)
 	}
 	if h.Length == 0 {
 		return nil, nil
@@ -33,68 +38,37 @@ func Read(r io.Reader, state serverstate.State, logger *log.Logger) (packet.Pack
 	packetIDLen := write.VarIntLen(int32(h.PacketID))
 
 	fieldsLength := int(h.Length) - packetIDLen
-	if fieldsLength == 0 && h.PacketID == id.StatusRequest {
-		return slp.StatusRequest{Header: h}, nil
+	if fieldsLength < 0 {
+		return nil, fmt.Errorf("packet length %d is shorter than its ID: %w", h.Length, ErrFraming)
 	}
 
 	var buf bytes.Buffer
 	readN, err := io.CopyN(&buf, r, int64(fieldsLength))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read packet bytes: %w", err)
+		return nil, fmt.Errorf("failed to read packet bytes: %w %w", err, ErrFraming)
 	}
 	if readN != int64(fieldsLength) {
-		return nil, fmt.Errorf("expected to read %d bytes, only read %d", fieldsLength, readN)
+		return nil, fmt.Errorf("expected to read %d bytes, only read %d: %w", fieldsLength, readN, ErrFraming)
 	}
 
-	var p packet.Packet
-	switch state {
-	case serverstate.PreHandshake, serverstate.ClientRequestingStatus:
-		switch h.PacketID {
-		case id.Handshake:
-			p, err = slp.ReadHandshake(&buf, h)
-		case id.HandshakePing:
-			p, err = slp.ReadHandshakePingRequest(&buf, h)
-		default:
-			logger.Printf("Unhandled packet type (state=%v): %x", state, h.PacketID)
-			return nil, nil
-		}
-	case serverstate.ClientRequestingLogin:
-		switch h.PacketID {
-		case id.LoginStart:
-			p, err = login.ReadLoginStart(&buf, h)
-		}
-	case serverstate.EncryptionRequested:
-		switch h.PacketID {
-		case id.EncryptionResponse:
-			p, err = login.ReadEncryptionResponse(&buf, h)
-		}
-	case serverstate.LoginCompletePendingAcknowledgement:
-		switch h.PacketID {
-		case id.LoginAcknowledgement:
-			p = login.LoginAcknowledgement{Header: h}
-		}
-	case serverstate.LoginComplete:
-		switch h.PacketID {
-		case id.ClientInformation:
-			p, err = config.ReadConfigClientInformation(&buf, h)
-		case id.ServerboundPlugin:
-			p, err = config.ReadConfigServerboundPlugin(&buf, h)
-		case id.AcknowledgeFinish:
-			p = config.AcknowledgeFinishConfiguration{Header: h}
-		case id.ServerboundKeepAlive:
-			p, err = config.ReadServerboundKeepAlive(&buf, h)
-		case id.Pong:
-			p, err = config.ReadConfigPong(&buf, h)
-		case id.ResourcePackResponse:
-			p, err = config.ReadConfigResourcePackResponse(&buf, h)
-		}
-	default:
-		logger.Printf("Unhandled packet type (state=%v): %x", state, h.PacketID)
-		return nil, nil
+	key := protocol.Key{
+		Version:   version,
+		State:     protocol.StateOf(state),
+		Direction: protocol.Serverbound,
+		ID:        h.PacketID,
+	}
+	e, ok := protocol.Default.Lookup(key)
+	if !ok || e.Decode == nil {
+		return packet.UnknownPacket{Header: h, Data: buf.Bytes()}, nil
 	}
+
+	p, err := e.Decode(&buf, h)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read packet (header=%+v): %w", h, err)
 	}
+	if buf.Len() > 0 {
+		return nil, fmt.Errorf("packet %s has %d unread bytes (header=%+v)", p.Name(), buf.Len(), h)
+	}
 
 	return p, nil
 }
@@ -2,6 +2,7 @@ package readpacket_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"log"
 	"slices"
@@ -12,6 +13,7 @@ import (
 	"github.com/airforce270/mc-srv/packet/login"
 	"github.com/airforce270/mc-srv/packet/login/logintest"
 	"github.com/airforce270/mc-srv/packet/pingtest"
+	"github.com/airforce270/mc-srv/packet/protocol"
 	"github.com/airforce270/mc-srv/packet/readpacket"
 	"github.com/airforce270/mc-srv/packet/slp"
 	"github.com/airforce270/mc-srv/packet/slp/slptest"
@@ -45,27 +47,24 @@ func TestRead(t *testing.T) {
 
 		{
 			state: serverstate.ClientRequestingStatus,
-			input: slices.Concat(slptest.NotchianHandshakeHeader, slptest.NotchianHandshake),
-			want: slp.Handshake{
+			input: []byte{0x01, 0x00},
+			want: slp.StatusRequest{
 				Header: packet.Header{
-					Length:   16,
-					PacketID: id.Handshake,
+					Length:   1,
+					PacketID: id.StatusRequest,
 				},
-				ProtocolVersion: 765,
-				ServerAddress:   "127.0.0.1",
-				ServerPort:      25565,
-				NextState:       1,
 			},
 		},
 		{
+			// Pings aren't sent until the status state.
 			state: serverstate.PreHandshake,
 			input: slices.Concat(pingtest.NotchianHeader, pingtest.Notchian),
-			want: slp.HandshakePingRequest{
+			want: packet.UnknownPacket{
 				Header: packet.Header{
 					Length:   9,
 					PacketID: id.HandshakePing,
 				},
-				Payload: 6837160,
+				Data: pingtest.Notchian,
 			},
 		},
 		{
@@ -91,13 +90,37 @@ func TestRead(t *testing.T) {
 				PlayerUUID: uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
 			},
 		},
+		{
+			state: serverstate.LoginPluginRequested,
+			input: slices.Concat(logintest.NotchianLoginPluginResponseHeader, logintest.NotchianLoginPluginResponse),
+			want: login.LoginPluginResponse{
+				Header: packet.Header{
+					Length:   6,
+					PacketID: id.LoginPluginResponse,
+				},
+				MessageID:  7,
+				Successful: true,
+				Data:       []byte{0x01, 0x02, 0x03},
+			},
+		},
+		{
+			state: serverstate.LoginPluginRequested,
+			input: []byte{0x03, 0x7f, 0x01, 0x02},
+			want: packet.UnknownPacket{
+				Header: packet.Header{
+					Length:   3,
+					PacketID: 0x7f,
+				},
+				Data: []byte{0x01, 0x02},
+			},
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(fmt.Sprintf("[%d]%T", tc.state, tc.want), func(t *testing.T) {
 			t.Parallel()
 
-			got, err := readpacket.Read(bytes.NewReader(tc.input), tc.state, log.Default())
+			got, err := readpacket.Read(bytes.NewReader(tc.input), protocol.V1_20_4, tc.state, log.Default())
 			if err != nil {
 				t.Fatalf("Read() unexpected err: %v", err)
 			}
@@ -108,3 +131,63 @@ func TestRead(t *testing.T) {
 		})
 	}
 }
+
+func TestReadInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc        string
+		state       serverstate.State
+		input       []byte
+		wantFraming bool
+	}{
+		{
+			desc:  "unread bytes",
+			state: serverstate.ClientRequestingStatus,
+			input: []byte{0x02, 0x00, 0x01},
+		},
+		{
+			desc:  "truncated fields",
+			state: serverstate.Play,
+			input: []byte{0x03, 0x15, 0x00, 0x01},
+		},
+		{
+			desc:        "length cut short",
+			state:       serverstate.Play,
+			input:       []byte{0x80},
+			wantFraming: true,
+		},
+		{
+			desc:        "packet cut short",
+			state:       serverstate.ClientRequestingStatus,
+			input:       []byte{0x05, 0x00, 0x01},
+			wantFraming: true,
+		},
+		{
+			desc:        "length out of bounds",
+			state:       serverstate.Play,
+			input:       []byte{0x80, 0x80, 0x80, 0x01, 0x00}, // 2^21
+			wantFraming: true,
+		},
+		{
+			desc:        "negative length",
+			state:       serverstate.Play,
+			input:       []byte{0xff, 0xff, 0xff, 0xff, 0x0f, 0x00}, // -1
+			wantFraming: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := readpacket.Read(bytes.NewReader(tc.input), protocol.V1_20_4, tc.state, log.Default())
+			if err == nil {
+				t.Fatalf("Read() = %+v, want an error", got)
+			}
+			if gotFraming := errors.Is(err, readpacket.ErrFraming); gotFraming != tc.wantFraming {
+				t.Errorf("Read() err = %v, want framing error? %t", err, tc.wantFraming)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/airforce270/mc-srv/read"
+)
+
+const (
+	// LegacyPingID is the first byte of a legacy (pre-1.7) server list ping.
+	// Modern packets can't start with it, since it would be the first byte
+	// of a length too long for a handshake.
+	LegacyPingID = 0xfe
+	// legacyPingPayload follows LegacyPingID in pings from 1.4+ clients.
+	legacyPingPayload = 0x01
+	// legacyPluginMessageID follows legacyPingPayload in pings from 1.6 clients.
+	legacyPluginMessageID = 0xfa
+	// legacyKickID is the ID of the packet sent in response to a legacy ping.
+	legacyKickID = 0xff
+
+	// legacyProtocolVersion is the protocol version sent in legacy ping responses.
+	// It's higher than any legacy client's, so they show the server as outdated.
+	legacyProtocolVersion = 127
+)
+
+// LegacyPingForm is the form of a legacy ping, depending on the client's version.
+type LegacyPingForm int
+
+const (
+	// LegacyPingBeta is a ping from beta 1.8 to 1.3 clients: just 0xFE.
+	LegacyPingBeta LegacyPingForm = iota
+	// LegacyPing14 is a ping from 1.4 and 1.5 clients: 0xFE 0x01.
+	LegacyPing14
+	// LegacyPing16 is a ping from 1.6 clients: 0xFE 0x01 0xFA
+	// followed by an MC|PingHost plugin message.
+	LegacyPing16
+)
+
+// LegacyPing is a server list ping from a pre-1.7 client.
+// https://wiki.vg/Server_List_Ping#1.6
+type LegacyPing struct {
+	// Form of the ping.
+	Form LegacyPingForm
+	// Protocol version of the client. Only set for LegacyPing16.
+	ProtocolVersion byte
+	// Hostname the client connected to. Only set for LegacyPing16.
+	Hostname string
+	// Port the client connected to. Only set for LegacyPing16.
+	Port int32
+}
+
+func (LegacyPing) Name() string { return "LegacyPing" }
+
+// IsLegacyPing returns whether the next packet in the reader is a legacy ping.
+// It should only be checked before the handshake.
+func IsLegacyPing(r *bufio.Reader) bool {
+	b, err := r.Peek(1)
+	return err == nil && b[0] == LegacyPingID
+}
+
+// ReadLegacyPing reads a legacy ping from the reader.
+//
+// Legacy pings aren't framed, so like the Notchian server,
+// the form is determined by which bytes have already been received.
+func ReadLegacyPing(r *bufio.Reader) (LegacyPing, error) {
+	var p LegacyPing
+
+	id, err := read.Byte(r)
+	if err != nil {
+		return p, fmt.Errorf("failed to read packet ID: %w", err)
+	}
+	if id != LegacyPingID {
+		return p, fmt.Errorf("packet ID 0x%02x is not a legacy ping", id)
+	}
+
+	if r.Buffered() == 0 {
+		return p, nil
+	}
+	if b, _ := r.Peek(1); b[0] != legacyPingPayload {
+		return p, nil
+	}
+	r.Discard(1)
+	p.Form = LegacyPing14
+
+	if r.Buffered() == 0 {
+		return p, nil
+	}
+	if b, _ := r.Peek(1); b[0] != legacyPluginMessageID {
+		return p, nil
+	}
+	r.Discard(1)
+	p.Form = LegacyPing16
+
+	if _, err := readLegacyString(r); err != nil {
+		return p, fmt.Errorf("failed to read plugin channel: %w", err)
+	}
+	var dataLen uint16
+	if err := binary.Read(r, binary.BigEndian, &dataLen); err != nil {
+		return p, fmt.Errorf("failed to read plugin data length: %w", err)
+	}
+	data, err := read.Bytes(r, int(dataLen))
+	if err != nil {
+		return p, fmt.Errorf("failed to read plugin data: %w", err)
+	}
+
+	dr := bytes.NewReader(data)
+	if p.ProtocolVersion, err = read.Byte(dr); err != nil {
+		return p, fmt.Errorf("failed to read protocol version: %w", err)
+	}
+	if p.Hostname, err = readLegacyString(dr); err != nil {
+		return p, fmt.Errorf("failed to read hostname: %w", err)
+	}
+	if p.Port, err = read.Int(dr); err != nil {
+		return p, fmt.Errorf("failed to read port: %w", err)
+	}
+
+	return p, nil
+}
+
+// LegacyPingResponse is the kick packet sent in response to a legacy ping.
+// https://wiki.vg/Server_List_Ping#1.4_to_1.5
+type LegacyPingResponse struct {
+	// Protocol version of the server.
+	ProtocolVersion int
+	// Name of the server's version, e.g. 1.20.4.
+	VersionName string
+	// Message of the day, shown in the server list.
+	// Legacy clients can only show text with legacy formatting codes.
+	MOTD string
+	// Number of players online.
+	OnlinePlayers int
+	// Maximum number of players.
+	MaxPlayers int
+}
+
+func (LegacyPingResponse) Name() string { return "LegacyPingResponse" }
+
+// NewLegacyPingResponse returns the response to a legacy ping
+// with the same status as a status response.
+func NewLegacyPingResponse(s Status) LegacyPingResponse {
+	return LegacyPingResponse{
+		ProtocolVersion: legacyProtocolVersion,
+		VersionName:     s.VersionName,
+		MOTD:            s.Description.Legacy(),
+		OnlinePlayers:   s.OnlinePlayers,
+		MaxPlayers:      s.MaxPlayers,
+	}
+}
+
+// Write writes the LegacyPingResponse to the writer.
+func (r LegacyPingResponse) Write(w io.Writer) error {
+	kick := strings.Join([]string{
+		"§1",
+		strconv.Itoa(r.ProtocolVersion),
+		r.VersionName,
+		r.MOTD,
+		strconv.Itoa(r.OnlinePlayers),
+		strconv.Itoa(r.MaxPlayers),
+	}, "\x00")
+
+	var buf bytes.Buffer
+	buf.WriteByte(legacyKickID)
+	if err := writeLegacyString(&buf, kick); err != nil {
+		return fmt.Errorf("failed to write kick message: %w", err)
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write packet: %w", err)
+	}
+	return nil
+}
+
+// readLegacyString reads a string in the legacy format:
+// its length in UTF-16 code units as a short, then UTF-16BE.
+func readLegacyString(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", fmt.Errorf("failed to read string's length: %w", err)
+	}
+	units := make([]uint16, length)
+	if err := binary.Read(r, binary.BigEndian, units); err != nil {
+		return "", fmt.Errorf("failed to read string: %w", err)
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// writeLegacyString writes a string in the legacy format.
+func writeLegacyString(w io.Writer, s string) error {
+	units := utf16.Encode([]rune(s))
+	if len(units) > 0xffff {
+		return fmt.Errorf("string is too long (%d UTF-16 code units)", len(units))
+	}
+	if err := binary.Write(w, binary.BigEndian, uint16(len(units))); err != nil {
+		return fmt.Errorf("failed to write string's length: %w", err)
+	}
+	if err := binary.Write(w, binary.BigEndian, units); err != nil {
+		return fmt.Errorf("failed to write string: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package slp_test
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/slp/slptest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadLegacyPing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input []byte
+		want  slp.LegacyPing
+	}{
+		{
+			desc:  "beta",
+			input: slptest.LegacyPingBeta,
+			want:  slp.LegacyPing{Form: slp.LegacyPingBeta},
+		},
+		{
+			desc:  "1.4",
+			input: slptest.LegacyPing14,
+			want:  slp.LegacyPing{Form: slp.LegacyPing14},
+		},
+		{
+			desc:  "1.6",
+			input: slptest.LegacyPing16,
+			want: slp.LegacyPing{
+				Form:            slp.LegacyPing16,
+				ProtocolVersion: 74,
+				Hostname:        "localhost",
+				Port:            25565,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			r := bufio.NewReader(bytes.NewReader(tc.input))
+			// Fill the buffer, as if the bytes had been received.
+			r.Peek(len(tc.input))
+
+			if !slp.IsLegacyPing(r) {
+				t.Fatalf("IsLegacyPing() = false, want true")
+			}
+			got, err := slp.ReadLegacyPing(r)
+			if err != nil {
+				t.Fatalf("ReadLegacyPing() unexpected err: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReadLegacyPing() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsLegacyPing(t *testing.T) {
+	t.Parallel()
+
+	r := bufio.NewReader(bytes.NewReader(slptest.NotchianHandshakeHeader))
+	if slp.IsLegacyPing(r) {
+		t.Errorf("IsLegacyPing() = true for a handshake, want false")
+	}
+}
+
+func TestWriteLegacyPingResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input slp.LegacyPingResponse
+		want  []byte
+	}{
+		{
+			desc: "standard",
+			input: slp.LegacyPingResponse{
+				ProtocolVersion: 127,
+				VersionName:     "1.20.4",
+				MOTD:            "hi",
+				OnlinePlayers:   12,
+				MaxPlayers:      34,
+			},
+			want: slices.Concat(
+				// packet ID
+				[]byte{0xff},
+				// length
+				[]byte{0x00, 0x16},
+				// §1
+				[]byte{0x00, 0xa7, 0x00, 0x31, 0x00, 0x00},
+				// protocol version
+				[]byte{0x00, '1', 0x00, '2', 0x00, '7', 0x00, 0x00},
+				// version name
+				[]byte{0x00, '1', 0x00, '.', 0x00, '2', 0x00, '0', 0x00, '.', 0x00, '4', 0x00, 0x00},
+				// MOTD
+				[]byte{0x00, 'h', 0x00, 'i', 0x00, 0x00},
+				// online players
+				[]byte{0x00, '1', 0x00, '2', 0x00, 0x00},
+				// max players
+				[]byte{0x00, '3', 0x00, '4'},
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			if err := tc.input.Write(&out); err != nil {
+				t.Fatalf("WriteLegacyPingResponse() unexpected err: %v", err)
+			}
+
+			got := out.Bytes()
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteLegacyPingResponse() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
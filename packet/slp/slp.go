@@ -3,20 +3,20 @@
 package slp
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 
 	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/codec"
 	"github.com/airforce270/mc-srv/packet/id"
-	"github.com/airforce270/mc-srv/packet/writepacket"
-	"github.com/airforce270/mc-srv/read"
-	"github.com/airforce270/mc-srv/write"
 )
 
 const (
 	HandshakeNextStateStatus = 1
 	HandshakeNextStateLogin  = 2
+	// Login after being transferred from another server.
+	// Added in 1.20.5.
+	HandshakeNextStateTransfer = 3
 )
 
 // Initial packet sent from the client server to establish connection.
@@ -26,13 +26,14 @@ type Handshake struct {
 	// (which is not important for the ping).
 	// If the client is pinging to determine what version to use,
 	// by convention -1 should be set.
-	ProtocolVersion int32
+	ProtocolVersion int32 `mc:"varint"`
 	// Hostname or IP, e.g. localhost or 127.0.0.1, that was used to connect.
 	ServerAddress string
 	// Default is 25565. The Notchian server does not use this information.
 	ServerPort uint16
-	// Should be 1 for status, but could also be 2 for login.
-	NextState int32
+	// Should be 1 for status, but could also be 2 for login
+	// or 3 for login after a transfer.
+	NextState int32 `mc:"varint"`
 }
 
 func (Handshake) Name() string { return "Handshake" }
@@ -41,29 +42,9 @@ func (Handshake) Name() string { return "Handshake" }
 // https://wiki.vg/Server_List_Ping#Handshake
 func ReadHandshake(r io.Reader, header packet.Header) (Handshake, error) {
 	h := Handshake{Header: header}
-
-	var err error
-
-	h.ProtocolVersion, err = read.VarInt(r)
-	if err != nil {
-		return h, fmt.Errorf("failed to read protocol version: %w", err)
-	}
-
-	h.ServerAddress, err = read.String(r)
-	if err != nil {
-		return h, fmt.Errorf("failed to read server address: %w", err)
-	}
-
-	h.ServerPort, err = read.UnsignedShort(r)
-	if err != nil {
-		return h, fmt.Errorf("failed to read server port: %w", err)
-	}
-
-	h.NextState, err = read.VarInt(r)
-	if err != nil {
-		return h, fmt.Errorf("failed to read next state: %w", err)
+	if err := codec.Decode(r, &h); err != nil {
+		return h, fmt.Errorf("failed to read handshake: %w", err)
 	}
-
 	return h, nil
 }
 
@@ -80,13 +61,9 @@ func (HandshakePingRequest) Name() string { return "PingRequest(Handshake)" }
 // https://wiki.vg/Server_List_Ping#Ping_Request
 func ReadHandshakePingRequest(r io.Reader, header packet.Header) (HandshakePingRequest, error) {
 	p := HandshakePingRequest{Header: header}
-
-	var err error
-	p.Payload, err = read.Long(r)
-	if err != nil {
-		return p, fmt.Errorf("failed to read payload: %w", err)
+	if err := codec.Decode(r, &p); err != nil {
+		return p, fmt.Errorf("failed to read ping request: %w", err)
 	}
-
 	return p, nil
 }
 
@@ -102,14 +79,5 @@ func (HandshakePingResponse) Name() string { return "PingResponse(Handshake)" }
 // Write writes the HandshakePingResponse to the writer.
 // https://wiki.vg/Protocol#Pong_Response
 func (pr HandshakePingResponse) Write(w io.Writer) error {
-	var buf bytes.Buffer
-	if err := write.Long(&buf, pr.Payload); err != nil {
-		return fmt.Errorf("failed to write ping response payload: %w", err)
-	}
-
-	if err := writepacket.Write(w, id.HandshakePong, &buf); err != nil {
-		return fmt.Errorf("failed to write packet: %w", err)
-	}
-
-	return nil
+	return codec.WritePacket(w, id.HandshakePong, pr)
 }
@@ -11,4 +11,21 @@ var (
 		0x01, // next state
 	}
 	NotchianHandshakeHeader = headertest.HandshakeLen16
+
+	LegacyPingBeta = []byte{0xfe}
+	LegacyPing14   = []byte{0xfe, 0x01}
+	LegacyPing16   = []byte{
+		0xfe,       // packet ID
+		0x01,       // payload
+		0xfa,       // plugin message ID
+		0x00, 0x0b, // channel length
+		0x00, 0x4d, 0x00, 0x43, 0x00, 0x7c, 0x00, 0x50, 0x00, 0x69, 0x00, 0x6e, // channel
+		0x00, 0x67, 0x00, 0x48, 0x00, 0x6f, 0x00, 0x73, 0x00, 0x74,
+		0x00, 0x19, // data length
+		0x4a,       // protocol version
+		0x00, 0x09, // hostname length
+		0x00, 0x6c, 0x00, 0x6f, 0x00, 0x63, 0x00, 0x61, 0x00, 0x6c, 0x00, 0x68, // hostname
+		0x00, 0x6f, 0x00, 0x73, 0x00, 0x74,
+		0x00, 0x00, 0x63, 0xdd, // port
+	}
 )
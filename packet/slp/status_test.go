@@ -5,7 +5,9 @@ import (
 	"slices"
 	"testing"
 
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 )
 
 func TestWriteStatusResponse(t *testing.T) {
@@ -13,18 +15,45 @@ func TestWriteStatusResponse(t *testing.T) {
 
 	tests := []struct {
 		desc  string
-		input int
+		input Status
 		want  []byte
 	}{
 		{
-			desc:  "standard",
-			input: 765,
+			desc: "standard",
+			input: Status{
+				VersionName:   "1.20.4",
+				Protocol:      765,
+				MaxPlayers:    20,
+				OnlinePlayers: 0,
+				Description:   types.TextComponent{Text: "A Minecraft Server"},
+			},
 			want: slices.Concat(
 				// header
-				[]byte{0x83, 0x4a, 0x00},
+				[]byte{0xab, 0x01, 0x00},
 				// payload
-				[]byte{0x80, 0x4a},
-				[]byte(`{"version":{"name":"1.20.4","protocol":765},"players":{"max":34,"online":12,"sample":null},"description":{"text":"The Minecraft client-server protocol kinda sucks ngl"},"favicon":"`+iconDataURI+`","enforcesSecureChat":false,"previewsChat":false}`),
+				[]byte{0xa8, 0x01},
+				[]byte(`{"version":{"name":"1.20.4","protocol":765},"players":{"max":20,"online":0},"description":{"text":"A Minecraft Server"},"enforcesSecureChat":false,"previewsChat":false}`),
+			),
+		},
+		{
+			desc: "sample and favicon",
+			input: Status{
+				VersionName:   "1.20.4",
+				Protocol:      765,
+				MaxPlayers:    20,
+				OnlinePlayers: 1,
+				Sample: []StatusPlayer{
+					{Name: "airfors", ID: uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8")},
+				},
+				Description: types.TextComponent{Text: "hi"},
+				Favicon:     "data:image/png;base64,AA==",
+			},
+			want: slices.Concat(
+				// header
+				[]byte{0x8c, 0x02, 0x00},
+				// payload
+				[]byte{0x89, 0x02},
+				[]byte(`{"version":{"name":"1.20.4","protocol":765},"players":{"max":20,"online":1,"sample":[{"name":"airfors","id":"8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"}]},"description":{"text":"hi"},"favicon":"data:image/png;base64,AA==","enforcesSecureChat":false,"previewsChat":false}`),
 			),
 		},
 	}
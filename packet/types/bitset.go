@@ -0,0 +1,63 @@
+package types
+
+// BitSet is a length-prefixed bit set,
+// where bit i is bit i%64 of the long at index i/64.
+// https://wiki.vg/Protocol#BitSet
+type BitSet []int64
+
+// Get returns whether bit i is set.
+func (b BitSet) Get(i int) bool {
+	if i < 0 || i/64 >= len(b) {
+		return false
+	}
+	return b[i/64]&(1<<(i%64)) != 0
+}
+
+// Set sets bit i, growing the set if needed.
+func (b *BitSet) Set(i int) {
+	for i/64 >= len(*b) {
+		*b = append(*b, 0)
+	}
+	(*b)[i/64] |= 1 << (i % 64)
+}
+
+// Clear clears bit i.
+func (b BitSet) Clear(i int) {
+	if i < 0 || i/64 >= len(b) {
+		return
+	}
+	b[i/64] &^= 1 << (i % 64)
+}
+
+// FixedBitSet is a bit set with a fixed length known to both sides,
+// where bit i is bit i%8 of the byte at index i/8.
+// https://wiki.vg/Protocol#Fixed_BitSet
+type FixedBitSet []byte
+
+// NewFixedBitSet returns an empty fixed bit set of n bits.
+func NewFixedBitSet(n int) FixedBitSet {
+	return make(FixedBitSet, FixedBitSetLen(n))
+}
+
+// FixedBitSetLen returns the number of bytes in a fixed bit set of n bits.
+func FixedBitSetLen(n int) int {
+	return (n + 7) / 8
+}
+
+// Get returns whether bit i is set.
+func (b FixedBitSet) Get(i int) bool {
+	if i < 0 || i/8 >= len(b) {
+		return false
+	}
+	return b[i/8]&(1<<(i%8)) != 0
+}
+
+// Set sets bit i. It panics if i is out of range.
+func (b FixedBitSet) Set(i int) {
+	b[i/8] |= 1 << (i % 8)
+}
+
+// Clear clears bit i. It panics if i is out of range.
+func (b FixedBitSet) Clear(i int) {
+	b[i/8] &^= 1 << (i % 8)
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Color is the color of a text component,
+// either one of the named colors or a hex color like "#ff0000".
+type Color string
+
+// Named colors, in the order of their legacy formatting codes (0-f).
+const (
+	ColorBlack       Color = "black"
+	ColorDarkBlue    Color = "dark_blue"
+	ColorDarkGreen   Color = "dark_green"
+	ColorDarkAqua    Color = "dark_aqua"
+	ColorDarkRed     Color = "dark_red"
+	ColorDarkPurple  Color = "dark_purple"
+	ColorGold        Color = "gold"
+	ColorGray        Color = "gray"
+	ColorDarkGray    Color = "dark_gray"
+	ColorBlue        Color = "blue"
+	ColorGreen       Color = "green"
+	ColorAqua        Color = "aqua"
+	ColorRed         Color = "red"
+	ColorLightPurple Color = "light_purple"
+	ColorYellow      Color = "yellow"
+	ColorWhite       Color = "white"
+)
+
+// namedColor is a named color and its legacy code and RGB value.
+type namedColor struct {
+	color Color
+	code  byte
+	rgb   uint32
+}
+
+var namedColors = []namedColor{
+	{ColorBlack, '0', 0x000000},
+	{ColorDarkBlue, '1', 0x0000aa},
+	{ColorDarkGreen, '2', 0x00aa00},
+	{ColorDarkAqua, '3', 0x00aaaa},
+	{ColorDarkRed, '4', 0xaa0000},
+	{ColorDarkPurple, '5', 0xaa00aa},
+	{ColorGold, '6', 0xffaa00},
+	{ColorGray, '7', 0xaaaaaa},
+	{ColorDarkGray, '8', 0x555555},
+	{ColorBlue, '9', 0x5555ff},
+	{ColorGreen, 'a', 0x55ff55},
+	{ColorAqua, 'b', 0x55ffff},
+	{ColorRed, 'c', 0xff5555},
+	{ColorLightPurple, 'd', 0xff55ff},
+	{ColorYellow, 'e', 0xffff55},
+	{ColorWhite, 'f', 0xffffff},
+}
+
+// HexColor returns the hex color with the given RGB value.
+func HexColor(rgb uint32) Color {
+	return Color(fmt.Sprintf("#%06x", rgb&0xffffff))
+}
+
+// RGB returns the RGB value of the color.
+// It returns false if the color isn't named or a valid hex color.
+func (c Color) RGB() (uint32, bool) {
+	if hex, ok := strings.CutPrefix(string(c), "#"); ok {
+		if len(hex) != 6 {
+			return 0, false
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return 0, false
+		}
+		return uint32(rgb), true
+	}
+	for _, nc := range namedColors {
+		if nc.color == c {
+			return nc.rgb, true
+		}
+	}
+	return 0, false
+}
+
+// legacyCode returns the legacy formatting code of the named color
+// nearest to the color.
+func (c Color) legacyCode() (byte, bool) {
+	rgb, ok := c.RGB()
+	if !ok {
+		return 0, false
+	}
+	nearest := namedColors[0]
+	nearestDist := -1
+	for _, nc := range namedColors {
+		if dist := colorDistance(rgb, nc.rgb); nearestDist < 0 || dist < nearestDist {
+			nearest, nearestDist = nc, dist
+		}
+	}
+	return nearest.code, true
+}
+
+// colorDistance returns the squared distance between two RGB values.
+func colorDistance(a, b uint32) int {
+	var dist int
+	for shift := 0; shift <= 16; shift += 8 {
+		d := int(a>>shift&0xff) - int(b>>shift&0xff)
+		dist += d * d
+	}
+	return dist
+}
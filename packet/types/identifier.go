@@ -0,0 +1,74 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultNamespace is the namespace of identifiers without one.
+const DefaultNamespace = "minecraft"
+
+// ErrInvalidIdentifier is returned when parsing a malformed identifier.
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// Identifier is a namespaced location, e.g. "minecraft:stone".
+// https://wiki.vg/Protocol#Identifier
+type Identifier string
+
+// NewIdentifier returns the identifier of path in namespace.
+func NewIdentifier(namespace, path string) Identifier {
+	return Identifier(namespace + ":" + path)
+}
+
+// ParseIdentifier parses and validates an identifier,
+// adding the default namespace if it has none.
+func ParseIdentifier(s string) (Identifier, error) {
+	namespace, path, ok := strings.Cut(s, ":")
+	if !ok {
+		namespace, path = DefaultNamespace, s
+	}
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	if path == "" {
+		return "", fmt.Errorf("%q has an empty path: %w", s, ErrInvalidIdentifier)
+	}
+	if i := strings.IndexFunc(namespace, func(r rune) bool { return !validNamespaceRune(r) }); i >= 0 {
+		return "", fmt.Errorf("%q has an invalid character in its namespace at %d: %w", s, i, ErrInvalidIdentifier)
+	}
+	if i := strings.IndexFunc(path, func(r rune) bool { return !validPathRune(r) }); i >= 0 {
+		return "", fmt.Errorf("%q has an invalid character in its path at %d: %w", s, i, ErrInvalidIdentifier)
+	}
+	return NewIdentifier(namespace, path), nil
+}
+
+// Namespace returns the identifier's namespace.
+func (id Identifier) Namespace() string {
+	namespace, _, ok := strings.Cut(string(id), ":")
+	if !ok || namespace == "" {
+		return DefaultNamespace
+	}
+	return namespace
+}
+
+// Path returns the identifier's path, i.e. everything after the namespace.
+func (id Identifier) Path() string {
+	if _, path, ok := strings.Cut(string(id), ":"); ok {
+		return path
+	}
+	return string(id)
+}
+
+// String returns the identifier with its namespace.
+func (id Identifier) String() string {
+	return id.Namespace() + ":" + id.Path()
+}
+
+func validNamespaceRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' || r == '_'
+}
+
+func validPathRune(r rune) bool {
+	return validNamespaceRune(r) || r == '/'
+}
@@ -0,0 +1,117 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON implements json.Marshaler.
+// Text is always written for text components, even if it's empty,
+// since it's what makes them text components.
+func (c TextComponent) MarshalJSON() ([]byte, error) {
+	// component has the same fields, but not the methods.
+	type component TextComponent
+	var text *string
+	if c.isText() {
+		text = &c.Text
+	}
+	return json.Marshal(struct {
+		// Text shadows the embedded Text field.
+		Text *string `json:"text,omitempty"`
+		component
+	}{text, component(c)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Besides objects, components can be strings, which are text components,
+// or arrays, where the rest of the components are extra of the first.
+func (c *TextComponent) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return fmt.Errorf("empty text component")
+	}
+
+	switch b[0] {
+	case '"':
+		*c = TextComponent{}
+		return json.Unmarshal(b, &c.Text)
+	case '[':
+		var parts []TextComponent
+		if err := json.Unmarshal(b, &parts); err != nil {
+			return err
+		}
+		if len(parts) == 0 {
+			return fmt.Errorf("empty text component array")
+		}
+		*c = parts[0]
+		c.Extra = append(c.Extra, parts[1:]...)
+		return nil
+	case '{':
+		type component TextComponent
+		var v component
+		if err := json.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		*c = TextComponent(v)
+		return nil
+	default:
+		// Other primitives are shown as their text, like the Notchian client.
+		*c = TextComponent{Text: string(b)}
+		return nil
+	}
+}
+
+// hoverEvent is the JSON format of a HoverEvent.
+type hoverEvent struct {
+	Action   HoverAction     `json:"action"`
+	Contents json.RawMessage `json:"contents"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e HoverEvent) MarshalJSON() ([]byte, error) {
+	var contents any
+	switch e.Action {
+	case HoverShowText:
+		contents = e.Text
+	case HoverShowItem:
+		contents = e.Item
+	case HoverShowEntity:
+		contents = e.Entity
+	default:
+		return nil, fmt.Errorf("unknown hover event action %q", e.Action)
+	}
+	b, err := json.Marshal(contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s hover event contents: %w", e.Action, err)
+	}
+	return json.Marshal(hoverEvent{Action: e.Action, Contents: b})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (e *HoverEvent) UnmarshalJSON(b []byte) error {
+	var he hoverEvent
+	if err := json.Unmarshal(b, &he); err != nil {
+		return err
+	}
+
+	*e = HoverEvent{Action: he.Action}
+	var contents any
+	switch he.Action {
+	case HoverShowText:
+		e.Text = &TextComponent{}
+		contents = e.Text
+	case HoverShowItem:
+		e.Item = &HoverItem{}
+		contents = e.Item
+	case HoverShowEntity:
+		e.Entity = &HoverEntity{}
+		contents = e.Entity
+	default:
+		return fmt.Errorf("unknown hover event action %q", he.Action)
+	}
+	if err := json.Unmarshal(he.Contents, contents); err != nil {
+		return fmt.Errorf("failed to unmarshal %s hover event contents: %w", he.Action, err)
+	}
+	return nil
+}
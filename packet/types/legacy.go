@@ -0,0 +1,263 @@
+package types
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// legacyPrefix starts a legacy formatting code.
+const legacyPrefix = '§'
+
+// Legacy formatting codes other than colors.
+const (
+	legacyObfuscated    = 'k'
+	legacyBold          = 'l'
+	legacyStrikethrough = 'm'
+	legacyUnderlined    = 'n'
+	legacyItalic        = 'o'
+	legacyReset         = 'r'
+	// legacyHex starts a hex color, as six more codes, e.g. §x§f§f§0§0§0§0.
+	// It's not supported by vanilla clients, but is by common proxies.
+	legacyHex = 'x'
+)
+
+// style is the resolved style of text, for legacy formatting codes.
+type style struct {
+	color         Color
+	bold          bool
+	italic        bool
+	underlined    bool
+	strikethrough bool
+	obfuscated    bool
+}
+
+// with returns the style of c's text, inheriting from s.
+func (s style) with(c TextComponent) style {
+	if c.Color != "" {
+		s.color = c.Color
+	}
+	for _, f := range []struct {
+		set *bool
+		dst *bool
+	}{
+		{c.Bold, &s.bold},
+		{c.Italic, &s.italic},
+		{c.Underlined, &s.underlined},
+		{c.Strikethrough, &s.strikethrough},
+		{c.Obfuscated, &s.obfuscated},
+	} {
+		if f.set != nil {
+			*f.dst = *f.set
+		}
+	}
+	return s
+}
+
+// component returns a text component with the text in the style.
+func (s style) component(text string) TextComponent {
+	c := TextComponent{Text: text, Color: s.color}
+	for _, f := range []struct {
+		set bool
+		dst **bool
+	}{
+		{s.bold, &c.Bold},
+		{s.italic, &c.Italic},
+		{s.underlined, &c.Underlined},
+		{s.strikethrough, &c.Strikethrough},
+		{s.obfuscated, &c.Obfuscated},
+	} {
+		if f.set {
+			*f.dst = Bool(true)
+		}
+	}
+	return c
+}
+
+// formats returns the legacy codes of the formats in the style, in order.
+func (s style) formats() []byte {
+	var codes []byte
+	for _, f := range []struct {
+		set  bool
+		code byte
+	}{
+		{s.obfuscated, legacyObfuscated},
+		{s.bold, legacyBold},
+		{s.strikethrough, legacyStrikethrough},
+		{s.underlined, legacyUnderlined},
+		{s.italic, legacyItalic},
+	} {
+		if f.set {
+			codes = append(codes, f.code)
+		}
+	}
+	return codes
+}
+
+// legacyCodes returns the legacy codes to change from the prev style to s.
+func (s style) legacyCodes(prev style) string {
+	var b strings.Builder
+	code := func(c byte) {
+		b.WriteRune(legacyPrefix)
+		b.WriteByte(c)
+	}
+
+	// Formats can only be removed by a color code or reset.
+	removesFormat := (prev.bold && !s.bold) || (prev.italic && !s.italic) ||
+		(prev.underlined && !s.underlined) || (prev.strikethrough && !s.strikethrough) ||
+		(prev.obfuscated && !s.obfuscated)
+	if s.color != prev.color || removesFormat {
+		if c, ok := s.color.legacyCode(); ok {
+			code(c)
+		} else {
+			code(legacyReset)
+		}
+		prev = style{color: s.color}
+	}
+
+	prevFormats := string(prev.formats())
+	for _, c := range s.formats() {
+		if !strings.ContainsRune(prevFormats, rune(c)) {
+			code(c)
+		}
+	}
+	return b.String()
+}
+
+// Legacy returns the component as a string with legacy formatting codes,
+// e.g. "§aHello §lworld", for clients that don't support components.
+// Hex colors are changed to the nearest named color,
+// and click and hover events are lost.
+func (c TextComponent) Legacy() string {
+	var b strings.Builder
+	var cur style
+	c.walk(style{}, func(text string, s style) {
+		if text == "" {
+			return
+		}
+		if s != cur {
+			b.WriteString(s.legacyCodes(cur))
+			cur = s
+		}
+		b.WriteString(text)
+	})
+	return b.String()
+}
+
+// ParseLegacy parses a string with legacy formatting codes,
+// e.g. "§aHello §lworld", into a component.
+// Hex colors in the §x§r§r§g§g§b§b format are also parsed.
+// Unknown codes are kept as text.
+func ParseLegacy(s string) TextComponent {
+	var (
+		parts []TextComponent
+		cur   style
+		text  strings.Builder
+	)
+	flush := func() {
+		if text.Len() > 0 {
+			parts = append(parts, cur.component(text.String()))
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != legacyPrefix || i+size >= len(s) {
+			text.WriteRune(r)
+			i += size
+			continue
+		}
+		code := lower(s[i+size])
+		next := i + size + 1
+
+		switch {
+		case code == legacyHex:
+			hex, n, ok := parseLegacyHex(s[next:])
+			if !ok {
+				text.WriteString(s[i:next])
+				i = next
+				continue
+			}
+			flush()
+			cur = style{color: Color("#" + hex)}
+			next += n
+		case code == legacyReset:
+			flush()
+			cur = style{}
+		case code == legacyObfuscated:
+			flush()
+			cur.obfuscated = true
+		case code == legacyBold:
+			flush()
+			cur.bold = true
+		case code == legacyStrikethrough:
+			flush()
+			cur.strikethrough = true
+		case code == legacyUnderlined:
+			flush()
+			cur.underlined = true
+		case code == legacyItalic:
+			flush()
+			cur.italic = true
+		default:
+			color, ok := legacyColor(code)
+			if !ok {
+				text.WriteRune(r)
+				i += size
+				continue
+			}
+			flush()
+			// Colors reset formatting.
+			cur = style{color: color}
+		}
+		i = next
+	}
+	flush()
+
+	switch len(parts) {
+	case 0:
+		return TextComponent{}
+	case 1:
+		return parts[0]
+	default:
+		return TextComponent{Extra: parts}
+	}
+}
+
+// legacyColor returns the named color with the legacy code.
+func legacyColor(code byte) (Color, bool) {
+	for _, nc := range namedColors {
+		if nc.code == code {
+			return nc.color, true
+		}
+	}
+	return "", false
+}
+
+// parseLegacyHex parses the six codes after §x
+// and returns the hex color and the number of bytes they take up.
+func parseLegacyHex(s string) (string, int, bool) {
+	var hex []byte
+	i := 0
+	for range 6 {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r != legacyPrefix || i+size >= len(s) {
+			return "", 0, false
+		}
+		c := lower(s[i+size])
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return "", 0, false
+		}
+		hex = append(hex, c)
+		i += size + 1
+	}
+	return string(hex), i, true
+}
+
+// lower returns the lowercase of an ASCII letter.
+func lower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,161 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLegacy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc  string
+		input string
+		want  types.TextComponent
+	}{
+		{
+			desc:  "empty",
+			input: "",
+			want:  types.TextComponent{},
+		},
+		{
+			desc:  "plain",
+			input: "A Minecraft Server",
+			want:  types.TextComponent{Text: "A Minecraft Server"},
+		},
+		{
+			desc:  "color",
+			input: "§aHello",
+			want:  types.TextComponent{Text: "Hello", Color: types.ColorGreen},
+		},
+		{
+			desc:  "uppercase code",
+			input: "§AHello",
+			want:  types.TextComponent{Text: "Hello", Color: types.ColorGreen},
+		},
+		{
+			desc:  "formats",
+			input: "§c§lHi §othere§rplain",
+			want: types.TextComponent{Extra: []types.TextComponent{
+				{Text: "Hi ", Color: types.ColorRed, Bold: types.Bool(true)},
+				{Text: "there", Color: types.ColorRed, Bold: types.Bool(true), Italic: types.Bool(true)},
+				{Text: "plain"},
+			}},
+		},
+		{
+			desc:  "color resets formats",
+			input: "§lbold§9blue",
+			want: types.TextComponent{Extra: []types.TextComponent{
+				{Text: "bold", Bold: types.Bool(true)},
+				{Text: "blue", Color: types.ColorBlue},
+			}},
+		},
+		{
+			desc:  "hex color",
+			input: "§x§f§f§8§8§0§0orange",
+			want:  types.TextComponent{Text: "orange", Color: "#ff8800"},
+		},
+		{
+			desc:  "unknown code",
+			input: "§zé§",
+			want:  types.TextComponent{Text: "§zé§"},
+		},
+		{
+			desc:  "prefix before multibyte rune",
+			input: "a§é",
+			want:  types.TextComponent{Text: "a§é"},
+		},
+		{
+			desc:  "invalid hex color",
+			input: "§x§fnope",
+			want: types.TextComponent{Extra: []types.TextComponent{
+				{Text: "§x"},
+				{Text: "nope", Color: types.ColorWhite},
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := types.ParseLegacy(tc.input)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseLegacy(%q) diff (-want +got):\n%s", tc.input, diff)
+			}
+		})
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc  string
+		input types.TextComponent
+		want  string
+	}{
+		{
+			desc:  "plain",
+			input: types.TextComponent{Text: "hi"},
+			want:  "hi",
+		},
+		{
+			desc: "inherited style",
+			input: types.TextComponent{
+				Text:  "Hi ",
+				Color: types.ColorRed,
+				Extra: []types.TextComponent{
+					{Text: "bold", Bold: types.Bool(true)},
+					{Text: " red"},
+				},
+			},
+			want: "§cHi §lbold§c red",
+		},
+		{
+			desc: "reset",
+			input: types.TextComponent{Extra: []types.TextComponent{
+				{Text: "a", Italic: types.Bool(true)},
+				{Text: "b"},
+			}},
+			want: "§oa§rb",
+		},
+		{
+			desc:  "hex color is nearest named color",
+			input: types.TextComponent{Text: "orange", Color: "#ffa500"},
+			want:  "§6orange",
+		},
+		{
+			desc:  "translate without fallback",
+			input: types.TextComponent{Translate: "multiplayer.disconnect.server_shutdown"},
+			want:  "multiplayer.disconnect.server_shutdown",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.input.Legacy()
+
+			if got != tc.want {
+				t.Errorf("Legacy() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLegacyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{
+		"§aHello §lworld",
+		"§c§lHi §othere§rplain",
+		"§kmagic§r and §nunderlined§m struck",
+	} {
+		if got := types.ParseLegacy(s).Legacy(); got != s {
+			t.Errorf("ParseLegacy(%q).Legacy() = %q", s, got)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// boolKeys are the keys of boolean fields,
+// which are encoded as bytes in NBT.
+var boolKeys = map[string]bool{
+	"bold":          true,
+	"italic":        true,
+	"underlined":    true,
+	"strikethrough": true,
+	"obfuscated":    true,
+}
+
+// MarshalNBT implements nbt.Marshaler.
+// Components are encoded as compounds with the same keys as in JSON.
+func (c TextComponent) MarshalNBT() (any, error) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode text component JSON: %w", err)
+	}
+	return fromJSON(v), nil
+}
+
+// fromJSON converts a decoded JSON value to the value it's encoded as in NBT.
+func fromJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = fromJSON(e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = fromJSON(e)
+		}
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil && int64(int32(i)) == i {
+			return int32(i)
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+// UnmarshalNBT implements nbt.Unmarshaler.
+// Besides compounds, components can be strings, which are text components,
+// or lists, where the rest of the components are extra of the first.
+func (c *TextComponent) UnmarshalNBT(v any) error {
+	b, err := json.Marshal(toJSON("", v))
+	if err != nil {
+		return fmt.Errorf("failed to convert text component to JSON: %w", err)
+	}
+	return json.Unmarshal(b, c)
+}
+
+// toJSON converts a decoded NBT value with the given key
+// to the value it's encoded as in JSON.
+func toJSON(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		// Lists with elements of different types
+		// have them wrapped in compounds with an empty key.
+		if e, ok := v[""]; ok && len(v) == 1 {
+			return toJSON(key, e)
+		}
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = toJSON(k, e)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, e := range v {
+			l[i] = toJSON(key, e)
+		}
+		return l
+	case int8:
+		if boolKeys[key] {
+			return v != 0
+		}
+		return v
+	case []int32:
+		// UUIDs may be encoded as int arrays.
+		if key == "id" && len(v) == 4 {
+			var u uuid.UUID
+			for i, n := range v {
+				binary.BigEndian.PutUint32(u[i*4:], uint32(n))
+			}
+			return u.String()
+		}
+		return v
+	default:
+		return v
+	}
+}
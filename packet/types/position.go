@@ -0,0 +1,51 @@
+package types
+
+import (
+	"fmt"
+	"math"
+)
+
+// Position is the location of a block.
+// https://wiki.vg/Protocol#Position
+type Position struct {
+	// X is between -33554432 and 33554431.
+	X int32
+	// Y is between -2048 and 2047.
+	Y int32
+	// Z is between -33554432 and 33554431.
+	Z int32
+}
+
+// Pack returns the position packed into a 64-bit integer:
+// x as a 26-bit integer, then z as a 26-bit integer, then y as a 12-bit integer.
+func (p Position) Pack() int64 {
+	return int64(p.X&0x3ffffff)<<38 | int64(p.Z&0x3ffffff)<<12 | int64(p.Y&0xfff)
+}
+
+// UnpackPosition returns the position packed into v.
+func UnpackPosition(v int64) Position {
+	return Position{
+		X: int32(v >> 38),
+		Y: int32(v << 52 >> 52),
+		Z: int32(v << 26 >> 38),
+	}
+}
+
+// String returns the position as "x, y, z".
+func (p Position) String() string {
+	return fmt.Sprintf("%d, %d, %d", p.X, p.Y, p.Z)
+}
+
+// Angle is a rotation angle in steps of 1/256 of a full turn.
+// https://wiki.vg/Protocol#Angle
+type Angle uint8
+
+// AngleFromDegrees returns the nearest angle to the given degrees.
+func AngleFromDegrees(deg float32) Angle {
+	return Angle(int64(math.Round(float64(deg)*256/360)) & 0xff)
+}
+
+// Degrees returns the angle in degrees, between 0 and 360.
+func (a Angle) Degrees() float32 {
+	return float32(a) * 360 / 256
+}
@@ -0,0 +1,18 @@
+package types
+
+// Slot is an item stack, e.g. in an inventory.
+// The zero value is an empty slot.
+// https://wiki.vg/Slot_Data
+type Slot struct {
+	// ItemID is the item's ID in the minecraft:item registry.
+	ItemID int32
+	// Count is the number of items in the stack.
+	Count int8
+	// NBT is the item's NBT data, e.g. its enchantments, if any.
+	NBT map[string]any
+}
+
+// Empty returns whether the slot has no items.
+func (s Slot) Empty() bool {
+	return s.Count <= 0
+}
@@ -1,10 +1,188 @@
 // Package types holds common API types.
 package types
 
-// TextComponent is a text component used throughout the API.
-// It should be JSON-marshalled and written as a String.
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TextComponent is a text component used throughout the API,
+// e.g. for MOTDs, disconnect reasons and chat.
+// It's JSON-marshalled and written as a String in the status and login states,
+// and written as network NBT in the configuration and play states.
 // https://wiki.vg/Text_formatting#Text_components
+//
+// A component's content is the first of Translate, Keybind, Score or
+// Selector that's set, or Text if none are.
+// Its style is inherited by its children, With and Extra,
+// unless they override it.
 type TextComponent struct {
-	// Text is the text.
-	Text string `json:"text"`
+	// Text is the text, for text components.
+	Text string `json:"text,omitempty"`
+	// Translate is the translation key, for translated components.
+	Translate string `json:"translate,omitempty"`
+	// With are the arguments of the translation.
+	With []TextComponent `json:"with,omitempty"`
+	// Fallback is shown if the translation key isn't known.
+	Fallback string `json:"fallback,omitempty"`
+	// Keybind is the ID of a keybind, e.g. "key.inventory",
+	// for keybind components, which show the key it's bound to.
+	Keybind string `json:"keybind,omitempty"`
+	// Score is the score shown, for score components.
+	Score *Score `json:"score,omitempty"`
+	// Selector is an entity selector, for selector components,
+	// which show the names of the entities it selects.
+	Selector string `json:"selector,omitempty"`
+	// Separator separates the entities' names in selector components.
+	// Defaults to a gray ", ".
+	Separator *TextComponent `json:"separator,omitempty"`
+
+	// Color is a named color, e.g. ColorRed, or a hex color, e.g. "#ff0000".
+	Color Color `json:"color,omitempty"`
+	// Font is the resource location of the font, e.g. "minecraft:uniform".
+	Font string `json:"font,omitempty"`
+	// Bold is whether the text is bold, or inherited if nil.
+	Bold *bool `json:"bold,omitempty"`
+	// Italic is whether the text is italic, or inherited if nil.
+	Italic *bool `json:"italic,omitempty"`
+	// Underlined is whether the text is underlined, or inherited if nil.
+	Underlined *bool `json:"underlined,omitempty"`
+	// Strikethrough is whether the text is struck through, or inherited if nil.
+	Strikethrough *bool `json:"strikethrough,omitempty"`
+	// Obfuscated is whether the text is obfuscated, or inherited if nil.
+	Obfuscated *bool `json:"obfuscated,omitempty"`
+	// Insertion is inserted into the chat input when the text is shift-clicked.
+	Insertion string `json:"insertion,omitempty"`
+	// ClickEvent happens when the text is clicked.
+	ClickEvent *ClickEvent `json:"clickEvent,omitempty"`
+	// HoverEvent happens when the text is hovered over.
+	HoverEvent *HoverEvent `json:"hoverEvent,omitempty"`
+
+	// Extra are components appended after this one.
+	Extra []TextComponent `json:"extra,omitempty"`
+}
+
+// Bool returns a pointer to b, for the style fields of a TextComponent.
+func Bool(b bool) *bool { return &b }
+
+// isText returns whether the component's content is its Text.
+func (c TextComponent) isText() bool {
+	return c.Translate == "" && c.Keybind == "" && c.Score == nil && c.Selector == ""
+}
+
+// String returns the component's text without formatting,
+// including its children.
+// Translated components are shown as their fallback or key,
+// and keybind, score and selector components as their raw values.
+func (c TextComponent) String() string {
+	var b strings.Builder
+	c.walk(style{}, func(text string, _ style) {
+		b.WriteString(text)
+	})
+	return b.String()
+}
+
+// content returns the component's own text, without its children.
+func (c TextComponent) content() string {
+	switch {
+	case c.Translate != "":
+		if c.Fallback != "" {
+			return c.Fallback
+		}
+		return c.Translate
+	case c.Keybind != "":
+		return c.Keybind
+	case c.Score != nil:
+		return c.Score.Value
+	case c.Selector != "":
+		return c.Selector
+	default:
+		return c.Text
+	}
+}
+
+// walk calls f with the text of the component and each of its children,
+// in order, with the style they inherit from their parents.
+func (c TextComponent) walk(parent style, f func(text string, s style)) {
+	s := parent.with(c)
+	f(c.content(), s)
+	for _, arg := range c.With {
+		arg.walk(s, f)
+	}
+	for _, child := range c.Extra {
+		child.walk(s, f)
+	}
+}
+
+// Score is the content of a score component.
+type Score struct {
+	// Name is the name of the score holder,
+	// or a selector that selects one entity.
+	Name string `json:"name"`
+	// Objective is the name of the objective.
+	Objective string `json:"objective"`
+	// Value is shown instead of the score, if set.
+	// Only used by old clients.
+	Value string `json:"value,omitempty"`
+}
+
+// ClickAction is what happens when a ClickEvent's text is clicked.
+type ClickAction string
+
+const (
+	ClickOpenURL         ClickAction = "open_url"
+	ClickRunCommand      ClickAction = "run_command"
+	ClickSuggestCommand  ClickAction = "suggest_command"
+	ClickChangePage      ClickAction = "change_page"
+	ClickCopyToClipboard ClickAction = "copy_to_clipboard"
+)
+
+// ClickEvent happens when a component's text is clicked.
+type ClickEvent struct {
+	Action ClickAction `json:"action"`
+	// Value is the URL, command, page number or text to copy,
+	// depending on the action.
+	Value string `json:"value"`
+}
+
+// HoverAction is what's shown when a HoverEvent's text is hovered over.
+type HoverAction string
+
+const (
+	HoverShowText   HoverAction = "show_text"
+	HoverShowItem   HoverAction = "show_item"
+	HoverShowEntity HoverAction = "show_entity"
+)
+
+// HoverEvent happens when a component's text is hovered over.
+// The field matching the action is set.
+type HoverEvent struct {
+	Action HoverAction
+	// Text is shown, for HoverShowText.
+	Text *TextComponent
+	// Item is shown, for HoverShowItem.
+	Item *HoverItem
+	// Entity is shown, for HoverShowEntity.
+	Entity *HoverEntity
+}
+
+// HoverItem is an item shown when text is hovered over.
+type HoverItem struct {
+	// ID is the item's resource location, e.g. "minecraft:diamond".
+	ID string `json:"id"`
+	// Count is the number of items. Defaults to 1.
+	Count int32 `json:"count,omitempty"`
+	// Tag is the item's NBT, in SNBT format.
+	Tag string `json:"tag,omitempty"`
+}
+
+// HoverEntity is an entity shown when text is hovered over.
+type HoverEntity struct {
+	// Type is the entity type's resource location, e.g. "minecraft:pig".
+	Type string `json:"type"`
+	// ID is the entity's UUID.
+	ID uuid.UUID `json:"id"`
+	// Name is the entity's name.
+	Name *TextComponent `json:"name,omitempty"`
 }
@@ -0,0 +1,246 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc  string
+		input types.TextComponent
+		want  string
+	}{
+		{
+			desc:  "text",
+			input: types.TextComponent{Text: "hi"},
+			want:  `{"text":"hi"}`,
+		},
+		{
+			desc:  "empty text",
+			input: types.TextComponent{},
+			want:  `{"text":""}`,
+		},
+		{
+			desc: "styled",
+			input: types.TextComponent{
+				Text:   "hi",
+				Color:  types.ColorRed,
+				Bold:   types.Bool(true),
+				Italic: types.Bool(false),
+				Extra:  []types.TextComponent{{Text: "there", Color: types.HexColor(0x00ff00)}},
+			},
+			want: `{"text":"hi","color":"red","bold":true,"italic":false,"extra":[{"text":"there","color":"#00ff00"}]}`,
+		},
+		{
+			desc: "translate",
+			input: types.TextComponent{
+				Translate: "chat.type.text",
+				With:      []types.TextComponent{{Text: "Notch"}, {Text: "hi"}},
+			},
+			want: `{"translate":"chat.type.text","with":[{"text":"Notch"},{"text":"hi"}]}`,
+		},
+		{
+			desc:  "keybind",
+			input: types.TextComponent{Keybind: "key.inventory"},
+			want:  `{"keybind":"key.inventory"}`,
+		},
+		{
+			desc:  "score",
+			input: types.TextComponent{Score: &types.Score{Name: "@p", Objective: "kills"}},
+			want:  `{"score":{"name":"@p","objective":"kills"}}`,
+		},
+		{
+			desc: "events",
+			input: types.TextComponent{
+				Text:       "click",
+				ClickEvent: &types.ClickEvent{Action: types.ClickOpenURL, Value: "https://example.com"},
+				HoverEvent: &types.HoverEvent{Action: types.HoverShowText, Text: &types.TextComponent{Text: "tip"}},
+			},
+			want: `{"text":"click","clickEvent":{"action":"open_url","value":"https://example.com"},"hoverEvent":{"action":"show_text","contents":{"text":"tip"}}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := json.Marshal(tc.input)
+			if err != nil {
+				t.Fatalf("Marshal() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("Marshal() diff (-want +got):\n%s", diff)
+			}
+
+			var roundTrip types.TextComponent
+			if err := json.Unmarshal(got, &roundTrip); err != nil {
+				t.Fatalf("Unmarshal() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tc.input, roundTrip); diff != "" {
+				t.Errorf("Unmarshal(Marshal()) diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	entityID := uuid.MustParse("b50ad385-829d-3141-a216-7e7d7539ba7f")
+	tests := []struct {
+		desc    string
+		input   string
+		want    types.TextComponent
+		wantErr bool
+	}{
+		{
+			desc:  "string",
+			input: `"hi"`,
+			want:  types.TextComponent{Text: "hi"},
+		},
+		{
+			desc:  "array",
+			input: `[{"text":"a","extra":[{"text":"b"}]},"c"]`,
+			want: types.TextComponent{
+				Text:  "a",
+				Extra: []types.TextComponent{{Text: "b"}, {Text: "c"}},
+			},
+		},
+		{
+			desc:  "number",
+			input: `1`,
+			want:  types.TextComponent{Text: "1"},
+		},
+		{
+			desc:  "show entity",
+			input: `{"text":"","hoverEvent":{"action":"show_entity","contents":{"type":"minecraft:pig","id":"b50ad385-829d-3141-a216-7e7d7539ba7f"}}}`,
+			want: types.TextComponent{
+				HoverEvent: &types.HoverEvent{
+					Action: types.HoverShowEntity,
+					Entity: &types.HoverEntity{Type: "minecraft:pig", ID: entityID},
+				},
+			},
+		},
+		{
+			desc:    "empty array",
+			input:   `[]`,
+			wantErr: true,
+		},
+		{
+			desc:    "unknown hover action",
+			input:   `{"text":"","hoverEvent":{"action":"show_achievement","contents":"a"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got types.TextComponent
+			err := json.Unmarshal([]byte(tc.input), &got)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Unmarshal() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Unmarshal() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNBT(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc  string
+		input types.TextComponent
+	}{
+		{
+			desc:  "text",
+			input: types.TextComponent{Text: "hi"},
+		},
+		{
+			desc: "styled",
+			input: types.TextComponent{
+				Text:          "hi",
+				Color:         types.ColorGold,
+				Bold:          types.Bool(true),
+				Strikethrough: types.Bool(false),
+				Extra:         []types.TextComponent{{Text: "a"}, {Translate: "b"}},
+			},
+		},
+		{
+			desc: "show item",
+			input: types.TextComponent{
+				Text: "item",
+				HoverEvent: &types.HoverEvent{
+					Action: types.HoverShowItem,
+					Item:   &types.HoverItem{ID: "minecraft:diamond", Count: 2},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := nbt.MarshalNetwork(tc.input)
+			if err != nil {
+				t.Fatalf("MarshalNetwork() unexpected err: %v", err)
+			}
+			if got := nbt.TagType(b[0]); got != nbt.TagCompound {
+				t.Errorf("MarshalNetwork() root tag = %s, want %s", got, nbt.TagCompound)
+			}
+
+			var got types.TextComponent
+			if err := nbt.UnmarshalNetwork(b, &got); err != nil {
+				t.Fatalf("UnmarshalNetwork() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tc.input, got); diff != "" {
+				t.Errorf("UnmarshalNetwork(MarshalNetwork()) diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalNBTString(t *testing.T) {
+	t.Parallel()
+
+	// A string tag with "hi".
+	input := []byte{0x08, 0x00, 0x02, 'h', 'i'}
+
+	var got types.TextComponent
+	if err := nbt.UnmarshalNetwork(input, &got); err != nil {
+		t.Fatalf("UnmarshalNetwork() unexpected err: %v", err)
+	}
+	if diff := cmp.Diff(types.TextComponent{Text: "hi"}, got); diff != "" {
+		t.Errorf("UnmarshalNetwork() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	input := types.TextComponent{
+		Text:  "Hello ",
+		Color: types.ColorRed,
+		Extra: []types.TextComponent{
+			{Text: "world", Bold: types.Bool(true)},
+			{Keybind: "key.jump"},
+		},
+	}
+	if got, want := input.String(), "Hello worldkey.jump"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -2,6 +2,7 @@
 package writepacket
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
@@ -10,22 +11,37 @@ import (
 )
 
 // Write writes a packet to the writer.
+//
+// The whole packet is written with a single call to w.Write,
+// so writers that re-frame packets (e.g. compression.Writer)
+// always see complete packets.
 func Write(w io.Writer, id id.ID, payload readLengther) error {
 	payloadLen := payload.Len()
 	h := packet.Header{
 		Length:   int32(id.Len() + payloadLen),
 		PacketID: id,
 	}
-	if err := h.WriteHeader(w); err != nil {
+
+	var buf bytes.Buffer
+	if err := h.WriteHeader(&buf); err != nil {
 		return fmt.Errorf("failed to write packet header (%+v): %w", h, err)
 	}
 
-	wroteLen, err := io.Copy(w, payload)
+	copiedLen, err := io.Copy(&buf, payload)
+	if err != nil {
+		return fmt.Errorf("failed to copy packet payload: %w", err)
+	}
+	if copiedLen != int64(payloadLen) {
+		return fmt.Errorf("writing packet payload expected to write %d bytes, but wrote %d", payloadLen, copiedLen)
+	}
+
+	wantLen := buf.Len()
+	wroteLen, err := w.Write(buf.Bytes())
 	if err != nil {
-		return fmt.Errorf("failed to write packet payload: %w", err)
+		return fmt.Errorf("failed to write packet: %w", err)
 	}
-	if wroteLen != int64(payloadLen) {
-		return fmt.Errorf("writing packet payload expected to write %d bytes, but wrote %d", payloadLen, wroteLen)
+	if wroteLen != wantLen {
+		return fmt.Errorf("writing packet expected to write %d bytes, but wrote %d", wantLen, wroteLen)
 	}
 
 	return nil
@@ -8,7 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/google/uuid"
 )
 
@@ -18,7 +21,9 @@ const (
 )
 
 var (
-	errVarIntTooBig = errors.New("varint is too big")
+	errVarIntTooBig   = errors.New("varint is too big")
+	errVarLongTooBig  = errors.New("varlong is too big")
+	errNegativeLength = errors.New("negative length")
 )
 
 // Bool reads a bool from the reader.
@@ -40,6 +45,15 @@ func Byte(r io.Reader) (byte, error) {
 	return b[0], nil
 }
 
+// Short reads a signed short from the reader.
+func Short(r io.Reader) (int16, error) {
+	v, err := UnsignedShort(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read short: %w", err)
+	}
+	return int16(v), nil
+}
+
 // UnsignedShort reads an unsigned short from the reader.
 func UnsignedShort(r io.Reader) (uint16, error) {
 	b, err := Bytes(r, 2)
@@ -78,6 +92,42 @@ func Long(r io.Reader) (int64, error) {
 	return val, nil
 }
 
+// Float reads a float from the reader.
+func Float(r io.Reader) (float32, error) {
+	v, err := Int(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read float: %w", err)
+	}
+	return math.Float32frombits(uint32(v)), nil
+}
+
+// Double reads a double from the reader.
+func Double(r io.Reader) (float64, error) {
+	v, err := Long(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read double: %w", err)
+	}
+	return math.Float64frombits(uint64(v)), nil
+}
+
+// Angle reads an angle from the reader.
+func Angle(r io.Reader) (types.Angle, error) {
+	b, err := Byte(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read angle: %w", err)
+	}
+	return types.Angle(b), nil
+}
+
+// Position reads a block position from the reader.
+func Position(r io.Reader) (types.Position, error) {
+	v, err := Long(r)
+	if err != nil {
+		return types.Position{}, fmt.Errorf("failed to read position: %w", err)
+	}
+	return types.UnpackPosition(v), nil
+}
+
 // String reads a string from the reader.
 func String(r io.Reader) (string, error) {
 	length, err := VarInt(r)
@@ -101,8 +151,10 @@ func VarInt(r io.Reader) (int32, error) {
 	for {
 		b, err := Byte(r)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				return val, nil
+			// EOF before any of the VarInt is reported as is,
+			// but a VarInt cut short by it is malformed.
+			if errors.Is(err, io.EOF) && pos > 0 {
+				return 0, fmt.Errorf("varint cut short after %d bits: %w", pos, io.ErrUnexpectedEOF)
 			}
 			return 0, fmt.Errorf("failed to read byte for varint: %w", err)
 		}
@@ -120,6 +172,43 @@ func VarInt(r io.Reader) (int32, error) {
 	}
 }
 
+// VarLong reads a VarLong from the reader.
+func VarLong(r io.Reader) (int64, error) {
+	var val int64
+	var pos int64
+
+	for {
+		b, err := Byte(r)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read byte for varlong: %w", err)
+		}
+		val |= (int64(b) & segmentBits) << pos
+
+		if b&continueBit == 0 {
+			return val, nil
+		}
+
+		pos += 7
+
+		if pos >= 64 {
+			return 0, fmt.Errorf("val=%d pos=%d: %w", val, pos, errVarLongTooBig)
+		}
+	}
+}
+
+// Identifier reads and validates an identifier from the reader.
+func Identifier(r io.Reader) (types.Identifier, error) {
+	s, err := String(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read identifier: %w", err)
+	}
+	id, err := types.ParseIdentifier(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse identifier: %w", err)
+	}
+	return id, nil
+}
+
 // UUID reads a UUID from the reader.
 //
 // UUIDs are encoded as an unsigned 128-bit integer
@@ -156,3 +245,128 @@ func Bytes(r io.Reader, count int) ([]byte, error) {
 
 	return buf, nil
 }
+
+// ByteArray reads a byte array prefixed with its length as a VarInt.
+func ByteArray(r io.Reader) ([]byte, error) {
+	length, err := length(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read byte array's length: %w", err)
+	}
+
+	b, err := Bytes(r, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read byte array: %w", err)
+	}
+	return b, nil
+}
+
+// ByteArrayMax reads a byte array prefixed with its length as a VarInt,
+// failing before reading it if it's longer than max bytes.
+func ByteArrayMax(r io.Reader, max int) ([]byte, error) {
+	length, err := length(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read byte array's length: %w", err)
+	}
+	if length > max {
+		return nil, fmt.Errorf("byte array of %d bytes is longer than the maximum of %d", length, max)
+	}
+
+	b, err := Bytes(r, length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read byte array: %w", err)
+	}
+	return b, nil
+}
+
+// BitSet reads a bit set prefixed with its length in longs as a VarInt.
+func BitSet(r io.Reader) (types.BitSet, error) {
+	longs, err := Array(r, Long)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bit set: %w", err)
+	}
+	return types.BitSet(longs), nil
+}
+
+// FixedBitSet reads a bit set of n bits from the reader.
+func FixedBitSet(r io.Reader, n int) (types.FixedBitSet, error) {
+	b, err := Bytes(r, types.FixedBitSetLen(n))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixed bit set of %d bits: %w", n, err)
+	}
+	return types.FixedBitSet(b), nil
+}
+
+// Optional reads a value prefixed with a bool saying whether it's present.
+// It returns nil if the value isn't present.
+func Optional[T any](r io.Reader, read func(io.Reader) (T, error)) (*T, error) {
+	present, err := Bool(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whether optional value is present: %w", err)
+	}
+	if !present {
+		return nil, nil
+	}
+
+	v, err := read(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read optional value: %w", err)
+	}
+	return &v, nil
+}
+
+// Array reads an array prefixed with its length as a VarInt,
+// reading each element with read.
+func Array[T any](r io.Reader, read func(io.Reader) (T, error)) ([]T, error) {
+	length, err := length(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read array's length: %w", err)
+	}
+
+	// Don't trust the length to preallocate; it may be bogus.
+	var vals []T
+	for i := range length {
+		v, err := read(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read array element %d of %d: %w", i, length, err)
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// Slot reads an item stack from the reader.
+func Slot(r io.Reader) (types.Slot, error) {
+	present, err := Bool(r)
+	if err != nil {
+		return types.Slot{}, fmt.Errorf("failed to read whether slot is present: %w", err)
+	}
+	if !present {
+		return types.Slot{}, nil
+	}
+
+	var s types.Slot
+	if s.ItemID, err = VarInt(r); err != nil {
+		return types.Slot{}, fmt.Errorf("failed to read slot's item ID: %w", err)
+	}
+	count, err := Byte(r)
+	if err != nil {
+		return types.Slot{}, fmt.Errorf("failed to read slot's item count: %w", err)
+	}
+	s.Count = int8(count)
+	if _, err := nbt.NewNetworkDecoder(r).Decode(&s.NBT); err != nil {
+		return types.Slot{}, fmt.Errorf("failed to read slot's NBT: %w", err)
+	}
+	return s, nil
+}
+
+// length reads a VarInt length, which must not be negative.
+func length(r io.Reader) (int, error) {
+	l, err := VarInt(r)
+	if err != nil {
+		return 0, err
+	}
+	if l < 0 {
+		return 0, fmt.Errorf("length=%d: %w", l, errNegativeLength)
+	}
+	return int(l), nil
+}
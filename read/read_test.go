@@ -2,10 +2,13 @@ package read_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"slices"
 	"testing"
 
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/airforce270/mc-srv/read"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
@@ -185,7 +188,7 @@ func TestVarInt(t *testing.T) {
 		{[]byte{0xe2, 0x01}, 226},
 		{[]byte{0xff, 0x01}, 255},
 		{[]byte{0xdd, 0xc7, 0x01}, 25565},
-		{[]byte{0xff, 0xff, 0xff}, 2097151},
+		{[]byte{0xff, 0xff, 0x7f}, 2097151},
 		{[]byte{0xff, 0xff, 0xff, 0xff, 0x07}, 2147483647},
 		{[]byte{0xff, 0xff, 0xff, 0xff, 0x0f}, -1},
 		{[]byte{0x80, 0x80, 0x80, 0x80, 0x08}, -2147483648},
@@ -206,6 +209,22 @@ func TestVarInt(t *testing.T) {
 	}
 }
 
+func TestVarIntEOF(t *testing.T) {
+	t.Parallel()
+
+	// EOF before any of the VarInt is reported.
+	if _, err := read.VarInt(bytes.NewReader(nil)); !errors.Is(err, io.EOF) {
+		t.Errorf("VarInt(empty) err = %v, want %v", err, io.EOF)
+	}
+
+	// A VarInt cut short by EOF is malformed.
+	for _, input := range [][]byte{{0xdd, 0xc7}, {0xff, 0xff, 0xff}} {
+		if _, err := read.VarInt(bytes.NewReader(input)); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("VarInt(%x) err = %v, want %v", input, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
 func TestUUID(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -260,3 +279,460 @@ func TestBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestShort(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input [2]byte
+		want  int16
+	}{
+		{[2]byte{0x0, 0x0}, 0},
+		{[2]byte{0x04, 0xd2}, 1234},
+		{[2]byte{0xff, 0xff}, -1},
+		{[2]byte{0x80, 0x00}, -32768},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%d", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Short(bytes.NewReader(tc.input[:]))
+			if err != nil {
+				t.Fatalf("Short() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Short() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFloat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input [4]byte
+		want  float32
+	}{
+		{[4]byte{0x0, 0x0, 0x0, 0x0}, 0},
+		{[4]byte{0x3f, 0x80, 0x0, 0x0}, 1},
+		{[4]byte{0xc0, 0x49, 0x0, 0x0}, -3.140625},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%v", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Float(bytes.NewReader(tc.input[:]))
+			if err != nil {
+				t.Fatalf("Float() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Float() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDouble(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input [8]byte
+		want  float64
+	}{
+		{[8]byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}, 0},
+		{[8]byte{0x3f, 0xf0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}, 1},
+		{[8]byte{0xc0, 0x09, 0x20, 0x0, 0x0, 0x0, 0x0, 0x0}, -3.140625},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%v", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Double(bytes.NewReader(tc.input[:]))
+			if err != nil {
+				t.Fatalf("Double() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Double() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAngle(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input byte
+		want  types.Angle
+	}{
+		{0x00, types.AngleFromDegrees(0)},
+		{0x40, types.AngleFromDegrees(90)},
+		{0xc0, types.AngleFromDegrees(-90)},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%d", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Angle(bytes.NewReader([]byte{tc.input}))
+			if err != nil {
+				t.Fatalf("Angle() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Angle() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input [8]byte
+		want  types.Position
+	}{
+		{
+			input: [8]byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
+			want:  types.Position{},
+		},
+		{
+			// Example from https://wiki.vg/Protocol#Position
+			input: [8]byte{0x46, 0x07, 0x63, 0x2c, 0x15, 0xb4, 0x83, 0x3f},
+			want:  types.Position{X: 18357644, Y: 831, Z: -20882616},
+		},
+		{
+			input: [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			want:  types.Position{X: -1, Y: -1, Z: -1},
+		},
+		{
+			input: [8]byte{0x80, 0x0, 0x0, 0x1f, 0xff, 0xff, 0xf8, 0x0},
+			want:  types.Position{X: -33554432, Y: -2048, Z: 33554431},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%s", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Position(bytes.NewReader(tc.input[:]))
+			if err != nil {
+				t.Fatalf("Position() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Position() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVarLong(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		want  int64
+	}{
+		{[]byte{0x00}, 0},
+		{[]byte{0x01}, 1},
+		{[]byte{0x7f}, 127},
+		{[]byte{0x80, 0x01}, 128},
+		{[]byte{0xff, 0x01}, 255},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0x07}, 2147483647},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, 9223372036854775807},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, -1},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0xf8, 0xff, 0xff, 0xff, 0xff, 0x01}, -2147483648},
+		{[]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}, -9223372036854775808},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%d", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.VarLong(bytes.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("VarLong() unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("VarLong() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   []byte
+		want    types.Identifier
+		wantErr bool
+	}{
+		{
+			input: slices.Concat([]byte{0x0f}, []byte("minecraft:stone")),
+			want:  "minecraft:stone",
+		},
+		{
+			input: slices.Concat([]byte{0x05}, []byte("stone")),
+			want:  "minecraft:stone",
+		},
+		{
+			input: slices.Concat([]byte{0x10}, []byte("mc-srv:brand/abc")),
+			want:  "mc-srv:brand/abc",
+		},
+		{
+			input:   slices.Concat([]byte{0x0f}, []byte("minecraft:Stone")),
+			wantErr: true,
+		},
+		{
+			input:   slices.Concat([]byte{0x0a}, []byte("minecraft:")),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%s", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Identifier(bytes.NewReader(tc.input))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Identifier() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("Identifier() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestByteArray(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		want  []byte
+	}{
+		{[]byte{0x00}, nil},
+		{[]byte{0x03, 0x11, 0x12, 0x13}, []byte{0x11, 0x12, 0x13}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.ByteArray(bytes.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("ByteArray() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ByteArray() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestByteArrayMax(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc    string
+		input   []byte
+		want    []byte
+		wantErr bool
+	}{
+		{
+			desc:  "at max",
+			input: []byte{0x03, 0x11, 0x12, 0x13},
+			want:  []byte{0x11, 0x12, 0x13},
+		},
+		{
+			desc:    "over max",
+			input:   []byte{0x04, 0x11, 0x12, 0x13, 0x14},
+			wantErr: true,
+		},
+		{
+			// Length of 2^31-1 with no data, which mustn't be allocated.
+			desc:    "huge",
+			input:   []byte{0xff, 0xff, 0xff, 0xff, 0x07},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.ByteArrayMax(bytes.NewReader(tc.input), 3)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ByteArrayMax() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ByteArrayMax() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBitSet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		want  types.BitSet
+	}{
+		{[]byte{0x00}, nil},
+		{
+			input: []byte{
+				0x02, // length
+				0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x05,
+				0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+			},
+			want: types.BitSet{5, -9223372036854775808},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%d", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.BitSet(bytes.NewReader(tc.input))
+			if err != nil {
+				t.Fatalf("BitSet() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("BitSet() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFixedBitSet(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		bits  int
+		want  types.FixedBitSet
+	}{
+		{[]byte{0x01}, 1, types.FixedBitSet{0x01}},
+		{[]byte{0x81, 0x02}, 10, types.FixedBitSet{0x81, 0x02}},
+		{[]byte{0x01, 0x02, 0x03}, 16, types.FixedBitSet{0x01, 0x02}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.FixedBitSet(bytes.NewReader(tc.input), tc.bits)
+			if err != nil {
+				t.Fatalf("FixedBitSet() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("FixedBitSet() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		want  *string
+	}{
+		{[]byte{0x00}, nil},
+		{[]byte{0x01, 0x02, 'h', 'i'}, ptr("hi")},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x", tc.input), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Optional(bytes.NewReader(tc.input), read.String)
+			if err != nil {
+				t.Fatalf("Optional() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Optional() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArray(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   []byte
+		want    []int32
+		wantErr bool
+	}{
+		{input: []byte{0x00}, want: nil},
+		{input: []byte{0x03, 0x01, 0x80, 0x01, 0x7f}, want: []int32{1, 128, 127}},
+		{input: []byte{0x03, 0x01}, wantErr: true},
+		{input: []byte{0xff, 0xff, 0xff, 0xff, 0x0f}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x", tc.input), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := read.Array(bytes.NewReader(tc.input), read.VarInt)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Array() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Array() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSlot(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		want  types.Slot
+	}{
+		{
+			input: []byte{0x00},
+			want:  types.Slot{},
+		},
+		{
+			input: []byte{
+				0x01,       // present
+				0x80, 0x01, // item ID
+				0x40, // count
+				0x00, // no NBT
+			},
+			want: types.Slot{ItemID: 128, Count: 64},
+		},
+		{
+			input: slices.Concat(
+				[]byte{
+					0x01, // present
+					0x05, // item ID
+					0x01, // count
+				},
+				[]byte{0x0a},                   // compound
+				[]byte{0x03, 0x00, 0x06},       // int tag named...
+				[]byte("Damage"),               // ...Damage
+				[]byte{0x00, 0x00, 0x00, 0x07}, // 7
+				[]byte{0x00},                   // end
+			),
+			want: types.Slot{ItemID: 5, Count: 1, NBT: map[string]any{"Damage": int32(7)}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x", tc.input), func(t *testing.T) {
+			t.Parallel()
+
+			r := bytes.NewReader(tc.input)
+			got, err := read.Slot(r)
+			if err != nil {
+				t.Fatalf("Slot() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Slot() diff (-want, +got):\n%s", diff)
+			}
+			if r.Len() != 0 {
+				t.Errorf("Slot() left %d bytes unread", r.Len())
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,293 @@
+// Package registry contains the bundled vanilla registry data
+// sent to the client during configuration.
+// https://wiki.vg/Registry_Data
+package registry
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/airforce270/mc-srv/nbt"
+)
+
+// Registries sent to the client, in the order they're sent.
+// Each is only sent to clients on protocol version since and later.
+var registries = []struct {
+	name  string
+	since int32
+}{
+	{name: "minecraft:dimension_type"},
+	{name: "minecraft:worldgen/biome"},
+	{name: "minecraft:chat_type"},
+	{name: "minecraft:damage_type"},
+	{name: "minecraft:trim_pattern", since: 766},
+	{name: "minecraft:trim_material", since: 766},
+	{name: "minecraft:wolf_variant", since: 766},
+	{name: "minecraft:banner_pattern", since: 766},
+	{name: "minecraft:painting_variant", since: 767},
+	{name: "minecraft:enchantment", since: 767},
+	{name: "minecraft:jukebox_song", since: 767},
+}
+
+// codecProtocol is the protocol version of the registries in Codec.
+// 1.20.4 is the last version sent the registries as one codec.
+const codecProtocol = 765
+
+//go:embed data/*.json
+var data embed.FS
+
+// Entry is an entry in a registry.
+type Entry struct {
+	// Name of the entry, e.g. "minecraft:overworld".
+	Name string `json:"name"`
+	// Since is the protocol version the entry was added in,
+	// or 0 if it's in every supported version.
+	Since int32 `json:"since"`
+	// Element is the entry's data,
+	// decoded from JSON with numbers as json.Number.
+	// Nil if the data isn't bundled,
+	// so the entry can only be sent to clients with the vanilla core pack.
+	Element map[string]any `json:"element"`
+}
+
+// HasData returns whether the entry's data is bundled.
+func (e Entry) HasData() bool {
+	return e.Element != nil
+}
+
+// NBT returns the entry's data as a network NBT compound.
+func (e Entry) NBT() map[string]any {
+	return fromJSON(e.Element).(map[string]any)
+}
+
+// Registry is a registry and its entries.
+type Registry struct {
+	// Name of the registry, e.g. "minecraft:dimension_type".
+	Name string
+	// Entries in the registry.
+	// An entry's index is its network ID.
+	Entries []Entry
+}
+
+// ID returns the network ID of the named entry.
+func (r Registry) ID(name string) (int32, bool) {
+	i := slices.IndexFunc(r.Entries, func(e Entry) bool { return e.Name == name })
+	return int32(i), i >= 0
+}
+
+// Tag is a named set of entries in a registry.
+type Tag struct {
+	// Name of the tag, e.g. "minecraft:is_fire".
+	Name string
+	// Network IDs of the entries in the tag.
+	Entries []int32 `mc:"varint"`
+}
+
+// RegistryTags are the tags for a registry.
+type RegistryTags struct {
+	// Name of the registry, e.g. "minecraft:damage_type".
+	Registry string
+	// Tags in the registry.
+	Tags []Tag
+}
+
+var (
+	// Codec returns the bundled registries of 1.20.4
+	// encoded as a network NBT compound,
+	// as sent in the Registry Data packet.
+	Codec = sync.OnceValues(loadCodec)
+
+	// loaded returns the bundled registries of every protocol version.
+	loaded = sync.OnceValues(loadAll)
+	// loadedTags returns the bundled tags by registry and tag name.
+	loadedTags = sync.OnceValues(loadRawTags)
+)
+
+// All returns the bundled registries sent to clients
+// on the given protocol version.
+func All(protocol int32) ([]Registry, error) {
+	regs, err := loaded()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Registry
+	for i, r := range regs {
+		if registries[i].since > protocol {
+			continue
+		}
+		out = append(out, Registry{
+			Name: r.Name,
+			Entries: slices.DeleteFunc(slices.Clone(r.Entries), func(e Entry) bool {
+				return e.Since > protocol
+			}),
+		})
+	}
+	return out, nil
+}
+
+func loadAll() ([]Registry, error) {
+	var out []Registry
+	for _, reg := range registries {
+		r, err := load(reg.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load registry %s: %w", reg.name, err)
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// load loads the registry with the given name from the bundled data.
+func load(name string) (Registry, error) {
+	r := Registry{Name: name}
+
+	file := path.Join("data", strings.ReplaceAll(strings.TrimPrefix(name, "minecraft:"), "/", "_")+".json")
+	if err := decodeJSON(file, &r.Entries); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+func loadCodec() ([]byte, error) {
+	regs, err := All(codecProtocol)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := map[string]any{}
+	for _, r := range regs {
+		var entries []any
+		for i, e := range r.Entries {
+			if !e.HasData() {
+				return nil, fmt.Errorf("registry %s entry %s has no bundled data", r.Name, e.Name)
+			}
+			entries = append(entries, map[string]any{
+				"name":    e.Name,
+				"id":      int32(i),
+				"element": e.NBT(),
+			})
+		}
+		codec[r.Name] = map[string]any{
+			"type":  r.Name,
+			"value": entries,
+		}
+	}
+
+	b, err := nbt.MarshalNetwork(codec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode registry codec: %w", err)
+	}
+	return b, nil
+}
+
+// fromJSON converts a value decoded from JSON into a value
+// that encodes as the matching NBT tag:
+// objects to compounds, arrays to lists, strings to strings,
+// bools to bytes, numbers with a fraction or exponent to doubles
+// and other numbers to ints (or longs if they don't fit).
+// The Notchian client accepts any numeric tag for numeric registry fields.
+func fromJSON(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(vv))
+		for k, e := range vv {
+			m[k] = fromJSON(e)
+		}
+		return m
+	case []any:
+		l := make([]any, len(vv))
+		for i, e := range vv {
+			l[i] = fromJSON(e)
+		}
+		return l
+	case json.Number:
+		if !strings.ContainsAny(string(vv), ".eE") {
+			if i, err := strconv.ParseInt(string(vv), 10, 32); err == nil {
+				return int32(i)
+			}
+			if i, err := strconv.ParseInt(string(vv), 10, 64); err == nil {
+				return i
+			}
+		}
+		f, _ := strconv.ParseFloat(string(vv), 64)
+		return f
+	default:
+		return v
+	}
+}
+
+func loadRawTags() (map[string]map[string][]string, error) {
+	var raw map[string]map[string][]string
+	if err := decodeJSON("data/tags.json", &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// Tags returns the bundled tags for the registries sent to clients
+// on the given protocol version.
+func Tags(protocol int32) ([]RegistryTags, error) {
+	regs, err := All(protocol)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := loadedTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []RegistryTags
+	for _, r := range regs {
+		tags, ok := raw[r.Name]
+		if !ok {
+			continue
+		}
+		rt := RegistryTags{Registry: r.Name}
+		for _, tagName := range sortedKeys(tags) {
+			tag := Tag{Name: tagName}
+			for _, entry := range tags[tagName] {
+				id, ok := r.ID(entry)
+				if !ok {
+					return nil, fmt.Errorf("tag %s references unknown %s entry %s", tagName, r.Name, entry)
+				}
+				tag.Entries = append(tag.Entries, id)
+			}
+			rt.Tags = append(rt.Tags, tag)
+		}
+		out = append(out, rt)
+	}
+	return out, nil
+}
+
+// decodeJSON decodes a bundled JSON file,
+// keeping numbers as json.Number so their NBT type can be inferred.
+func decodeJSON(file string, v any) error {
+	b, err := data.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input any
+		want  any
+	}{
+		{
+			desc:  "bool",
+			input: true,
+			want:  true,
+		},
+		{
+			desc:  "string",
+			input: "hi",
+			want:  "hi",
+		},
+		{
+			desc:  "int",
+			input: json.Number("7"),
+			want:  int32(7),
+		},
+		{
+			desc:  "long",
+			input: json.Number("4294967296"),
+			want:  int64(4294967296),
+		},
+		{
+			desc:  "double",
+			input: json.Number("0.5"),
+			want:  0.5,
+		},
+		{
+			desc:  "double with exponent",
+			input: json.Number("1e3"),
+			want:  1000.0,
+		},
+		{
+			desc: "nested",
+			input: map[string]any{
+				"c": map[string]any{"i": json.Number("1")},
+				"l": []any{"x"},
+			},
+			want: map[string]any{
+				"c": map[string]any{"i": int32(1)},
+				"l": []any{"x"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := fromJSON(tc.input)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("fromJSON() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		protocol int32
+		want     []string
+		// Registries the client can't join without entries in.
+		wantNonEmpty []string
+		// Entries only sent to newer versions.
+		wantMissing []string
+	}{
+		{
+			desc:     "1.20.4",
+			protocol: 765,
+			want: []string{
+				"minecraft:dimension_type",
+				"minecraft:worldgen/biome",
+				"minecraft:chat_type",
+				"minecraft:damage_type",
+			},
+			wantNonEmpty: []string{"minecraft:dimension_type", "minecraft:worldgen/biome"},
+			wantMissing:  []string{"minecraft:campfire", "minecraft:taiga"},
+		},
+		{
+			desc:     "1.21",
+			protocol: 767,
+			want: []string{
+				"minecraft:dimension_type",
+				"minecraft:worldgen/biome",
+				"minecraft:chat_type",
+				"minecraft:damage_type",
+				"minecraft:trim_pattern",
+				"minecraft:trim_material",
+				"minecraft:wolf_variant",
+				"minecraft:banner_pattern",
+				"minecraft:painting_variant",
+				"minecraft:enchantment",
+				"minecraft:jukebox_song",
+			},
+			wantNonEmpty: []string{
+				"minecraft:dimension_type",
+				"minecraft:worldgen/biome",
+				"minecraft:wolf_variant",
+				"minecraft:painting_variant",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			regs, err := All(tc.protocol)
+			if err != nil {
+				t.Fatalf("All(%d) unexpected err: %v", tc.protocol, err)
+			}
+
+			var got []string
+			for _, r := range regs {
+				got = append(got, r.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("All(%d) registries diff (-want, +got):\n%s", tc.protocol, diff)
+			}
+
+			for _, r := range regs {
+				if slices.Contains(tc.wantNonEmpty, r.Name) && len(r.Entries) == 0 {
+					t.Errorf("All(%d) registry %s has no entries", tc.protocol, r.Name)
+				}
+				for _, e := range r.Entries {
+					if slices.Contains(tc.wantMissing, e.Name) {
+						t.Errorf("All(%d) registry %s has entry %s, want it missing", tc.protocol, r.Name, e.Name)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestWolfVariantBiomes checks that the biomes wolf variants spawn in
+// are sent too, since the client fails to load registries
+// with references to missing entries.
+func TestWolfVariantBiomes(t *testing.T) {
+	t.Parallel()
+
+	regs, err := All(767)
+	if err != nil {
+		t.Fatalf("All() unexpected err: %v", err)
+	}
+	biomes := regs[slices.IndexFunc(regs, func(r Registry) bool { return r.Name == "minecraft:worldgen/biome" })]
+	wolves := regs[slices.IndexFunc(regs, func(r Registry) bool { return r.Name == "minecraft:wolf_variant" })]
+
+	for _, e := range wolves.Entries {
+		biome, ok := e.Element["biomes"].(string)
+		if !ok {
+			t.Errorf("wolf variant %s has no biomes", e.Name)
+			continue
+		}
+		if strings.HasPrefix(biome, "#") {
+			continue
+		}
+		if _, ok := biomes.ID(biome); !ok {
+			t.Errorf("wolf variant %s spawns in %s, which isn't in the biome registry", e.Name, biome)
+		}
+	}
+}
+
+func TestCodec(t *testing.T) {
+	t.Parallel()
+
+	codec, err := Codec()
+	if err != nil {
+		t.Fatalf("Codec() unexpected err: %v", err)
+	}
+	if len(codec) == 0 || nbt.TagType(codec[0]) != nbt.TagCompound {
+		t.Errorf("Codec() doesn't start with a compound tag")
+	}
+}
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	tags, err := Tags(765)
+	if err != nil {
+		t.Fatalf("Tags() unexpected err: %v", err)
+	}
+
+	regs, err := All(765)
+	if err != nil {
+		t.Fatalf("All() unexpected err: %v", err)
+	}
+	damageTypes := regs[slices.IndexFunc(regs, func(r Registry) bool { return r.Name == "minecraft:damage_type" })]
+	fall, _ := damageTypes.ID("minecraft:fall")
+	stalagmite, _ := damageTypes.ID("minecraft:stalagmite")
+
+	for _, rt := range tags {
+		if rt.Registry != "minecraft:damage_type" {
+			continue
+		}
+		for _, tag := range rt.Tags {
+			if tag.Name != "minecraft:is_fall" {
+				continue
+			}
+			if diff := cmp.Diff([]int32{fall, stalagmite}, tag.Entries); diff != "" {
+				t.Errorf("Tags() minecraft:is_fall diff (-want, +got):\n%s", diff)
+			}
+			return
+		}
+	}
+	t.Errorf("Tags() has no minecraft:is_fall damage type tag")
+}
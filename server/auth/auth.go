@@ -0,0 +1,49 @@
+// Package auth authenticates players joining the server
+// with a session server.
+// https://wiki.vg/Protocol_Encryption#Authentication
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotAuthenticated is returned when the session server
+// doesn't know of the player joining the server,
+// e.g. because they're not logged in or are using a cracked client.
+var ErrNotAuthenticated = errors.New("player is not authenticated")
+
+// An Authenticator authenticates players joining the server.
+type Authenticator interface {
+	// Authenticate checks that the player with the given username
+	// has told the session server they're joining the server
+	// identified by serverHash, and returns their profile.
+	// clientIP is the IP address the player is connecting from,
+	// or the zero value if it's unknown.
+	Authenticate(ctx context.Context, username, serverHash string, clientIP netip.Addr) (Profile, error)
+}
+
+// Profile is an authenticated player's game profile.
+type Profile struct {
+	// Player's UUID.
+	ID uuid.UUID
+	// Player's username.
+	Name string
+	// Other properties, normally has one containing the player's skin blob:
+	// {Name: "textures", Value: "base64 string",
+	//  Signature: "base64 string signed using Yggdrasil's private key"}
+	Properties []Property
+}
+
+// Property is a property of a profile.
+type Property struct {
+	// Name of the property.
+	Name string
+	// Value of the property.
+	Value string
+	// Signature of the value, or empty if it's unsigned.
+	Signature string
+}
@@ -0,0 +1,153 @@
+// Package authtest provides a local stand-in for Mojang's session server,
+// so logins can be tested without network access.
+package authtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/google/uuid"
+)
+
+// SessionServer is a local stand-in for Mojang's session server.
+// It implements the join endpoint called by clients
+// and the hasJoined endpoint called by servers.
+// https://wiki.vg/Protocol_Encryption#Authentication
+type SessionServer struct {
+	mux *http.ServeMux
+
+	mtx sync.Mutex
+	// profiles are the known profiles, by access token.
+	profiles map[string]auth.Profile
+	// joins are the servers players have joined, by username.
+	joins map[string]join
+}
+
+// join is a player joining a server.
+type join struct {
+	serverHash string
+	ip         string
+}
+
+// NewSessionServer returns a new session server with no profiles.
+func NewSessionServer() *SessionServer {
+	s := &SessionServer{
+		mux:      http.NewServeMux(),
+		profiles: map[string]auth.Profile{},
+		joins:    map[string]join{},
+	}
+	s.mux.HandleFunc("POST /session/minecraft/join", s.handleJoin)
+	s.mux.HandleFunc("GET /session/minecraft/hasJoined", s.handleHasJoined)
+	return s
+}
+
+// AddProfile adds a profile that can join servers with the access token.
+func (s *SessionServer) AddProfile(accessToken string, p auth.Profile) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.profiles[accessToken] = p
+}
+
+// ServeHTTP implements http.Handler.
+func (s *SessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// JoinRequest is the body of a request to the join endpoint.
+type JoinRequest struct {
+	// Access token of the player.
+	AccessToken string `json:"accessToken"`
+	// Player's UUID, without dashes.
+	SelectedProfile string `json:"selectedProfile"`
+	// Hash of the server being joined.
+	ServerID string `json:"serverId"`
+}
+
+func (s *SessionServer) handleJoin(w http.ResponseWriter, r *http.Request) {
+	var req JoinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	selected, err := uuid.Parse(req.SelectedProfile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.profiles[req.AccessToken]
+	if !ok || p.ID != selected {
+		http.Error(w, "invalid access token", http.StatusForbidden)
+		return
+	}
+	ip, _, _ := net.SplitHostPort(r.RemoteAddr)
+	s.joins[p.Name] = join{serverHash: req.ServerID, ip: ip}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *SessionServer) handleHasJoined(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	username := query.Get("username")
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	j, ok := s.joins[username]
+	if !ok || j.serverHash != query.Get("serverId") {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if ip := query.Get("ip"); ip != "" && ip != j.ip {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	for _, p := range s.profiles {
+		if p.Name != username {
+			continue
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(auth.NewHasJoinedResponse(p))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Join calls the join endpoint of the session server at baseURL,
+// as a client does before sending its encryption response.
+func Join(ctx context.Context, baseURL string, req JoinRequest) error {
+	reqURL, err := url.JoinPath(baseURL, "session/minecraft/join")
+	if err != nil {
+		return fmt.Errorf("failed to build join URL from %q: %w", baseURL, err)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal join request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create join request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to call join API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("join API returned %s", resp.Status)
+	}
+	return nil
+}
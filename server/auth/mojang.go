@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultSessionServerURL is the base URL of Mojang's session server.
+	DefaultSessionServerURL = "https://sessionserver.mojang.com"
+	// DefaultTimeout is how long to wait for the session server by default.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Mojang authenticates players with Mojang's session server,
+// or any server implementing the same API.
+// The zero value is ready to use.
+type Mojang struct {
+	// BaseURL of the session server.
+	// Defaults to DefaultSessionServerURL.
+	BaseURL string
+	// Timeout for each call to the session server.
+	// Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// PreventProxyConnections is whether to send the player's IP
+	// to the session server, which then rejects players
+	// connecting from a different IP than they authenticated from.
+	PreventProxyConnections bool
+	// Client makes the HTTP requests.
+	// Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Authenticate calls the session server's hasJoined endpoint.
+// https://wiki.vg/Protocol_Encryption#Server
+func (m Mojang) Authenticate(ctx context.Context, username, serverHash string, clientIP netip.Addr) (Profile, error) {
+	baseURL := m.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultSessionServerURL
+	}
+	timeout := m.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	reqURL, err := url.JoinPath(baseURL, "session/minecraft/hasJoined")
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to build hasJoined URL from %q: %w", baseURL, err)
+	}
+	query := url.Values{
+		"username": {username},
+		"serverId": {serverHash},
+	}
+	if m.PreventProxyConnections && clientIP.IsValid() {
+		query.Set("ip", clientIP.String())
+	}
+	reqURL += "?" + query.Encode()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to create hasJoined request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to call hasJoined API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return Profile{}, ErrNotAuthenticated
+	default:
+		return Profile{}, fmt.Errorf("hasJoined API returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to read body from hasJoined call: %w", err)
+	}
+	var hasJoinedResp HasJoinedResponse
+	if err := json.Unmarshal(body, &hasJoinedResp); err != nil {
+		return Profile{}, fmt.Errorf("failed to unmarshal body from hasJoined call to JSON: %w", err)
+	}
+
+	return hasJoinedResp.Profile()
+}
+
+// HasJoinedResponse is the response from the /hasJoined endpoint.
+type HasJoinedResponse struct {
+	// Player's identifier, in the format 11111111222233334444555555555555
+	ID string `json:"id"`
+	// Player's username
+	Name string `json:"name"`
+	// Other properties, normally has one containing the user's skin blob.
+	Properties []HasJoinedResponseProperty `json:"properties"`
+}
+
+// HasJoinedResponseProperty is a property in HasJoinedResponse.
+type HasJoinedResponseProperty struct {
+	// Name of the property.
+	Name string `json:"name"`
+	// Value of the property.
+	Value string `json:"value"`
+	// Signature of the value.
+	Signature string `json:"signature,omitempty"`
+}
+
+// Profile converts the response to a Profile.
+func (r HasJoinedResponse) Profile() (Profile, error) {
+	id, err := uuid.Parse(r.ID)
+	if err != nil {
+		return Profile{}, fmt.Errorf("failed to parse player's UUID (%s): %w", r.ID, err)
+	}
+
+	p := Profile{ID: id, Name: r.Name}
+	for _, prop := range r.Properties {
+		p.Properties = append(p.Properties, Property(prop))
+	}
+	return p, nil
+}
+
+// NewHasJoinedResponse converts a Profile to a response.
+func NewHasJoinedResponse(p Profile) HasJoinedResponse {
+	r := HasJoinedResponse{
+		ID:   undashedUUID(p.ID),
+		Name: p.Name,
+	}
+	for _, prop := range p.Properties {
+		r.Properties = append(r.Properties, HasJoinedResponseProperty(prop))
+	}
+	return r
+}
+
+// undashedUUID formats a UUID without dashes, as the session server does.
+func undashedUUID(u uuid.UUID) string {
+	return hex.EncodeToString(u[:])
+}
@@ -0,0 +1,138 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/auth/authtest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+var testProfile = auth.Profile{
+	ID:   uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+	Name: "airfors",
+	Properties: []auth.Property{
+		{Name: "textures", Value: "dGV4dHVyZXM=", Signature: "c2lnbmF0dXJl"},
+	},
+}
+
+const (
+	testAccessToken = "token"
+	testServerHash  = "-7c9d5b0044c130109a5d7b5fb5c317c02b4e28c1"
+)
+
+func TestMojangAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		join     *authtest.JoinRequest
+		mojang   auth.Mojang
+		clientIP netip.Addr
+		want     auth.Profile
+		wantErr  error
+	}{
+		{
+			desc: "joined",
+			join: &authtest.JoinRequest{
+				AccessToken:     testAccessToken,
+				SelectedProfile: testProfile.ID.String(),
+				ServerID:        testServerHash,
+			},
+			want: testProfile,
+		},
+		{
+			desc:    "not joined",
+			wantErr: auth.ErrNotAuthenticated,
+		},
+		{
+			desc: "joined different server",
+			join: &authtest.JoinRequest{
+				AccessToken:     testAccessToken,
+				SelectedProfile: testProfile.ID.String(),
+				ServerID:        "1234",
+			},
+			wantErr: auth.ErrNotAuthenticated,
+		},
+		{
+			desc: "joined from same ip",
+			join: &authtest.JoinRequest{
+				AccessToken:     testAccessToken,
+				SelectedProfile: testProfile.ID.String(),
+				ServerID:        testServerHash,
+			},
+			mojang:   auth.Mojang{PreventProxyConnections: true},
+			clientIP: netip.MustParseAddr("127.0.0.1"),
+			want:     testProfile,
+		},
+		{
+			desc: "joined from different ip",
+			join: &authtest.JoinRequest{
+				AccessToken:     testAccessToken,
+				SelectedProfile: testProfile.ID.String(),
+				ServerID:        testServerHash,
+			},
+			mojang:   auth.Mojang{PreventProxyConnections: true},
+			clientIP: netip.MustParseAddr("192.0.2.1"),
+			wantErr:  auth.ErrNotAuthenticated,
+		},
+		{
+			desc: "different ip ignored without prevent proxy connections",
+			join: &authtest.JoinRequest{
+				AccessToken:     testAccessToken,
+				SelectedProfile: testProfile.ID.String(),
+				ServerID:        testServerHash,
+			},
+			clientIP: netip.MustParseAddr("192.0.2.1"),
+			want:     testProfile,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			sessionServer := authtest.NewSessionServer()
+			sessionServer.AddProfile(testAccessToken, testProfile)
+			srv := httptest.NewServer(sessionServer)
+			defer srv.Close()
+
+			if tc.join != nil {
+				if err := authtest.Join(context.Background(), srv.URL, *tc.join); err != nil {
+					t.Fatalf("Join() unexpected err: %v", err)
+				}
+			}
+
+			m := tc.mojang
+			m.BaseURL = srv.URL
+			got, err := m.Authenticate(context.Background(), testProfile.Name, testServerHash, tc.clientIP)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Authenticate() err = %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Authenticate() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMojangAuthenticateServerError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	m := auth.Mojang{BaseURL: srv.URL}
+	if _, err := m.Authenticate(context.Background(), testProfile.Name, testServerHash, netip.Addr{}); err == nil {
+		t.Errorf("Authenticate() err = nil, want an error")
+	}
+}
@@ -0,0 +1,417 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/crypto"
+	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/packet/writepacket"
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/serverconfig"
+	"github.com/airforce270/mc-srv/write"
+	"github.com/google/uuid"
+)
+
+const testProtocolVersion = 765
+
+// startTestServer starts a server with the given options on a local port
+// and returns its address.
+func startTestServer(t *testing.T, opts Options) string {
+	t.Helper()
+	addr, _ := startTestServerWithServer(t, opts)
+	return addr
+}
+
+// startTestServerWithServer is like startTestServer,
+// but also returns the server.
+func startTestServerWithServer(t *testing.T, opts Options) (string, *Server) {
+	t.Helper()
+
+	if opts.Conns == nil {
+		opts.Conns = NewConnTracker()
+	}
+	srv, err := New(func(s *Server) { s.opts = opts })
+	if err != nil {
+		t.Fatalf("New() unexpected err: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go srv.Serve(listener)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			t.Errorf("Shutdown() unexpected err: %v", err)
+		}
+	})
+
+	return listener.Addr().String(), srv
+}
+
+// testConfig returns the default config
+// with the given compression threshold and online mode.
+func testConfig(compressionThreshold int, onlineMode bool) serverconfig.Config {
+	cfg := serverconfig.Default()
+	cfg.NetworkCompressionThreshold = compressionThreshold
+	cfg.OnlineMode = onlineMode
+	return cfg
+}
+
+// testClient is a minimal client for end-to-end tests.
+type testClient struct {
+	conn net.Conn
+	r    io.Reader
+	w    io.Writer
+
+	// serverAddress is sent in the handshake.
+	serverAddress string
+	// protocol is the protocol version sent in the handshake.
+	protocol int32
+	// loggedIn is whether login success has been read,
+	// after which packet IDs no longer mean login packets.
+	loggedIn bool
+	// unlisted is whether the client doesn't allow server listings.
+	unlisted bool
+}
+
+func dialTestServer(t *testing.T, addr string) *testClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to dial %s: %v", addr, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &testClient{conn: conn, r: conn, w: conn, serverAddress: "localhost", protocol: testProtocolVersion}
+}
+
+// writePacket writes a packet with the given ID and fields.
+func (c *testClient) writePacket(packetID id.ID, fields ...any) error {
+	var buf bytes.Buffer
+	for _, f := range fields {
+		var err error
+		switch ff := f.(type) {
+		case bool:
+			err = write.Bool(&buf, ff)
+		case byte:
+			err = write.Byte(&buf, ff)
+		case int32:
+			err = write.VarInt(&buf, ff)
+		case uint16:
+			err = write.Bytes(&buf, []byte{byte(ff >> 8), byte(ff)})
+		case string:
+			err = write.String(&buf, ff)
+		case uuid.UUID:
+			err = write.UUID(&buf, ff)
+		case []byte:
+			err = write.Bytes(&buf, ff)
+		default:
+			err = fmt.Errorf("unsupported field type %T", f)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write field %v: %w", f, err)
+		}
+	}
+	return writepacket.Write(c.w, packetID, &buf)
+}
+
+// readPacket reads the next packet and returns its ID and fields.
+// Set Compression packets are handled transparently during login.
+func (c *testClient) readPacket() (id.ID, *bytes.Buffer, error) {
+	for {
+		h, err := packet.ReadHeader(c.r)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		if h.Length == 0 {
+			return 0, nil, io.EOF
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, c.r, int64(int(h.Length)-h.PacketID.Len())); err != nil {
+			return 0, nil, fmt.Errorf("failed to read packet: %w", err)
+		}
+
+		if !c.loggedIn && h.PacketID == id.SetCompression {
+			threshold, err := read.VarInt(&buf)
+			if err != nil {
+				return 0, nil, fmt.Errorf("failed to read compression threshold: %w", err)
+			}
+			c.r = compression.NewReader(c.r)
+			c.w = compression.NewWriter(c.w, int(threshold))
+			continue
+		}
+		return h.PacketID, &buf, nil
+	}
+}
+
+// expectPacket reads the next packet and checks it has the given ID.
+func (c *testClient) expectPacket(want id.ID) (*bytes.Buffer, error) {
+	got, buf, err := c.readPacket()
+	if err != nil {
+		return nil, err
+	}
+	if got != want {
+		return nil, fmt.Errorf("got packet 0x%02x, want 0x%02x", got, want)
+	}
+	return buf, nil
+}
+
+// startLogin sends the handshake and login start packets.
+func (c *testClient) startLogin(username string, playerUUID uuid.UUID) error {
+	return c.startLoginWith(slp.HandshakeNextStateLogin, username, playerUUID)
+}
+
+// startLoginWith is startLogin with the given next state in the handshake,
+// e.g. slp.HandshakeNextStateTransfer.
+func (c *testClient) startLoginWith(nextState int32, username string, playerUUID uuid.UUID) error {
+	if err := c.writePacket(id.Handshake, c.protocol, c.serverAddress, uint16(25565), nextState); err != nil {
+		return fmt.Errorf("failed to write handshake: %w", err)
+	}
+	if err := c.writePacket(id.LoginStart, username, playerUUID); err != nil {
+		return fmt.Errorf("failed to write login start: %w", err)
+	}
+	return nil
+}
+
+// encrypt reads the encryption request, calls join with the server hash,
+// sends the encryption response and enables encryption.
+func (c *testClient) encrypt(join func(serverHash string) error) error {
+	buf, err := c.expectPacket(id.EncryptionRequest)
+	if err != nil {
+		return fmt.Errorf("failed to read encryption request: %w", err)
+	}
+	serverID, err := read.String(buf)
+	if err != nil {
+		return fmt.Errorf("failed to read server ID: %w", err)
+	}
+	publicKey, err := readByteArray(buf)
+	if err != nil {
+		return fmt.Errorf("failed to read public key: %w", err)
+	}
+	verifyToken, err := readByteArray(buf)
+	if err != nil {
+		return fmt.Errorf("failed to read verify token: %w", err)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("public key is a %T, not an RSA key", key)
+	}
+
+	sharedSecret := make([]byte, 16)
+	rand.Read(sharedSecret)
+
+	hash := sha1.New()
+	hash.Write([]byte(serverID))
+	hash.Write(sharedSecret)
+	hash.Write(publicKey)
+	if err := join(minecraftDigest(hash)); err != nil {
+		return fmt.Errorf("failed to join: %w", err)
+	}
+
+	encryptedSecret, err := rsa.EncryptPKCS1v15(rand.Reader, rsaKey, sharedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt shared secret: %w", err)
+	}
+	encryptedToken, err := rsa.EncryptPKCS1v15(rand.Reader, rsaKey, verifyToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt verify token: %w", err)
+	}
+	if err := c.writePacket(id.EncryptionResponse, int32(len(encryptedSecret)), encryptedSecret, int32(len(encryptedToken)), encryptedToken); err != nil {
+		return fmt.Errorf("failed to write encryption response: %w", err)
+	}
+
+	if c.r, err = crypto.NewDecryptReader(c.r, sharedSecret); err != nil {
+		return fmt.Errorf("failed to enable decryption: %w", err)
+	}
+	if c.w, err = crypto.NewEncryptWriter(c.w, sharedSecret); err != nil {
+		return fmt.Errorf("failed to enable encryption: %w", err)
+	}
+	return nil
+}
+
+// readLoginSuccess reads the login success packet
+// and returns the profile in it.
+func (c *testClient) readLoginSuccess() (auth.Profile, error) {
+	var p auth.Profile
+
+	buf, err := c.expectPacket(id.LoginSuccess)
+	if err != nil {
+		return p, fmt.Errorf("failed to read login success: %w", err)
+	}
+	c.loggedIn = true
+	if p.ID, err = read.UUID(buf); err != nil {
+		return p, fmt.Errorf("failed to read UUID: %w", err)
+	}
+	if p.Name, err = read.String(buf); err != nil {
+		return p, fmt.Errorf("failed to read username: %w", err)
+	}
+	count, err := read.VarInt(buf)
+	if err != nil {
+		return p, fmt.Errorf("failed to read property count: %w", err)
+	}
+	for range count {
+		var prop auth.Property
+		if prop.Name, err = read.String(buf); err != nil {
+			return p, fmt.Errorf("failed to read property name: %w", err)
+		}
+		if prop.Value, err = read.String(buf); err != nil {
+			return p, fmt.Errorf("failed to read property value: %w", err)
+		}
+		isSigned, err := read.Bool(buf)
+		if err != nil {
+			return p, fmt.Errorf("failed to read property is signed: %w", err)
+		}
+		if isSigned {
+			if prop.Signature, err = read.String(buf); err != nil {
+				return p, fmt.Errorf("failed to read property signature: %w", err)
+			}
+		}
+		p.Properties = append(p.Properties, prop)
+	}
+	return p, nil
+}
+
+// readLoginDisconnect reads a login Disconnect packet and returns its reason.
+func (c *testClient) readLoginDisconnect() (types.TextComponent, error) {
+	var reason types.TextComponent
+
+	buf, err := c.expectPacket(id.LoginDisconnect)
+	if err != nil {
+		return reason, fmt.Errorf("failed to read login disconnect: %w", err)
+	}
+	s, err := read.String(buf)
+	if err != nil {
+		return reason, fmt.Errorf("failed to read reason: %w", err)
+	}
+	if err := json.Unmarshal([]byte(s), &reason); err != nil {
+		return reason, fmt.Errorf("failed to unmarshal reason: %w", err)
+	}
+	return reason, nil
+}
+
+// joinGame acknowledges login success, goes through configuration
+// and reads packets until the client is in the play state.
+func (c *testClient) joinGame() error {
+	if err := c.writePacket(id.LoginAcknowledgement); err != nil {
+		return fmt.Errorf("failed to write login acknowledged: %w", err)
+	}
+	return c.configure()
+}
+
+// configure goes through configuration once login is acknowledged
+// and reads packets until the client is in the play state.
+func (c *testClient) configure() error {
+	if c.protocol >= int32(protocol.V1_21) {
+		return c.configure1_21()
+	}
+	if err := c.writePacket(id.ClientInformation, "en_us", byte(10), int32(0), true, byte(0x7f), int32(1), false, !c.unlisted); err != nil {
+		return fmt.Errorf("failed to write client information: %w", err)
+	}
+	if err := c.readUntil(id.FinishConfiguration); err != nil {
+		return fmt.Errorf("failed to read finish configuration: %w", err)
+	}
+	if err := c.writePacket(id.AcknowledgeFinish); err != nil {
+		return fmt.Errorf("failed to write acknowledge finish configuration: %w", err)
+	}
+	if err := c.readUntil(id.PlayLogin); err != nil {
+		return fmt.Errorf("failed to read login (play): %w", err)
+	}
+	return nil
+}
+
+// configure1_21 is configure for 1.21 clients,
+// which have the vanilla data pack.
+func (c *testClient) configure1_21() error {
+	if err := c.writePacket(0x00, "en_us", byte(10), int32(0), true, byte(0x7f), int32(1), false, !c.unlisted); err != nil {
+		return fmt.Errorf("failed to write client information: %w", err)
+	}
+	if err := c.readUntil(0x0E); err != nil {
+		return fmt.Errorf("failed to read known packs: %w", err)
+	}
+	if err := c.writePacket(0x07, int32(1), "minecraft", "core", "1.21"); err != nil {
+		return fmt.Errorf("failed to write known packs: %w", err)
+	}
+	if err := c.readUntil(0x03); err != nil {
+		return fmt.Errorf("failed to read finish configuration: %w", err)
+	}
+	if err := c.writePacket(0x03); err != nil {
+		return fmt.Errorf("failed to write acknowledge finish configuration: %w", err)
+	}
+	if err := c.readUntil(0x2B); err != nil {
+		return fmt.Errorf("failed to read login (play): %w", err)
+	}
+	return nil
+}
+
+// readUntil reads packets until one with the given ID.
+func (c *testClient) readUntil(want id.ID) error {
+	_, err := c.readUntilPacket(want)
+	return err
+}
+
+// readUntilPacket reads packets until one with the given ID
+// and returns its fields.
+func (c *testClient) readUntilPacket(want id.ID) (*bytes.Buffer, error) {
+	for {
+		got, buf, err := c.readPacket()
+		if err != nil {
+			return nil, err
+		}
+		if got == want {
+			return buf, nil
+		}
+	}
+}
+
+// status sends a status request and returns the status response JSON.
+func (c *testClient) status() (string, error) {
+	if err := c.writePacket(id.Handshake, c.protocol, c.serverAddress, uint16(25565), int32(slp.HandshakeNextStateStatus)); err != nil {
+		return "", fmt.Errorf("failed to write handshake: %w", err)
+	}
+	if err := c.writePacket(id.StatusRequest); err != nil {
+		return "", fmt.Errorf("failed to write status request: %w", err)
+	}
+	buf, err := c.expectPacket(id.StatusResponse)
+	if err != nil {
+		return "", fmt.Errorf("failed to read status response: %w", err)
+	}
+	return read.String(buf)
+}
+
+func readByteArray(r io.Reader) ([]byte, error) {
+	n, err := read.VarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, errors.New("negative length")
+	}
+	return read.Bytes(r, int(n))
+}
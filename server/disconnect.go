@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/airforce270/mc-srv/packet/types"
+)
+
+// Reasons players are disconnected with.
+// Where the Notchian server has an equivalent, its message is used.
+const (
+	reasonUnverifiedUsername = "Failed to verify username!"
+	reasonAuthServersDown    = "Authentication servers are down. Please try again later, sorry!"
+	reasonEncryptionFailed   = "Failed to set up encryption"
+	reasonVelocityRequired   = "This server requires you to connect with Velocity."
+	reasonBungeeCordRequired = "If you wish to use IP forwarding, please enable it in your BungeeCord config as well!"
+	reasonTimedOut           = "Timed out"
+	reasonTransfersDisabled  = "Server does not accept transfers"
+	reasonLoginFailed        = "Failed to log in"
+	reasonNotWhitelisted     = "You are not white-listed on this server!"
+	reasonCorePackRequired   = "This server requires the vanilla data pack (minecraft:core)"
+	// Formatted with the supported version's name.
+	reasonOutdatedClient = "Outdated client! Please use %s"
+	reasonOutdatedServer = "Outdated server! I'm still on %s"
+)
+
+// errUnexpectedPacket is returned when a packet is received
+// in a state it isn't expected in.
+var errUnexpectedPacket = errors.New("unexpected packet")
+
+// disconnectError is an error handling a packet
+// after which the player should be disconnected with a reason.
+type disconnectError struct {
+	reason types.TextComponent
+	err    error
+}
+
+// disconnectWith wraps err so the player is disconnected with the reason.
+func disconnectWith(reason string, err error) error {
+	return &disconnectError{reason: types.TextComponent{Text: reason}, err: err}
+}
+
+// NewDisconnectError wraps err so the player is disconnected
+// with the reason when it's returned from Hooks.OnLogin.
+// The reason is shown to the player, and err is only logged.
+func NewDisconnectError(reason types.TextComponent, err error) error {
+	return &disconnectError{reason: reason, err: err}
+}
+
+func (e *disconnectError) Error() string { return e.err.Error() }
+
+func (e *disconnectError) Unwrap() error { return e.err }
+
+// disconnectReason returns the reason to disconnect the player with
+// if err means they should be, and whether they should be.
+func disconnectReason(err error) (types.TextComponent, bool) {
+	var de *disconnectError
+	if errors.As(err, &de) {
+		return de.reason, true
+	}
+	return types.TextComponent{}, false
+}
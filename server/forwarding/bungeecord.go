@@ -0,0 +1,54 @@
+package forwarding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/google/uuid"
+)
+
+// ErrNotForwarded is returned when a handshake's server address
+// doesn't contain BungeeCord forwarding data,
+// e.g. because the client connected directly instead of through the proxy.
+var ErrNotForwarded = errors.New("handshake has no forwarding data")
+
+// ParseBungeeCord parses the server address of a handshake
+// sent by a BungeeCord-style proxy with IP forwarding enabled,
+// in the format "host\x00ip\x00uuid[\x00properties]".
+// It returns the actual server address and the forwarded player,
+// whose profile has no name (the name is sent in Login Start).
+func ParseBungeeCord(serverAddress string) (string, Player, error) {
+	var p Player
+
+	parts := strings.Split(serverAddress, "\x00")
+	if len(parts) < 3 {
+		return serverAddress, p, ErrNotForwarded
+	}
+	host, addr, id := parts[0], parts[1], parts[2]
+
+	var err error
+	p.Addr, err = netip.ParseAddr(addr)
+	if err != nil {
+		return host, p, fmt.Errorf("failed to parse address %q: %w", addr, err)
+	}
+	p.Profile.ID, err = uuid.Parse(id)
+	if err != nil {
+		return host, p, fmt.Errorf("failed to parse UUID %q: %w", id, err)
+	}
+
+	if len(parts) > 3 && parts[3] != "" {
+		var props []auth.HasJoinedResponseProperty
+		if err := json.Unmarshal([]byte(parts[3]), &props); err != nil {
+			return host, p, fmt.Errorf("failed to unmarshal properties: %w", err)
+		}
+		for _, prop := range props {
+			p.Profile.Properties = append(p.Profile.Properties, auth.Property(prop))
+		}
+	}
+
+	return host, p, nil
+}
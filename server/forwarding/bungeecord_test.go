@@ -0,0 +1,107 @@
+package forwarding_test
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/forwarding"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+func TestParseBungeeCord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		input    string
+		wantHost string
+		want     forwarding.Player
+		wantErr  error
+	}{
+		{
+			desc:     "with properties",
+			input:    "mc.example.com\x00192.0.2.1\x008996cb86cb634c2d8b457cdfd7b542c8\x00" + `[{"name":"textures","value":"dGV4dHVyZXM=","signature":"c2lnbmF0dXJl"}]`,
+			wantHost: "mc.example.com",
+			want: forwarding.Player{
+				Addr: netip.MustParseAddr("192.0.2.1"),
+				Profile: auth.Profile{
+					ID: uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+					Properties: []auth.Property{
+						{Name: "textures", Value: "dGV4dHVyZXM=", Signature: "c2lnbmF0dXJl"},
+					},
+				},
+			},
+		},
+		{
+			desc:     "without properties",
+			input:    "mc.example.com\x002001:db8::1\x008996cb86-cb63-4c2d-8b45-7cdfd7b542c8",
+			wantHost: "mc.example.com",
+			want: forwarding.Player{
+				Addr: netip.MustParseAddr("2001:db8::1"),
+				Profile: auth.Profile{
+					ID: uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+				},
+			},
+		},
+		{
+			desc:     "not forwarded",
+			input:    "mc.example.com",
+			wantHost: "mc.example.com",
+			wantErr:  forwarding.ErrNotForwarded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			gotHost, got, err := forwarding.ParseBungeeCord(tc.input)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("ParseBungeeCord() err = %v, want %v", err, tc.wantErr)
+			}
+
+			if gotHost != tc.wantHost {
+				t.Errorf("ParseBungeeCord() host = %q, want %q", gotHost, tc.wantHost)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateComparable(netip.Addr{})); diff != "" {
+				t.Errorf("ParseBungeeCord() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseBungeeCordErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input string
+	}{
+		{
+			desc:  "bad address",
+			input: "mc.example.com\x00not-an-ip\x008996cb86cb634c2d8b457cdfd7b542c8",
+		},
+		{
+			desc:  "bad uuid",
+			input: "mc.example.com\x00192.0.2.1\x00not-a-uuid",
+		},
+		{
+			desc:  "bad properties",
+			input: "mc.example.com\x00192.0.2.1\x008996cb86cb634c2d8b457cdfd7b542c8\x00{",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if _, _, err := forwarding.ParseBungeeCord(tc.input); err == nil {
+				t.Errorf("ParseBungeeCord() err = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+// Package forwarding reads player info forwarded by proxies
+// that handle authentication in front of the server.
+package forwarding
+
+import (
+	"net/netip"
+
+	"github.com/airforce270/mc-srv/server/auth"
+)
+
+// Player is the info a proxy forwards about a player.
+type Player struct {
+	// Addr is the IP address the player connected to the proxy from.
+	Addr netip.Addr
+	// Profile is the player's profile, as authenticated by the proxy.
+	Profile auth.Profile
+}
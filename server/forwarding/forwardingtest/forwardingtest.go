@@ -0,0 +1,35 @@
+// Package forwardingtest contains helpers for testing player info forwarding,
+// acting as the proxy.
+package forwardingtest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"github.com/airforce270/mc-srv/server/forwarding"
+	"github.com/airforce270/mc-srv/write"
+)
+
+// VelocityData returns the data Velocity sends in its login plugin response,
+// signed with secret.
+func VelocityData(secret []byte, version int32, p forwarding.Player) []byte {
+	var payload bytes.Buffer
+	write.VarInt(&payload, version)
+	write.String(&payload, p.Addr.String())
+	write.UUID(&payload, p.Profile.ID)
+	write.String(&payload, p.Profile.Name)
+	write.VarInt(&payload, int32(len(p.Profile.Properties)))
+	for _, prop := range p.Profile.Properties {
+		write.String(&payload, prop.Name)
+		write.String(&payload, prop.Value)
+		write.Bool(&payload, prop.Signature != "")
+		if prop.Signature != "" {
+			write.String(&payload, prop.Signature)
+		}
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload.Bytes())
+	return append(mac.Sum(nil), payload.Bytes()...)
+}
@@ -0,0 +1,122 @@
+package forwarding
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/server/auth"
+)
+
+const (
+	// VelocityChannel is the login plugin channel
+	// Velocity's modern forwarding uses.
+	VelocityChannel = "velocity:player_info"
+
+	// VelocityModernDefault is the version of Velocity's modern forwarding
+	// without a chat signing key, used by clients since 1.19.3.
+	VelocityModernDefault = 1
+)
+
+// ErrInvalidSignature is returned when forwarded player info
+// isn't signed with the forwarding secret.
+var ErrInvalidSignature = errors.New("invalid forwarding signature")
+
+// VelocityRequestData returns the data to send in the login plugin request
+// on VelocityChannel: the highest forwarding version the server supports.
+func VelocityRequestData() []byte {
+	return []byte{VelocityModernDefault}
+}
+
+// ParseVelocity parses the data in the login plugin response
+// on VelocityChannel, verifying it's signed with secret.
+// https://github.com/PaperMC/Velocity/blob/dev/3.0.0/proxy/src/main/java/com/velocitypowered/proxy/connection/backend/VelocityServerConnection.java
+func ParseVelocity(data, secret []byte) (Player, error) {
+	var p Player
+
+	if len(data) < sha256.Size {
+		return p, fmt.Errorf("forwarded data is too short (%d bytes)", len(data))
+	}
+	signature, payload := data[:sha256.Size], data[sha256.Size:]
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return p, ErrInvalidSignature
+	}
+
+	r := bytes.NewReader(payload)
+
+	version, err := read.VarInt(r)
+	if err != nil {
+		return p, fmt.Errorf("failed to read forwarding version: %w", err)
+	}
+	if version < VelocityModernDefault {
+		return p, fmt.Errorf("unsupported forwarding version %d", version)
+	}
+
+	addr, err := read.String(r)
+	if err != nil {
+		return p, fmt.Errorf("failed to read address: %w", err)
+	}
+	p.Addr, err = netip.ParseAddr(addr)
+	if err != nil {
+		return p, fmt.Errorf("failed to parse address %q: %w", addr, err)
+	}
+
+	p.Profile.ID, err = read.UUID(r)
+	if err != nil {
+		return p, fmt.Errorf("failed to read UUID: %w", err)
+	}
+	p.Profile.Name, err = read.String(r)
+	if err != nil {
+		return p, fmt.Errorf("failed to read username: %w", err)
+	}
+	p.Profile.Properties, err = readProperties(r)
+	if err != nil {
+		return p, fmt.Errorf("failed to read properties: %w", err)
+	}
+
+	// Versions with chat signing keys have more data after the properties,
+	// which isn't needed since we only requested VelocityModernDefault.
+
+	return p, nil
+}
+
+// readProperties reads a profile's properties
+// in the same format as the Login Success packet.
+func readProperties(r *bytes.Reader) ([]auth.Property, error) {
+	count, err := read.VarInt(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read property count: %w", err)
+	}
+	if count < 0 || int(count) > r.Len() {
+		return nil, fmt.Errorf("invalid property count %d", count)
+	}
+
+	props := make([]auth.Property, 0, count)
+	for range count {
+		var prop auth.Property
+		if prop.Name, err = read.String(r); err != nil {
+			return nil, fmt.Errorf("failed to read property name: %w", err)
+		}
+		if prop.Value, err = read.String(r); err != nil {
+			return nil, fmt.Errorf("failed to read property value: %w", err)
+		}
+		isSigned, err := read.Bool(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read property is signed: %w", err)
+		}
+		if isSigned {
+			if prop.Signature, err = read.String(r); err != nil {
+				return nil, fmt.Errorf("failed to read property signature: %w", err)
+			}
+		}
+		props = append(props, prop)
+	}
+	return props, nil
+}
@@ -0,0 +1,117 @@
+package forwarding_test
+
+import (
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/forwarding"
+	"github.com/airforce270/mc-srv/server/forwarding/forwardingtest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+var (
+	testSecret = []byte("secret")
+
+	testPlayer = forwarding.Player{
+		Addr: netip.MustParseAddr("192.0.2.1"),
+		Profile: auth.Profile{
+			ID:   uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+			Name: "airfors",
+			Properties: []auth.Property{
+				{Name: "textures", Value: "dGV4dHVyZXM=", Signature: "c2lnbmF0dXJl"},
+				{Name: "unsigned", Value: "x"},
+			},
+		},
+	}
+)
+
+func TestParseVelocity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		input   []byte
+		want    forwarding.Player
+		wantErr error
+	}{
+		{
+			desc:  "modern default",
+			input: forwardingtest.VelocityData(testSecret, forwarding.VelocityModernDefault, testPlayer),
+			want:  testPlayer,
+		},
+		{
+			desc: "ipv6",
+			input: forwardingtest.VelocityData(testSecret, forwarding.VelocityModernDefault, forwarding.Player{
+				Addr:    netip.MustParseAddr("2001:db8::1"),
+				Profile: auth.Profile{ID: testPlayer.Profile.ID, Name: testPlayer.Profile.Name},
+			}),
+			want: forwarding.Player{
+				Addr:    netip.MustParseAddr("2001:db8::1"),
+				Profile: auth.Profile{ID: testPlayer.Profile.ID, Name: testPlayer.Profile.Name},
+			},
+		},
+		{
+			desc:  "newer version",
+			input: forwardingtest.VelocityData(testSecret, 4, testPlayer),
+			want:  testPlayer,
+		},
+		{
+			desc:    "wrong secret",
+			input:   forwardingtest.VelocityData([]byte("wrong"), forwarding.VelocityModernDefault, testPlayer),
+			wantErr: forwarding.ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := forwarding.ParseVelocity(tc.input, testSecret)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("ParseVelocity() err = %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateComparable(netip.Addr{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ParseVelocity() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseVelocityErrors(t *testing.T) {
+	t.Parallel()
+
+	valid := forwardingtest.VelocityData(testSecret, forwarding.VelocityModernDefault, testPlayer)
+
+	tests := []struct {
+		desc  string
+		input []byte
+	}{
+		{
+			desc:  "empty",
+			input: nil,
+		},
+		{
+			desc:  "truncated",
+			input: valid[:len(valid)-1],
+		},
+		{
+			desc:  "version 0",
+			input: forwardingtest.VelocityData(testSecret, 0, testPlayer),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := forwarding.ParseVelocity(tc.input, testSecret); err == nil {
+				t.Errorf("ParseVelocity() err = nil, want an error")
+			}
+		})
+	}
+}
@@ -9,18 +9,22 @@ import (
 	"time"
 
 	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/protocol"
 )
 
 const (
 	monitorInterval = 50 * time.Millisecond
 )
 
-// New creates a new KeepAliver.
-func New(interval time.Duration, w io.Writer) KeepAliver {
+// New creates a new KeepAliver
+// writing packets in the given protocol version.
+func New(interval time.Duration, w io.Writer, v protocol.Version) KeepAliver {
 	return KeepAliver{
 		sendInterval:  interval,
 		mustRespondIn: 5 * time.Second,
 		w:             w,
+		protocol:      v,
 		rand:          nil,
 		pending:       map[int64]time.Time{},
 		cancel:        make(chan struct{}, 10),
@@ -29,11 +33,12 @@ func New(interval time.Duration, w io.Writer) KeepAliver {
 
 // NewForTesting creates a new KeepAliver for testing.
 // Notably, it allows providing a source of random data for predictability.
-func NewForTesting(sendInterval, mustRespondIn time.Duration, w io.Writer, rr rand.Source) KeepAliver {
+func NewForTesting(sendInterval, mustRespondIn time.Duration, w io.Writer, v protocol.Version, rr rand.Source) KeepAliver {
 	return KeepAliver{
 		sendInterval:  sendInterval,
 		mustRespondIn: mustRespondIn,
 		w:             w,
+		protocol:      v,
 		rand:          rand.New(rr),
 		pending:       map[int64]time.Time{},
 		cancel:        make(chan struct{}, 10),
@@ -46,11 +51,19 @@ type KeepAliver struct {
 	sendInterval  time.Duration
 	mustRespondIn time.Duration
 	w             io.Writer
+	protocol      protocol.Version
 	rand          *rand.Rand
 
 	pending    map[int64]time.Time
 	pendingMtx sync.RWMutex // protects pending
 
+	// play is whether the client is in the play state,
+	// which uses different keepalive packets than configuration.
+	play bool
+	// playMtx protects play and is held while writing a keepalive,
+	// so none of the old state's are written once EnterPlay returns.
+	playMtx sync.Mutex
+
 	cancel chan struct{}
 }
 
@@ -70,8 +83,7 @@ func (k *KeepAliver) StartPinging(ctx context.Context, logger *log.Logger) {
 		case <-ticker.C:
 			keepAliveID := k.randInt64()
 			logger.Printf("Sending keepalive %d", keepAliveID)
-			pack := config.ClientboundKeepAlive{KeepAliveID: keepAliveID}
-			if err := pack.Write(k.w); err != nil {
+			if err := k.writeKeepAlive(keepAliveID); err != nil {
 				logger.Printf("Failed to write keepalive packet: %v", err)
 			}
 			k.pendingMtx.Lock()
@@ -82,6 +94,24 @@ func (k *KeepAliver) StartPinging(ctx context.Context, logger *log.Logger) {
 	}
 }
 
+// EnterPlay switches to sending play state keepalive packets.
+// If a keepalive is being written, it waits for it to be written first.
+func (k *KeepAliver) EnterPlay() {
+	k.playMtx.Lock()
+	defer k.playMtx.Unlock()
+	k.play = true
+}
+
+// writeKeepAlive writes a keepalive packet for the client's current state.
+func (k *KeepAliver) writeKeepAlive(keepAliveID int64) error {
+	k.playMtx.Lock()
+	defer k.playMtx.Unlock()
+	if k.play {
+		return protocol.Default.Write(k.w, k.protocol, play.ClientboundKeepAlive{KeepAliveID: keepAliveID})
+	}
+	return protocol.Default.Write(k.w, k.protocol, config.ClientboundKeepAlive{KeepAliveID: keepAliveID})
+}
+
 // Receive marks a keepalive ID as received.
 func (p *KeepAliver) Receive(id int64) {
 	p.pendingMtx.Lock()
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"log"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/airforce270/mc-srv/packet"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/protocol"
 	"github.com/airforce270/mc-srv/read"
 	"github.com/airforce270/mc-srv/server/keepaliver"
 )
@@ -18,6 +21,25 @@ type fakeRandSource struct {
 
 func (s fakeRandSource) Uint64() uint64 { return s.val }
 
+// syncBuffer is a bytes.Buffer safe to write to from the pinging goroutine
+// while the test reads from it.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Read(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Read(p)
+}
+
 func TestSend(t *testing.T) {
 	const dur = 50 * time.Millisecond
 	const timeout = dur * 100
@@ -25,11 +47,11 @@ func TestSend(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
 
-	var buf bytes.Buffer
+	var buf syncBuffer
 
 	source := fakeRandSource{val: 9999999999999999999}
 	const want = 776627963145224191 // just so happens to be what the above val resolves to
-	p := keepaliver.NewForTesting(dur, timeout, &buf, &source)
+	p := keepaliver.NewForTesting(dur, timeout, &buf, protocol.V1_20_4, &source)
 
 	go p.StartPinging(ctx, log.Default())
 
@@ -53,3 +75,31 @@ func TestSend(t *testing.T) {
 		t.Errorf("KeepAliveID = %d, want %d", val, want)
 	}
 }
+
+func TestSendPlay(t *testing.T) {
+	const dur = 50 * time.Millisecond
+	const timeout = dur * 100
+	const buffer = 25 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var buf syncBuffer
+
+	source := fakeRandSource{val: 9999999999999999999}
+	p := keepaliver.NewForTesting(dur, timeout, &buf, protocol.V1_20_4, &source)
+	p.EnterPlay()
+
+	go p.StartPinging(ctx, log.Default())
+
+	const wait = dur + buffer
+	time.Sleep(wait)
+	cancel()
+
+	h, err := packet.ReadHeader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read header: %v", err)
+	}
+	if h.PacketID != id.PlayClientboundKeepAlive {
+		t.Errorf("PacketID is 0x%x, expected 0x%x", h.PacketID, id.PlayClientboundKeepAlive)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// unixPrefix is the prefix of bind addresses of unix domain sockets.
+const unixPrefix = "unix:"
+
+// Listen listens on a bind address, which is either a TCP address
+// (e.g. "0.0.0.0:25565", "[::]:25565" or ":25565")
+// or a path to a unix domain socket prefixed with "unix:"
+// (e.g. "unix:/run/mc-srv.sock").
+//
+// Listening on "[::]" or an empty host accepts both IPv4 and IPv6
+// connections where the OS supports dual-stack sockets.
+// A stale unix domain socket at the path is removed first.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+		l, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on unix socket %s: %w", path, err)
+		}
+		return l, nil
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes the unix domain socket at path, if any,
+// so it can be listened on again after an unclean exit.
+// Files that aren't sockets are left alone.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if fi.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%s exists and isn't a unix socket", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale unix socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// ListenAll listens on each of the bind addresses
+// and merges the listeners into one with MultiListener.
+// See Listen for the format of the addresses.
+func ListenAll(addrs ...string) (net.Listener, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no bind addresses")
+	}
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := Listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return MultiListener(listeners...), nil
+}
+
+// MultiListener returns a listener that accepts conns
+// from all of the given listeners.
+// Closing it closes all of them.
+// Its Addr is the address of the first listener.
+func MultiListener(listeners ...net.Listener) net.Listener {
+	if len(listeners) == 1 {
+		return listeners[0]
+	}
+	ml := &multiListener{
+		listeners: listeners,
+		accepted:  make(chan acceptResult),
+		closed:    make(chan struct{}),
+	}
+	for _, l := range listeners {
+		go ml.acceptLoop(l)
+	}
+	return ml
+}
+
+type multiListener struct {
+	listeners []net.Listener
+	// accepted receives each conn, or error, from the listeners.
+	accepted  chan acceptResult
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// acceptLoop accepts conns from l until it fails or ml is closed.
+func (ml *multiListener) acceptLoop(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		select {
+		case ml.accepted <- acceptResult{conn, err}:
+		case <-ml.closed:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Accept waits for and returns the next conn from any of the listeners.
+// If one of the listeners fails, its error is returned.
+func (ml *multiListener) Accept() (net.Conn, error) {
+	select {
+	case r := <-ml.accepted:
+		return r.conn, r.err
+	case <-ml.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes all of the listeners.
+func (ml *multiListener) Close() error {
+	var errs []error
+	ml.closeOnce.Do(func() {
+		close(ml.closed)
+		for _, l := range ml.listeners {
+			if err := l.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Addr returns the address of the first listener.
+func (ml *multiListener) Addr() net.Addr {
+	return ml.listeners[0].Addr()
+}
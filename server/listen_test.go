@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc        string
+		addr        string
+		wantNetwork string
+	}{
+		{
+			desc:        "ipv4",
+			addr:        "127.0.0.1:0",
+			wantNetwork: "tcp",
+		},
+		{
+			desc:        "all interfaces",
+			addr:        ":0",
+			wantNetwork: "tcp",
+		},
+		{
+			desc:        "unix",
+			addr:        "unix:" + filepath.Join(t.TempDir(), "mc-srv.sock"),
+			wantNetwork: "unix",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			l, err := Listen(tc.addr)
+			if err != nil {
+				t.Fatalf("Listen(%q) unexpected err: %v", tc.addr, err)
+			}
+			defer l.Close()
+
+			if got := l.Addr().Network(); got != tc.wantNetwork {
+				t.Errorf("Listen(%q).Addr().Network() = %q, want %q", tc.addr, got, tc.wantNetwork)
+			}
+
+			conn, err := net.Dial(l.Addr().Network(), l.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial() unexpected err: %v", err)
+			}
+			conn.Close()
+		})
+	}
+}
+
+func TestListenStaleUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "mc-srv.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	// Simulate an unclean exit, which leaves the socket file behind.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	l, err := Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Listen() unexpected err: %v", err)
+	}
+	l.Close()
+}
+
+func TestListenNotSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+
+	if l, err := Listen("unix:" + path); err == nil {
+		l.Close()
+		t.Errorf("Listen() unexpected success on a regular file")
+	}
+}
+
+func TestMultiListener(t *testing.T) {
+	t.Parallel()
+
+	l, err := ListenAll("127.0.0.1:0", "unix:"+filepath.Join(t.TempDir(), "mc-srv.sock"))
+	if err != nil {
+		t.Fatalf("ListenAll() unexpected err: %v", err)
+	}
+	defer l.Close()
+	ml := l.(*multiListener)
+
+	for _, sub := range ml.listeners {
+		conn, err := net.Dial(sub.Addr().Network(), sub.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial(%s) unexpected err: %v", sub.Addr(), err)
+		}
+		defer conn.Close()
+
+		accepted, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Accept() unexpected err: %v", err)
+		}
+		if got, want := accepted.LocalAddr().Network(), sub.Addr().Network(); got != want {
+			t.Errorf("Accept() got conn on %s, want %s", got, want)
+		}
+		accepted.Close()
+	}
+
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() unexpected err: %v", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Accept() after Close() err = %v, want %v", err, net.ErrClosed)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Accept() after Close() didn't return")
+	}
+}
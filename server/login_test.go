@@ -0,0 +1,748 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/auth/authtest"
+	"github.com/airforce270/mc-srv/server/forwarding"
+	"github.com/airforce270/mc-srv/server/forwarding/forwardingtest"
+	"github.com/airforce270/mc-srv/server/serverconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+var testProfile = auth.Profile{
+	ID:   uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"),
+	Name: "airfors",
+	Properties: []auth.Property{
+		{Name: "textures", Value: "dGV4dHVyZXM=", Signature: "c2lnbmF0dXJl"},
+	},
+}
+
+const testAccessToken = "token"
+
+func TestLoginOnline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc                 string
+		join                 bool
+		compressionThreshold int
+		wantSuccess          bool
+		wantReason           string
+	}{
+		{
+			desc:                 "joined",
+			join:                 true,
+			compressionThreshold: compression.Disabled,
+			wantSuccess:          true,
+		},
+		{
+			desc:                 "joined with compression",
+			join:                 true,
+			compressionThreshold: 0,
+			wantSuccess:          true,
+		},
+		{
+			desc:                 "not joined",
+			join:                 false,
+			compressionThreshold: compression.Disabled,
+			wantSuccess:          false,
+			wantReason:           reasonUnverifiedUsername,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			sessionServer := authtest.NewSessionServer()
+			sessionServer.AddProfile(testAccessToken, testProfile)
+			sessionSrv := httptest.NewServer(sessionServer)
+			defer sessionSrv.Close()
+
+			addr := startTestServer(t, Options{
+				Config:        testConfig(tc.compressionThreshold, true),
+				Authenticator: auth.Mojang{BaseURL: sessionSrv.URL},
+			})
+			c := dialTestServer(t, addr)
+
+			if err := c.startLogin(testProfile.Name, testProfile.ID); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			err := c.encrypt(func(serverHash string) error {
+				if !tc.join {
+					return nil
+				}
+				return authtest.Join(context.Background(), sessionSrv.URL, authtest.JoinRequest{
+					AccessToken:     testAccessToken,
+					SelectedProfile: testProfile.ID.String(),
+					ServerID:        serverHash,
+				})
+			})
+			if err != nil {
+				t.Fatalf("encrypt() unexpected err: %v", err)
+			}
+
+			if !tc.wantSuccess {
+				reason, err := c.readLoginDisconnect()
+				if err != nil {
+					t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+				}
+				if reason.Text != tc.wantReason {
+					t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+				}
+				return
+			}
+
+			got, err := c.readLoginSuccess()
+			if err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(testProfile, got); diff != "" {
+				t.Errorf("readLoginSuccess() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoginOnlineDisconnect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc          string
+		authenticator auth.Authenticator
+		wantReason    string
+	}{
+		{
+			desc:          "session server down",
+			authenticator: staticAuthenticator{err: errors.New("connection refused")},
+			wantReason:    reasonAuthServersDown,
+		},
+		{
+			desc:          "UUID mismatch",
+			authenticator: staticAuthenticator{profile: auth.Profile{ID: uuid.New(), Name: testProfile.Name}},
+			wantReason:    reasonUnverifiedUsername,
+		},
+		{
+			desc:          "username mismatch",
+			authenticator: staticAuthenticator{profile: auth.Profile{ID: testProfile.ID, Name: "jeb_"}},
+			wantReason:    reasonUnverifiedUsername,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config:        testConfig(compression.Disabled, true),
+				Authenticator: tc.authenticator,
+			})
+			c := dialTestServer(t, addr)
+
+			if err := c.startLogin(testProfile.Name, testProfile.ID); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			if err := c.encrypt(func(string) error { return nil }); err != nil {
+				t.Fatalf("encrypt() unexpected err: %v", err)
+			}
+
+			reason, err := c.readLoginDisconnect()
+			if err != nil {
+				t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+			}
+			if reason.Text != tc.wantReason {
+				t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestLoginOffline(t *testing.T) {
+	t.Parallel()
+
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+	})
+	c := dialTestServer(t, addr)
+
+	if err := c.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+	got, err := c.readLoginSuccess()
+	if err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+
+	want := auth.Profile{
+		ID:   uuid.MustParse("b50ad385-829d-3141-a216-7e7d7539ba7f"),
+		Name: "Notch",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("readLoginSuccess() diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestLoginWhitelist(t *testing.T) {
+	t.Parallel()
+
+	notch := uuid.MustParse("b50ad385-829d-3141-a216-7e7d7539ba7f")
+
+	tests := []struct {
+		desc       string
+		enabled    bool
+		whitelist  []uuid.UUID
+		wantReason string
+	}{
+		{
+			desc:      "whitelisted",
+			enabled:   true,
+			whitelist: []uuid.UUID{uuid.New(), notch},
+		},
+		{
+			desc:       "not whitelisted",
+			enabled:    true,
+			whitelist:  []uuid.UUID{uuid.New()},
+			wantReason: reasonNotWhitelisted,
+		},
+		{
+			desc:       "empty whitelist",
+			enabled:    true,
+			wantReason: reasonNotWhitelisted,
+		},
+		{
+			desc: "disabled",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testConfig(compression.Disabled, false)
+			cfg.WhiteList = tc.enabled
+			addr := startTestServer(t, Options{
+				Config:    cfg,
+				Whitelist: tc.whitelist,
+			})
+			c := dialTestServer(t, addr)
+
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+
+			if tc.wantReason == "" {
+				if _, err := c.readLoginSuccess(); err != nil {
+					t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+				}
+				return
+			}
+			reason, err := c.readLoginDisconnect()
+			if err != nil {
+				t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+			}
+			if reason.Text != tc.wantReason {
+				t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestJoinDifficulty(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig(compression.Disabled, false)
+	cfg.Difficulty = serverconfig.Difficulty(play.DifficultyHard)
+	addr := startTestServer(t, Options{Config: cfg})
+	c := dialTestServer(t, addr)
+
+	if err := c.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+	if _, err := c.readLoginSuccess(); err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+	if err := c.joinGame(); err != nil {
+		t.Fatalf("joinGame() unexpected err: %v", err)
+	}
+
+	buf, err := c.readUntilPacket(id.PlayChangeDifficulty)
+	if err != nil {
+		t.Fatalf("failed to read change difficulty: %v", err)
+	}
+	var got play.ChangeDifficulty
+	if err := codec.Decode(buf, &got); err != nil {
+		t.Fatalf("failed to decode change difficulty: %v", err)
+	}
+
+	want := play.ChangeDifficulty{Difficulty: play.DifficultyHard}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("change difficulty diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestLoginBadFraming(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc                 string
+		compressionThreshold int
+		frame                []byte
+	}{
+		{
+			desc:                 "packet length out of bounds",
+			compressionThreshold: compression.Disabled,
+			frame:                []byte{0x80, 0x80, 0x80, 0x01}, // 2^21
+		},
+		{
+			desc:                 "compressed packet length out of bounds",
+			compressionThreshold: 0,
+			frame:                []byte{0x80, 0x80, 0x80, 0x01}, // 2^21
+		},
+		{
+			desc:                 "compressed data length out of bounds",
+			compressionThreshold: 0,
+			frame: []byte{
+				0x05,                         // packet length
+				0x81, 0x80, 0x80, 0x04, 0x00, // data length (8 MiB + 1)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config: testConfig(tc.compressionThreshold, false),
+			})
+			c := dialTestServer(t, addr)
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			if _, err := c.readLoginSuccess(); err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+
+			if _, err := c.conn.Write(tc.frame); err != nil {
+				t.Fatalf("Failed to write frame: %v", err)
+			}
+
+			// The server can't find the next packet, so it must close the conn.
+			if err := c.conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+				t.Fatalf("Failed to set read deadline: %v", err)
+			}
+			if _, err := io.Copy(io.Discard, c.conn); err != nil {
+				t.Errorf("Conn wasn't closed after a bad frame: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoginUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		protocol   int32
+		wantReason string
+	}{
+		{
+			desc:       "outdated client",
+			protocol:   47,
+			wantReason: "Outdated client! Please use 1.20.4-1.21.1",
+		},
+		{
+			desc:       "between supported versions",
+			protocol:   766,
+			wantReason: "Outdated client! Please use 1.20.4-1.21.1",
+		},
+		{
+			desc:       "outdated server",
+			protocol:   100000,
+			wantReason: "Outdated server! I'm still on 1.20.4-1.21.1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+			})
+			c := dialTestServer(t, addr)
+			c.protocol = tc.protocol
+
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+
+			reason, err := c.readLoginDisconnect()
+			if err != nil {
+				t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+			}
+			if reason.Text != tc.wantReason {
+				t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+			}
+		})
+	}
+}
+
+// registries1_21 are the registries a 1.21 client needs to join, sorted.
+var registries1_21 = []string{
+	"minecraft:banner_pattern",
+	"minecraft:chat_type",
+	"minecraft:damage_type",
+	"minecraft:dimension_type",
+	"minecraft:enchantment",
+	"minecraft:jukebox_song",
+	"minecraft:painting_variant",
+	"minecraft:trim_material",
+	"minecraft:trim_pattern",
+	"minecraft:wolf_variant",
+	"minecraft:worldgen/biome",
+}
+
+// nonEmptyRegistries1_21 are the registries a 1.21 client
+// can't join without entries in.
+var nonEmptyRegistries1_21 = []string{
+	"minecraft:damage_type",
+	"minecraft:dimension_type",
+	"minecraft:painting_variant",
+	"minecraft:wolf_variant",
+	"minecraft:worldgen/biome",
+}
+
+func TestJoin1_21(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		packs      []config.KnownPack
+		wantReason string
+	}{
+		{
+			desc:  "knows core pack",
+			packs: []config.KnownPack{{Namespace: "minecraft", ID: "core", Version: "1.21.1"}},
+		},
+		{
+			// Enchantments' data isn't bundled.
+			desc:       "doesn't know core pack",
+			wantReason: reasonCorePackRequired,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+			})
+			c := dialTestServer(t, addr)
+			c.protocol = int32(protocol.V1_21)
+
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			buf, err := c.expectPacket(id.LoginSuccess)
+			if err != nil {
+				t.Fatalf("failed to read login success: %v", err)
+			}
+			c.loggedIn = true
+			var ls login.LoginSuccess
+			if err := codec.DecodeVersion(buf, &ls, int32(protocol.V1_21)); err != nil {
+				t.Fatalf("failed to decode login success: %v", err)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("login success has %d unread bytes", buf.Len())
+			}
+
+			if err := c.writePacket(id.LoginAcknowledgement); err != nil {
+				t.Fatalf("failed to write login acknowledged: %v", err)
+			}
+			if err := c.writePacket(0x00, "en_us", byte(10), int32(0), true, byte(0x7f), int32(1), false, true); err != nil {
+				t.Fatalf("failed to write client information: %v", err)
+			}
+
+			buf, err = c.readUntilPacket(0x0E)
+			if err != nil {
+				t.Fatalf("failed to read known packs: %v", err)
+			}
+			var kp config.ClientboundKnownPacks
+			if err := codec.Decode(buf, &kp); err != nil {
+				t.Fatalf("failed to decode known packs: %v", err)
+			}
+			if len(kp.Packs) == 0 {
+				t.Errorf("known packs = %v, want the core pack", kp.Packs)
+			}
+
+			var packs bytes.Buffer
+			if err := codec.Encode(&packs, config.ServerboundKnownPacks{Packs: tc.packs}); err != nil {
+				t.Fatalf("failed to encode known packs: %v", err)
+			}
+			if err := c.writePacket(0x07, packs.Bytes()); err != nil {
+				t.Fatalf("failed to write known packs: %v", err)
+			}
+
+			// Registries must all be sent before configuration finishes,
+			// and none are sent if the player is disconnected.
+			var gotRegistries []string
+			for {
+				pid, buf, err := c.readPacket()
+				if err != nil {
+					t.Fatalf("failed to read packet: %v", err)
+				}
+				if pid == 0x03 {
+					break
+				}
+				if pid == 0x02 {
+					var d config.Disconnect
+					if err := codec.Decode(buf, &d); err != nil {
+						t.Fatalf("failed to decode disconnect: %v", err)
+					}
+					if d.Reason.Text != tc.wantReason {
+						t.Errorf("disconnect reason = %q, want %q", d.Reason.Text, tc.wantReason)
+					}
+					if len(gotRegistries) != 0 {
+						t.Errorf("registries sent before disconnect = %v, want none", gotRegistries)
+					}
+					return
+				}
+				if pid != 0x07 {
+					continue
+				}
+				var reg config.Registry
+				if err := codec.Decode(buf, &reg); err != nil {
+					t.Fatalf("failed to decode registry: %v", err)
+				}
+				gotRegistries = append(gotRegistries, reg.RegistryID)
+				if slices.Contains(nonEmptyRegistries1_21, reg.RegistryID) && len(reg.Entries) == 0 {
+					t.Errorf("registry %s has no entries", reg.RegistryID)
+				}
+				for _, e := range reg.Entries {
+					if e.Data != nil {
+						t.Errorf("registry %s entry %s has data, want only its name", reg.RegistryID, e.ID)
+					}
+				}
+			}
+			if tc.wantReason != "" {
+				t.Fatalf("configuration finished, want disconnect with %q", tc.wantReason)
+			}
+			slices.Sort(gotRegistries)
+			if diff := cmp.Diff(registries1_21, gotRegistries); diff != "" {
+				t.Errorf("registries sent before finish configuration diff (-want +got):\n%s", diff)
+			}
+
+			if err := c.writePacket(0x03); err != nil {
+				t.Fatalf("failed to write acknowledge finish configuration: %v", err)
+			}
+			buf, err = c.readUntilPacket(0x2B)
+			if err != nil {
+				t.Fatalf("failed to read login (play): %v", err)
+			}
+			var l play.Login
+			if err := codec.DecodeVersion(buf, &l, int32(protocol.V1_21)); err != nil {
+				t.Fatalf("failed to decode login (play): %v", err)
+			}
+			if l.DimensionName != "minecraft:overworld" {
+				t.Errorf("login (play) dimension = %s, want minecraft:overworld", l.DimensionName)
+			}
+		})
+	}
+}
+
+func TestLoginVelocity(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	player := forwarding.Player{
+		Addr:    netip.MustParseAddr("192.0.2.1"),
+		Profile: testProfile,
+	}
+
+	tests := []struct {
+		desc        string
+		successful  bool
+		data        []byte
+		wantSuccess bool
+		wantReason  string
+	}{
+		{
+			desc:        "forwarded",
+			successful:  true,
+			data:        forwardingtest.VelocityData(secret, forwarding.VelocityModernDefault, player),
+			wantSuccess: true,
+		},
+		{
+			desc:        "wrong secret",
+			successful:  true,
+			data:        forwardingtest.VelocityData([]byte("wrong"), forwarding.VelocityModernDefault, player),
+			wantSuccess: false,
+			wantReason:  reasonVelocityRequired,
+		},
+		{
+			desc:        "not through proxy",
+			successful:  false,
+			wantSuccess: false,
+			wantReason:  reasonVelocityRequired,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config:         testConfig(compression.Disabled, true),
+				Authenticator:  failingAuthenticator{},
+				VelocitySecret: secret,
+			})
+			c := dialTestServer(t, addr)
+
+			// The proxy logs in with whatever the client sent it.
+			if err := c.startLogin(testProfile.Name, uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+
+			buf, err := c.expectPacket(id.LoginPluginRequest)
+			if err != nil {
+				t.Fatalf("Failed to read login plugin request: %v", err)
+			}
+			messageID, err := read.VarInt(buf)
+			if err != nil {
+				t.Fatalf("Failed to read message ID: %v", err)
+			}
+			channel, err := read.String(buf)
+			if err != nil {
+				t.Fatalf("Failed to read channel: %v", err)
+			}
+			if channel != forwarding.VelocityChannel {
+				t.Fatalf("Login plugin request channel = %q, want %q", channel, forwarding.VelocityChannel)
+			}
+
+			fields := []any{messageID, tc.successful}
+			if tc.successful {
+				fields = append(fields, tc.data)
+			}
+			if err := c.writePacket(id.LoginPluginResponse, fields...); err != nil {
+				t.Fatalf("Failed to write login plugin response: %v", err)
+			}
+
+			if !tc.wantSuccess {
+				reason, err := c.readLoginDisconnect()
+				if err != nil {
+					t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+				}
+				if reason.Text != tc.wantReason {
+					t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+				}
+				return
+			}
+
+			got, err := c.readLoginSuccess()
+			if err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(testProfile, got); diff != "" {
+				t.Errorf("readLoginSuccess() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoginBungeeCord(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc          string
+		serverAddress string
+		wantSuccess   bool
+		wantReason    string
+	}{
+		{
+			desc:          "forwarded",
+			serverAddress: "localhost\x00192.0.2.1\x008996cb86cb634c2d8b457cdfd7b542c8\x00" + `[{"name":"textures","value":"dGV4dHVyZXM=","signature":"c2lnbmF0dXJl"}]`,
+			wantSuccess:   true,
+		},
+		{
+			desc:          "not through proxy",
+			serverAddress: "localhost",
+			wantSuccess:   false,
+			wantReason:    reasonBungeeCordRequired,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config:        testConfig(compression.Disabled, true),
+				Authenticator: failingAuthenticator{},
+				BungeeCord:    true,
+			})
+			c := dialTestServer(t, addr)
+			c.serverAddress = tc.serverAddress
+
+			if err := c.startLogin(testProfile.Name, uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+
+			if !tc.wantSuccess {
+				reason, err := c.readLoginDisconnect()
+				if err != nil {
+					t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+				}
+				if reason.Text != tc.wantReason {
+					t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+				}
+				return
+			}
+
+			got, err := c.readLoginSuccess()
+			if err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(testProfile, got); diff != "" {
+				t.Errorf("readLoginSuccess() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// failingAuthenticator fails every authentication.
+// staticAuthenticator returns the same profile or error for every player.
+type staticAuthenticator struct {
+	profile auth.Profile
+	err     error
+}
+
+func (a staticAuthenticator) Authenticate(context.Context, string, string, netip.Addr) (auth.Profile, error) {
+	return a.profile, a.err
+}
+
+type failingAuthenticator struct{}
+
+func (failingAuthenticator) Authenticate(context.Context, string, string, netip.Addr) (auth.Profile, error) {
+	return auth.Profile{}, errors.New("authentication should have been skipped")
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/protocol"
+)
+
+// LoginQuery sends a login plugin request on the channel with the data
+// and waits for the client's response.
+// understood is false if the client doesn't understand the channel,
+// as the Notchian client answers on every channel.
+// It must be called from the OnLogin hook, before login succeeds.
+func (c *Conn) LoginQuery(ctx context.Context, channel string, data []byte) (response []byte, understood bool, err error) {
+	if protocol.StateOf(c.State()) != protocol.Login {
+		return nil, false, fmt.Errorf("failed to query %s: %w", channel, ErrWrongState)
+	}
+
+	// Buffered so the response can be delivered even if ctx is done.
+	responses := make(chan login.LoginPluginResponse, 1)
+	c.loginQueriesMtx.Lock()
+	messageID := c.nextMessageIDLocked()
+	if c.loginQueries == nil {
+		c.loginQueries = map[int32]chan<- login.LoginPluginResponse{}
+	}
+	c.loginQueries[messageID] = responses
+	c.loginQueriesMtx.Unlock()
+	defer func() {
+		c.loginQueriesMtx.Lock()
+		delete(c.loginQueries, messageID)
+		c.loginQueriesMtx.Unlock()
+	}()
+
+	pr := login.LoginPluginRequest{MessageID: messageID, Channel: channel, Data: data}
+	if err := c.writePacket(pr); err != nil {
+		return nil, false, fmt.Errorf("failed to write login plugin request: %w", err)
+	}
+	c.logger.Printf("Wrote login plugin request %d on %s", messageID, channel)
+
+	select {
+	case <-ctx.Done():
+		return nil, false, fmt.Errorf("failed to wait for response to %s: %w", channel, ctx.Err())
+	case resp := <-responses:
+		if !resp.Successful {
+			return nil, false, nil
+		}
+		return resp.Data, true, nil
+	}
+}
+
+// answerLoginQuery delivers the client's response to the LoginQuery awaiting it.
+func (c *Conn) answerLoginQuery(p login.LoginPluginResponse) error {
+	c.loginQueriesMtx.Lock()
+	responses, ok := c.loginQueries[p.MessageID]
+	delete(c.loginQueries, p.MessageID)
+	c.loginQueriesMtx.Unlock()
+	if !ok {
+		return fmt.Errorf("login plugin response has message ID %d, which wasn't requested: %w", p.MessageID, errUnexpectedPacket)
+	}
+	responses <- p
+	return nil
+}
+
+// nextMessageID returns a login plugin request message ID
+// unique to the conn.
+func (c *Conn) nextMessageID() int32 {
+	c.loginQueriesMtx.Lock()
+	defer c.loginQueriesMtx.Unlock()
+	return c.nextMessageIDLocked()
+}
+
+// nextMessageIDLocked is nextMessageID with loginQueriesMtx held.
+func (c *Conn) nextMessageIDLocked() int32 {
+	c.lastMessageID++
+	return c.lastMessageID
+}
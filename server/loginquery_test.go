@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+// queryResult is the result of a LoginQuery.
+type queryResult struct {
+	Response   []byte
+	Understood bool
+}
+
+func TestLoginQuery(t *testing.T) {
+	t.Parallel()
+
+	results := make(chan []queryResult, 1)
+	addr := startTestServer(t, Options{
+		Config: testConfig(0, false),
+		Hooks: Hooks{
+			OnLogin: func(ctx context.Context, c *Conn) error {
+				var got []queryResult
+				for _, channel := range []string{"mc-srv:known", "mc-srv:unknown"} {
+					resp, understood, err := c.LoginQuery(ctx, channel, []byte("ping"))
+					if err != nil {
+						return err
+					}
+					got = append(got, queryResult{resp, understood})
+				}
+				results <- got
+				return nil
+			},
+		},
+	})
+	c := dialTestServer(t, addr)
+	if err := c.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+
+	var messageIDs []int32
+	for _, answer := range []func(messageID int32) error{
+		func(messageID int32) error {
+			return c.writePacket(id.LoginPluginResponse, messageID, true, []byte("pong"))
+		},
+		func(messageID int32) error {
+			return c.writePacket(id.LoginPluginResponse, messageID, false)
+		},
+	} {
+		buf, err := c.expectPacket(id.LoginPluginRequest)
+		if err != nil {
+			t.Fatalf("Failed to read login plugin request: %v", err)
+		}
+		var pr login.LoginPluginRequest
+		if err := codec.Decode(buf, &pr); err != nil {
+			t.Fatalf("Failed to decode login plugin request: %v", err)
+		}
+		if string(pr.Data) != "ping" {
+			t.Errorf("Login plugin request data = %q, want %q", pr.Data, "ping")
+		}
+		messageIDs = append(messageIDs, pr.MessageID)
+		if err := answer(pr.MessageID); err != nil {
+			t.Fatalf("Failed to write login plugin response: %v", err)
+		}
+	}
+	if messageIDs[0] == messageIDs[1] {
+		t.Errorf("Login plugin requests have the same message ID %d", messageIDs[0])
+	}
+
+	if _, err := c.readLoginSuccess(); err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+	want := []queryResult{
+		{Response: []byte("pong"), Understood: true},
+		{Response: nil, Understood: false},
+	}
+	if diff := cmp.Diff(want, <-results); diff != "" {
+		t.Errorf("LoginQuery() results diff (-want +got):\n%s", diff)
+	}
+	if err := c.joinGame(); err != nil {
+		t.Errorf("joinGame() unexpected err: %v", err)
+	}
+}
+
+func TestLoginHookError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc       string
+		err        error
+		wantReason string
+	}{
+		{
+			desc:       "internal error",
+			err:        errors.New("failed to query ban database: connection refused"),
+			wantReason: reasonLoginFailed,
+		},
+		{
+			desc:       "disconnect error",
+			err:        NewDisconnectError(types.TextComponent{Text: "You are banned"}, errors.New("player is banned")),
+			wantReason: "You are banned",
+		},
+		{
+			desc:       "wrapped disconnect error",
+			err:        fmt.Errorf("failed to check bans: %w", NewDisconnectError(types.TextComponent{Text: "You are banned"}, errors.New("player is banned"))),
+			wantReason: "You are banned",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+				Hooks: Hooks{
+					OnLogin: func(ctx context.Context, c *Conn) error {
+						return tc.err
+					},
+				},
+			})
+			c := dialTestServer(t, addr)
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+
+			reason, err := c.readLoginDisconnect()
+			if err != nil {
+				t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+			}
+			if reason.Text != tc.wantReason {
+				t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestLoginQueryWrongState(t *testing.T) {
+	t.Parallel()
+
+	hookErrs := make(chan error, 1)
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Hooks: Hooks{
+			OnJoin: func(c *Conn) {
+				_, _, err := c.LoginQuery(context.Background(), "mc-srv:late", nil)
+				hookErrs <- err
+			},
+		},
+	})
+	join(t, addr, testProtocolVersion)
+
+	if err := <-hookErrs; !errors.Is(err, ErrWrongState) {
+		t.Errorf("LoginQuery() err = %v, want %v", err, ErrWrongState)
+	}
+}
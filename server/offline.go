@@ -0,0 +1,23 @@
+package server
+
+import (
+	"crypto/md5"
+
+	"github.com/google/uuid"
+)
+
+// offlineUUID returns the UUID of a player in offline mode,
+// derived from their username the same way the Notchian server does
+// (a version 3 UUID of "OfflinePlayer:<name>", without a namespace).
+func offlineUUID(username string) uuid.UUID {
+	return nameUUID("OfflinePlayer:" + username)
+}
+
+// nameUUID returns the version 3 UUID of name, without a namespace,
+// like Java's UUID.nameUUIDFromBytes.
+func nameUUID(name string) uuid.UUID {
+	u := uuid.UUID(md5.Sum([]byte(name)))
+	u[6] = u[6]&0x0f | 0x30 // version 3
+	u[8] = u[8]&0x3f | 0x80 // RFC 4122 variant
+	return u
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOfflineUUID(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Notch", "b50ad385-829d-3141-a216-7e7d7539ba7f"},
+		{"jeb_", "a762f560-4fce-3236-812a-b80efff0b62b"},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s->%s", tc.input, tc.want), func(t *testing.T) {
+			if got := offlineUUID(tc.input).String(); got != tc.want {
+				t.Errorf("offlineUUID() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/plugin"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/types"
+)
+
+// DefaultBrand is the brand the server sends clients,
+// shown on their debug screen.
+const DefaultBrand = "mc-srv"
+
+// A ChannelHandler handles the plugin messages a client sends on a channel,
+// in the configuration and play states.
+// Like hooks, it's called synchronously, so it should return quickly.
+// If it returns an error, it's logged.
+type ChannelHandler func(c *Conn, data []byte) error
+
+// SendPluginMessage sends data to the client on a plugin channel.
+// The conn must be in the configuration or play state.
+func (c *Conn) SendPluginMessage(channel types.Identifier, data []byte) error {
+	var p any
+	switch protocol.StateOf(c.State()) {
+	case protocol.Configuration:
+		p = config.ClientboundPlugin{Channel: channel, Data: data}
+	case protocol.Play:
+		p = play.ClientboundPlugin{Channel: channel, Data: data}
+	default:
+		return fmt.Errorf("failed to send plugin message on %s: %w", channel, ErrWrongState)
+	}
+	if err := c.writePacket(p); err != nil {
+		return fmt.Errorf("failed to write plugin message: %w", err)
+	}
+	return nil
+}
+
+// ClientBrand returns the brand the client announced, e.g. "vanilla",
+// or "" if it hasn't.
+func (c *Conn) ClientBrand() string {
+	c.pluginMtx.Lock()
+	defer c.pluginMtx.Unlock()
+	return c.clientBrand
+}
+
+// ClientChannels returns the channels the client registered, sorted.
+func (c *Conn) ClientChannels() []types.Identifier {
+	c.pluginMtx.Lock()
+	defer c.pluginMtx.Unlock()
+	return slices.Sorted(maps.Keys(c.clientChannels))
+}
+
+// sendBrand sends the server's brand and the channels it handles
+// at the start of configuration, like the Notchian server.
+func (c *Conn) sendBrand() error {
+	brand := c.opts.Brand
+	if brand == "" {
+		brand = DefaultBrand
+	}
+	if err := c.writePacket(config.ClientboundPlugin{Channel: plugin.BrandChannel, Data: plugin.Brand(brand)}); err != nil {
+		return fmt.Errorf("failed to write brand: %w", err)
+	}
+	if len(c.opts.Channels) == 0 {
+		return nil
+	}
+	channels := slices.Sorted(maps.Keys(c.opts.Channels))
+	if err := c.writePacket(config.ClientboundPlugin{Channel: plugin.RegisterChannel, Data: plugin.Channels(channels)}); err != nil {
+		return fmt.Errorf("failed to write registered channels: %w", err)
+	}
+	return nil
+}
+
+// handlePluginMessage handles a plugin message from the client,
+// keeping track of its brand and channels
+// and passing it to the channel's handler, if any.
+func (c *Conn) handlePluginMessage(channel types.Identifier, data []byte) error {
+	switch channel {
+	case plugin.BrandChannel:
+		brand, err := plugin.ReadBrand(data)
+		if err != nil {
+			return fmt.Errorf("failed to read client brand: %w", err)
+		}
+		c.pluginMtx.Lock()
+		c.clientBrand = brand
+		c.pluginMtx.Unlock()
+		c.logger.Printf("Client brand is %q", brand)
+	case plugin.RegisterChannel, plugin.UnregisterChannel:
+		channels, err := plugin.ReadChannels(data)
+		if err != nil {
+			return fmt.Errorf("failed to read %s channels: %w", channel, err)
+		}
+		c.pluginMtx.Lock()
+		if c.clientChannels == nil {
+			c.clientChannels = map[types.Identifier]struct{}{}
+		}
+		for _, ch := range channels {
+			if channel == plugin.RegisterChannel {
+				c.clientChannels[ch] = struct{}{}
+			} else {
+				delete(c.clientChannels, ch)
+			}
+		}
+		c.pluginMtx.Unlock()
+	}
+
+	h, ok := c.opts.Channels[channel]
+	if !ok {
+		return nil
+	}
+	if err := h(c, data); err != nil {
+		return fmt.Errorf("failed to handle plugin message on %s: %w", channel, err)
+	}
+	return nil
+}
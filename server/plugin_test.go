@@ -0,0 +1,155 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/plugin"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+// pluginState is what a conn knows of the client's plugin channels.
+type pluginState struct {
+	Brand    string
+	Channels []types.Identifier
+}
+
+func TestPluginChannels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		version protocol.Version
+		// IDs of the plugin message packets in the version.
+		configClientbound, configServerbound id.ID
+		playClientbound, playServerbound     id.ID
+	}{
+		{
+			desc:              "1.20.4",
+			version:           protocol.V1_20_4,
+			configClientbound: id.ClientboundPlugin,
+			configServerbound: id.ServerboundPlugin,
+			playClientbound:   id.PlayClientboundPlugin,
+			playServerbound:   id.PlayServerboundPlugin,
+		},
+		{
+			desc:              "1.21",
+			version:           protocol.V1_21,
+			configClientbound: 0x01,
+			configServerbound: 0x02,
+			playClientbound:   0x19,
+			playServerbound:   0x12,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			states := make(chan pluginState, 1)
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+				Brand:  "mc-srv test",
+				Channels: map[types.Identifier]ChannelHandler{
+					"mc-srv:echo": func(c *Conn, data []byte) error {
+						states <- pluginState{Brand: c.ClientBrand(), Channels: c.ClientChannels()}
+						return c.SendPluginMessage("mc-srv:echo", data)
+					},
+				},
+			})
+
+			c := dialTestServer(t, addr)
+			c.protocol = int32(tc.version)
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			if _, err := c.readLoginSuccess(); err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+			if err := c.writePacket(id.LoginAcknowledgement); err != nil {
+				t.Fatalf("Failed to write login acknowledged: %v", err)
+			}
+
+			var got []config.ClientboundPlugin
+			for range 2 {
+				buf, err := c.expectPacket(tc.configClientbound)
+				if err != nil {
+					t.Fatalf("Failed to read plugin message: %v", err)
+				}
+				var p config.ClientboundPlugin
+				if err := codec.Decode(buf, &p); err != nil {
+					t.Fatalf("Failed to decode plugin message: %v", err)
+				}
+				got = append(got, p)
+			}
+			want := []config.ClientboundPlugin{
+				{Channel: plugin.BrandChannel, Data: plugin.Brand("mc-srv test")},
+				{Channel: plugin.RegisterChannel, Data: []byte("mc-srv:echo")},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Plugin messages diff (-want +got):\n%s", diff)
+			}
+
+			if err := c.writePacket(tc.configServerbound, string(plugin.BrandChannel), plugin.Brand("vanilla")); err != nil {
+				t.Fatalf("Failed to write brand: %v", err)
+			}
+			if err := c.writePacket(tc.configServerbound, string(plugin.RegisterChannel), []byte("mc-srv:client\x00mc-srv:other")); err != nil {
+				t.Fatalf("Failed to write register: %v", err)
+			}
+			if err := c.configure(); err != nil {
+				t.Fatalf("configure() unexpected err: %v", err)
+			}
+
+			if err := c.writePacket(tc.playServerbound, string(plugin.UnregisterChannel), []byte("mc-srv:other")); err != nil {
+				t.Fatalf("Failed to write unregister: %v", err)
+			}
+			if err := c.writePacket(tc.playServerbound, "mc-srv:echo", []byte("hello")); err != nil {
+				t.Fatalf("Failed to write echo: %v", err)
+			}
+			buf, err := c.readUntilPacket(tc.playClientbound)
+			if err != nil {
+				t.Fatalf("Failed to read echo: %v", err)
+			}
+			var echo play.ClientboundPlugin
+			if err := codec.Decode(buf, &echo); err != nil {
+				t.Fatalf("Failed to decode echo: %v", err)
+			}
+			if diff := cmp.Diff(play.ClientboundPlugin{Channel: "mc-srv:echo", Data: []byte("hello")}, echo); diff != "" {
+				t.Errorf("Echo diff (-want +got):\n%s", diff)
+			}
+
+			wantState := pluginState{Brand: "vanilla", Channels: []types.Identifier{"mc-srv:client"}}
+			if diff := cmp.Diff(wantState, <-states); diff != "" {
+				t.Errorf("Plugin state diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSendPluginMessageWrongState(t *testing.T) {
+	t.Parallel()
+
+	hookErrs := make(chan error, 1)
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Hooks: Hooks{
+			OnConnect: func(c *Conn) error {
+				hookErrs <- c.SendPluginMessage("mc-srv:early", nil)
+				return nil
+			},
+		},
+	})
+	dialTestServer(t, addr)
+
+	if err := <-hookErrs; !errors.Is(err, ErrWrongState) {
+		t.Errorf("SendPluginMessage() err = %v, want %v", err, ErrWrongState)
+	}
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/uuid"
+)
+
+// DefaultResourcePackRequiredMessage is the reason players are disconnected
+// with when they don't apply a forced resource pack,
+// the same as the Notchian client shows.
+const DefaultResourcePackRequiredMessage = "Server requires a custom resource pack"
+
+// A ResourcePack is a resource pack offered to players
+// during configuration.
+type ResourcePack struct {
+	// UUID identifies the pack.
+	// If nil, it's derived from the URL like the Notchian server does.
+	UUID uuid.UUID
+	// URL is where the client downloads the pack from.
+	URL string
+	// Hash is the hex SHA-1 hash of the pack, or "" to not verify it.
+	Hash string
+	// Forced is whether the player must apply the pack to join.
+	Forced bool
+	// Prompt is shown when the client is asked to apply the pack, if set.
+	Prompt *types.TextComponent
+}
+
+// id returns the UUID of the pack.
+func (p ResourcePack) id() uuid.UUID {
+	if p.UUID != uuid.Nil {
+		return p.UUID
+	}
+	return nameUUID(p.URL)
+}
+
+// validate returns an error if the client can't be sent the pack.
+func (p ResourcePack) validate() error {
+	if p.URL == "" {
+		return errors.New("no URL")
+	}
+	if p.Hash == "" {
+		return nil
+	}
+	if b, err := hex.DecodeString(p.Hash); err != nil || len(b) != 20 {
+		return fmt.Errorf("hash %q isn't a hex SHA-1 hash", p.Hash)
+	}
+	return nil
+}
+
+// ResourcePackResult returns the last result the client sent
+// for the resource pack with the UUID, and whether it's sent one.
+func (c *Conn) ResourcePackResult(id uuid.UUID) (config.ResourcePackResult, bool) {
+	c.resourcePacksMtx.Lock()
+	defer c.resourcePacksMtx.Unlock()
+	result, ok := c.resourcePackResults[id]
+	return result, ok
+}
+
+// sendResourcePacks asks the client to apply the server's resource packs.
+// Configuration isn't finished until the client has applied or rejected
+// each of them.
+func (c *Conn) sendResourcePacks() error {
+	c.resourcePacksMtx.Lock()
+	c.pendingResourcePacks = map[uuid.UUID]ResourcePack{}
+	for _, p := range c.opts.ResourcePacks {
+		c.pendingResourcePacks[p.id()] = p
+	}
+	c.resourcePacksMtx.Unlock()
+
+	for _, p := range c.opts.ResourcePacks {
+		ap := config.ConfigAddResourcePack{
+			UUID:          p.id(),
+			URL:           p.URL,
+			Hash:          p.Hash,
+			Forced:        p.Forced,
+			PromptMessage: p.Prompt,
+		}
+		if err := c.writePacket(ap); err != nil {
+			return fmt.Errorf("failed to write add resource pack %s: %w", p.URL, err)
+		}
+		c.logger.Printf("Wrote add resource pack %s", p.URL)
+	}
+	return nil
+}
+
+// receiveResourcePackResult records the result the client sent
+// for a resource pack.
+// If it didn't apply a forced pack, it's disconnected.
+func (c *Conn) receiveResourcePackResult(id uuid.UUID, result config.ResourcePackResult) error {
+	c.resourcePacksMtx.Lock()
+	p, pending := c.pendingResourcePacks[id]
+	if result.Final() {
+		delete(c.pendingResourcePacks, id)
+	}
+	if c.resourcePackResults == nil {
+		c.resourcePackResults = map[uuid.UUID]config.ResourcePackResult{}
+	}
+	c.resourcePackResults[id] = result
+	c.resourcePacksMtx.Unlock()
+	c.logger.Printf("Resource pack %s result: %d", id, result)
+
+	if pending && p.Forced && result.Final() && !result.Succeeded() {
+		return &disconnectError{
+			reason: c.resourcePackRequiredMessage(),
+			err:    fmt.Errorf("client didn't apply forced resource pack %s, result: %d", p.URL, result),
+		}
+	}
+	return nil
+}
+
+// resourcePacksPending returns whether the client
+// is still applying any of the server's resource packs.
+func (c *Conn) resourcePacksPending() bool {
+	c.resourcePacksMtx.Lock()
+	defer c.resourcePacksMtx.Unlock()
+	return len(c.pendingResourcePacks) > 0
+}
+
+// resourcePackRequiredMessage returns the reason to disconnect players with
+// when they don't apply a forced resource pack.
+func (c *Conn) resourcePackRequiredMessage() types.TextComponent {
+	if c.opts.ResourcePackRequiredMessage == nil {
+		return types.TextComponent{Text: DefaultResourcePackRequiredMessage}
+	}
+	return *c.opts.ResourcePackRequiredMessage
+}
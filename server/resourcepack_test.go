@@ -0,0 +1,208 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestResourcePacks(t *testing.T) {
+	t.Parallel()
+
+	packUUID := uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8")
+	tests := []struct {
+		desc    string
+		version protocol.Version
+		pack    ResourcePack
+		message *types.TextComponent
+		results []config.ResourcePackResult
+		// wantReason is the reason the player is disconnected with,
+		// or "" if they join.
+		wantReason string
+	}{
+		{
+			desc:    "forced applied",
+			version: protocol.V1_20_4,
+			pack:    ResourcePack{UUID: packUUID, URL: "https://example.com/pack.zip", Forced: true},
+			results: []config.ResourcePackResult{
+				config.ResourcePackResultAccepted,
+				config.ResourcePackResultDownloaded,
+				config.ResourcePackResultSuccessfullyDownloaded,
+			},
+		},
+		{
+			desc:    "optional declined",
+			version: protocol.V1_20_4,
+			pack:    ResourcePack{UUID: packUUID, URL: "https://example.com/pack.zip"},
+			results: []config.ResourcePackResult{config.ResourcePackResultDeclined},
+		},
+		{
+			desc:       "forced declined",
+			version:    protocol.V1_20_4,
+			pack:       ResourcePack{UUID: packUUID, URL: "https://example.com/pack.zip", Forced: true},
+			results:    []config.ResourcePackResult{config.ResourcePackResultDeclined},
+			wantReason: DefaultResourcePackRequiredMessage,
+		},
+		{
+			desc:    "forced failed with message",
+			version: protocol.V1_20_4,
+			pack:    ResourcePack{UUID: packUUID, URL: "https://example.com/pack.zip", Forced: true},
+			message: &types.TextComponent{Text: "Please enable server resource packs"},
+			results: []config.ResourcePackResult{
+				config.ResourcePackResultAccepted,
+				config.ResourcePackResultFailedToDownload,
+			},
+			wantReason: "Please enable server resource packs",
+		},
+		{
+			desc:    "1.21 forced applied",
+			version: protocol.V1_21,
+			pack:    ResourcePack{UUID: packUUID, URL: "https://example.com/pack.zip", Forced: true},
+			results: []config.ResourcePackResult{
+				config.ResourcePackResultAccepted,
+				config.ResourcePackResultSuccessfullyDownloaded,
+			},
+		},
+		{
+			desc:       "1.21 forced declined",
+			version:    protocol.V1_21,
+			pack:       ResourcePack{UUID: packUUID, URL: "https://example.com/pack.zip", Forced: true},
+			results:    []config.ResourcePackResult{config.ResourcePackResultDeclined},
+			wantReason: DefaultResourcePackRequiredMessage,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			// IDs of the configuration packets in the version.
+			addID, responseID, disconnectID := id.ConfigAddResourcePack, id.ResourcePackResponse, id.ConfigDisconnect
+			if tc.version == protocol.V1_21 {
+				addID, responseID, disconnectID = 0x09, 0x06, 0x02
+			}
+
+			results := make(chan config.ResourcePackResult, 1)
+			addr := startTestServer(t, Options{
+				Config:                      testConfig(compression.Disabled, false),
+				ResourcePacks:               []ResourcePack{tc.pack},
+				ResourcePackRequiredMessage: tc.message,
+				Hooks: Hooks{
+					OnJoin: func(c *Conn) {
+						result, _ := c.ResourcePackResult(packUUID)
+						results <- result
+					},
+				},
+			})
+			c := dialTestServer(t, addr)
+			c.protocol = int32(tc.version)
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			if _, err := c.readLoginSuccess(); err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+			if err := c.writePacket(id.LoginAcknowledgement); err != nil {
+				t.Fatalf("Failed to write login acknowledged: %v", err)
+			}
+
+			buf, err := c.readUntilPacket(addID)
+			if err != nil {
+				t.Fatalf("Failed to read add resource pack: %v", err)
+			}
+			var got config.ConfigAddResourcePack
+			if err := codec.Decode(buf, &got); err != nil {
+				t.Fatalf("Failed to decode add resource pack: %v", err)
+			}
+			want := config.ConfigAddResourcePack{UUID: packUUID, URL: tc.pack.URL, Forced: tc.pack.Forced}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Add resource pack diff (-want +got):\n%s", diff)
+			}
+
+			for _, result := range tc.results {
+				if err := c.writePacket(responseID, packUUID, int32(result)); err != nil {
+					t.Fatalf("Failed to write resource pack response: %v", err)
+				}
+			}
+
+			if tc.wantReason != "" {
+				buf, err := c.readUntilPacket(disconnectID)
+				if err != nil {
+					t.Fatalf("Failed to read disconnect: %v", err)
+				}
+				var d config.Disconnect
+				if err := codec.Decode(buf, &d); err != nil {
+					t.Fatalf("Failed to decode disconnect: %v", err)
+				}
+				if d.Reason.Text != tc.wantReason {
+					t.Errorf("Disconnect reason = %q, want %q", d.Reason.Text, tc.wantReason)
+				}
+				return
+			}
+
+			if err := c.configure(); err != nil {
+				t.Fatalf("configure() unexpected err: %v", err)
+			}
+			if got, want := <-results, tc.results[len(tc.results)-1]; got != want {
+				t.Errorf("ResourcePackResult() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestResourcePackUUIDFromURL(t *testing.T) {
+	t.Parallel()
+
+	p := ResourcePack{URL: "https://example.com/pack.zip"}
+	if got, want := p.id(), nameUUID(p.URL); got != want {
+		t.Errorf("id() = %s, want %s", got, want)
+	}
+	if got := p.id().Version(); got != 3 {
+		t.Errorf("id().Version() = %d, want 3", got)
+	}
+}
+
+func TestNewInvalidResourcePack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		pack ResourcePack
+	}{
+		{
+			desc: "no URL",
+			pack: ResourcePack{},
+		},
+		{
+			desc: "short hash",
+			pack: ResourcePack{URL: "https://example.com/pack.zip", Hash: "abcdef"},
+		},
+		{
+			desc: "non-hex hash",
+			pack: ResourcePack{URL: "https://example.com/pack.zip", Hash: "zz39a3ee5e6b4b0d3255bfef95601890afd80709"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			valid := ResourcePack{URL: "https://example.com/valid.zip"}
+			_, err := New(WithResourcePacks(valid, tc.pack))
+			if err == nil {
+				t.Fatal("New() err = nil, want an error")
+			}
+			if want := "resource pack 1"; !strings.Contains(err.Error(), want) {
+				t.Errorf("New() err = %v, want it to contain %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/serverconfig"
+	"github.com/airforce270/mc-srv/server/status"
+	"github.com/google/uuid"
+)
+
+// DefaultShutdownMessage is the reason players are disconnected with
+// when the server shuts down, the same as the Notchian server.
+const DefaultShutdownMessage = "Server closed"
+
+// ErrServerClosed is returned by Serve and ListenAndServe
+// after Shutdown is called.
+var ErrServerClosed = errors.New("server closed")
+
+// Server accepts conns and handles them until it's shut down.
+type Server struct {
+	opts            Options
+	faviconFile     string
+	shutdownMessage types.TextComponent
+
+	// ctx is the parent context of every conn's context,
+	// canceled once the server shuts down.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mtx          sync.Mutex
+	listeners    map[net.Listener]struct{}
+	shuttingDown bool
+	// handlers tracks the goroutines handling conns.
+	handlers sync.WaitGroup
+}
+
+// An Option configures a Server.
+type Option func(*Server)
+
+// WithConfig sets the server's configuration.
+// It replaces the whole config, so it should come before
+// options that change parts of it, e.g. WithCompressionThreshold.
+// Defaults to serverconfig.Default().
+func WithConfig(cfg serverconfig.Config) Option {
+	return func(s *Server) { s.opts.Config = cfg }
+}
+
+// WithCompressionThreshold sets the minimum size of a packet, in bytes,
+// before it is compressed.
+// compression.Disabled (or any negative value) disables compression.
+func WithCompressionThreshold(threshold int) Option {
+	return func(s *Server) { s.opts.Config.NetworkCompressionThreshold = threshold }
+}
+
+// WithLogger sets the logger the server and its conns log to.
+// Defaults to the standard logger.
+func WithLogger(logger *log.Logger) Option {
+	return func(s *Server) { s.opts.Logger = logger }
+}
+
+// WithAuthenticator sets how players are authenticated in online mode.
+// Defaults to auth.Mojang with its default settings.
+func WithAuthenticator(a auth.Authenticator) Option {
+	return func(s *Server) { s.opts.Authenticator = a }
+}
+
+// WithWhitelist sets the UUIDs of the players who can join
+// if the config's WhiteList is set.
+func WithWhitelist(players ...uuid.UUID) Option {
+	return func(s *Server) { s.opts.Whitelist = players }
+}
+
+// WithStatus sets the provider of the status shown in the server list.
+// Defaults to a status.ConfigProvider with the config's MOTD and max players,
+// reporting the server's players online.
+func WithStatus(p status.Provider) Option {
+	return func(s *Server) { s.opts.Status = p }
+}
+
+// WithFaviconFile sets the path to a 64x64 PNG shown in the server list
+// by the default status provider.
+func WithFaviconFile(name string) Option {
+	return func(s *Server) { s.faviconFile = name }
+}
+
+// WithShutdownMessage sets the reason players are disconnected with
+// when the server shuts down.
+// Defaults to DefaultShutdownMessage.
+func WithShutdownMessage(msg types.TextComponent) Option {
+	return func(s *Server) { s.shutdownMessage = msg }
+}
+
+// WithVelocitySecret requires players to connect through a Velocity proxy
+// using modern forwarding with the given secret.
+func WithVelocitySecret(secret []byte) Option {
+	return func(s *Server) { s.opts.VelocitySecret = secret }
+}
+
+// WithBungeeCord requires players to connect through a BungeeCord-style
+// proxy with IP forwarding enabled.
+func WithBungeeCord() Option {
+	return func(s *Server) { s.opts.BungeeCord = true }
+}
+
+// WithBrand sets the brand the server sends clients.
+func WithBrand(brand string) Option {
+	return func(s *Server) { s.opts.Brand = brand }
+}
+
+// WithChannel sets the handler of a plugin channel.
+func WithChannel(channel types.Identifier, h ChannelHandler) Option {
+	return func(s *Server) {
+		if s.opts.Channels == nil {
+			s.opts.Channels = map[types.Identifier]ChannelHandler{}
+		}
+		s.opts.Channels[channel] = h
+	}
+}
+
+// WithResourcePacks sets the resource packs offered to players.
+func WithResourcePacks(packs ...ResourcePack) Option {
+	return func(s *Server) { s.opts.ResourcePacks = packs }
+}
+
+// WithResourcePackRequiredMessage sets the reason players are disconnected with
+// when they don't apply a forced resource pack.
+func WithResourcePackRequiredMessage(msg types.TextComponent) Option {
+	return func(s *Server) { s.opts.ResourcePackRequiredMessage = &msg }
+}
+
+// WithHooks sets functions called on events in each conn's lifecycle.
+func WithHooks(hooks Hooks) Option {
+	return func(s *Server) { s.opts.Hooks = hooks }
+}
+
+// New returns a new server with the given options.
+func New(opts ...Option) (*Server, error) {
+	s := &Server{
+		opts: Options{
+			Config: serverconfig.Default(),
+			Conns:  NewConnTracker(),
+		},
+		shutdownMessage: types.TextComponent{Text: DefaultShutdownMessage},
+		listeners:       map[net.Listener]struct{}{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.opts.VelocitySecret != nil && s.opts.BungeeCord {
+		return nil, errors.New("velocity and bungeecord forwarding can't both be enabled")
+	}
+	// Packs are named by their index, since their UUID and URL may be unset.
+	for i, p := range s.opts.ResourcePacks {
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("invalid resource pack %d (url=%q): %w", i, p.URL, err)
+		}
+	}
+	if s.opts.Logger == nil {
+		s.opts.Logger = log.Default()
+	}
+	if s.opts.Status == nil {
+		cfg, err := s.opts.Config.StatusConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create status config: %w", err)
+		}
+		cfg.FaviconFile = s.faviconFile
+		s.opts.Status, err = status.New(cfg, s.opts.Conns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create status provider: %w", err)
+		}
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// Conns returns the tracker of the server's conns.
+func (s *Server) Conns() *ConnTracker {
+	return s.opts.Conns
+}
+
+// ListenAndServe listens on the given bind addresses and serves conns
+// from all of them. See Listen for the format of the addresses.
+// If none are given, it listens on the config's server-ip and server-port.
+// It always returns a non-nil error, ErrServerClosed after Shutdown.
+func (s *Server) ListenAndServe(addrs ...string) error {
+	if len(addrs) == 0 {
+		addrs = []string{net.JoinHostPort(s.opts.Config.ServerIP, strconv.Itoa(s.opts.Config.ServerPort))}
+	}
+	l, err := ListenAll(addrs...)
+	if err != nil {
+		return err
+	}
+	s.opts.Logger.Printf("Listening on %s", strings.Join(addrs, ", "))
+	return s.Serve(l)
+}
+
+// Serve accepts conns from l and handles each in a new goroutine.
+// l is closed when Serve returns.
+// It always returns a non-nil error, ErrServerClosed after Shutdown.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.trackListener(l) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrackListener(l)
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isShuttingDown() {
+				return ErrServerClosed
+			}
+			return fmt.Errorf("failed to accept conn: %w", err)
+		}
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetNoDelay(true)
+			tcpConn.SetKeepAlive(true)
+		}
+		s.opts.Logger.Printf("New connection from %s", conn.RemoteAddr())
+
+		c, err := NewConn(conn, s.opts)
+		if err != nil {
+			s.opts.Logger.Printf("Failed to create connection handler: %v", err)
+			conn.Close()
+			continue
+		}
+
+		if !s.addHandler() {
+			c.Close()
+			return ErrServerClosed
+		}
+		go func() {
+			defer s.handlers.Done()
+			ctx, cancel := context.WithCancel(s.ctx)
+			defer cancel()
+			c.Handle(ctx)
+			c.Close()
+		}()
+	}
+}
+
+// Shutdown gracefully shuts down the server.
+// It stops accepting conns, disconnects every open conn
+// with the shutdown message and waits for their handlers to return.
+// Writing the disconnect packets is given until ctx's deadline, if any.
+// If ctx is done first, the remaining conns are closed
+// and its error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mtx.Lock()
+	s.shuttingDown = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	s.mtx.Unlock()
+
+	deadline, hasDeadline := ctx.Deadline()
+	var disconnects sync.WaitGroup
+	for _, c := range s.opts.Conns.Conns() {
+		disconnects.Go(func() {
+			if hasDeadline {
+				c.conn.SetWriteDeadline(deadline)
+			}
+			c.Disconnect(s.shutdownMessage)
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		disconnects.Wait()
+		s.cancel()
+		s.handlers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		// Unblock any disconnects still writing.
+		for _, c := range s.opts.Conns.Conns() {
+			c.Close()
+		}
+		return fmt.Errorf("failed to wait for conns to close: %w", ctx.Err())
+	}
+}
+
+// trackListener adds l to the listeners closed on shutdown.
+// It returns false if the server is already shutting down.
+func (s *Server) trackListener(l net.Listener) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.shuttingDown {
+		return false
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+// addHandler adds a conn handler to wait for on shutdown.
+// It returns false if the server is already shutting down.
+func (s *Server) addHandler() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.shuttingDown {
+		return false
+	}
+	s.handlers.Add(1)
+	return true
+}
+
+func (s *Server) untrackListener(l net.Listener) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.listeners, l)
+}
+
+func (s *Server) isShuttingDown() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.shuttingDown
+}
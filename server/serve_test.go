@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/airforce270/mc-srv/packet/id"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/read"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestServerShutdown(t *testing.T) {
+	t.Parallel()
+
+	shutdownMessage := types.TextComponent{Text: "bye"}
+	tests := []struct {
+		desc string
+		// setup puts the client in the state under test.
+		setup  func(c *testClient) error
+		wantID id.ID
+		// readReason reads the reason of the disconnect packet.
+		readReason func(buf *bytes.Buffer) (types.TextComponent, error)
+	}{
+		{
+			desc: "login",
+			setup: func(c *testClient) error {
+				_, err := c.readLoginSuccess()
+				return err
+			},
+			wantID:     id.LoginDisconnect,
+			readReason: readJSONReason,
+		},
+		{
+			desc: "configuration",
+			setup: func(c *testClient) error {
+				if _, err := c.readLoginSuccess(); err != nil {
+					return err
+				}
+				if err := c.writePacket(id.LoginAcknowledgement); err != nil {
+					return err
+				}
+				return c.readUntil(id.ConfigUpdateTags)
+			},
+			wantID:     id.ConfigDisconnect,
+			readReason: readNBTReason,
+		},
+		{
+			desc: "play",
+			setup: func(c *testClient) error {
+				if _, err := c.readLoginSuccess(); err != nil {
+					return err
+				}
+				return c.joinGame()
+			},
+			wantID:     id.PlayDisconnect,
+			readReason: readNBTReason,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			srv, err := New(
+				WithConfig(testConfig(compression.Disabled, false)),
+				WithLogger(log.New(t.Output(), "", 0)),
+				WithShutdownMessage(shutdownMessage),
+			)
+			if err != nil {
+				t.Fatalf("New() unexpected err: %v", err)
+			}
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %v", err)
+			}
+			serveErr := make(chan error, 1)
+			go func() { serveErr <- srv.Serve(listener) }()
+
+			c := dialTestServer(t, listener.Addr().String())
+			if err := c.startLogin("Notch", uuid.New()); err != nil {
+				t.Fatalf("startLogin() unexpected err: %v", err)
+			}
+			if err := tc.setup(c); err != nil {
+				t.Fatalf("setup() unexpected err: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := srv.Shutdown(ctx); err != nil {
+				t.Fatalf("Shutdown() unexpected err: %v", err)
+			}
+
+			buf, err := c.readUntilPacket(tc.wantID)
+			if err != nil {
+				t.Fatalf("Failed to read disconnect packet: %v", err)
+			}
+			reason, err := tc.readReason(buf)
+			if err != nil {
+				t.Fatalf("Failed to read disconnect reason: %v", err)
+			}
+			if diff := cmp.Diff(shutdownMessage, reason); diff != "" {
+				t.Errorf("Disconnect reason diff (-want +got):\n%s", diff)
+			}
+
+			if err := <-serveErr; !errors.Is(err, ErrServerClosed) {
+				t.Errorf("Serve() err = %v, want %v", err, ErrServerClosed)
+			}
+			if conns := srv.Conns().Conns(); len(conns) != 0 {
+				t.Errorf("Conns() after Shutdown() = %d conns, want 0", len(conns))
+			}
+			if _, _, err := c.readPacket(); err == nil {
+				t.Errorf("readPacket() after disconnect unexpected success")
+			}
+			if _, err := net.Dial("tcp", listener.Addr().String()); err == nil {
+				t.Errorf("Dial() after Shutdown() unexpected success")
+			}
+			if err := srv.Serve(listener); !errors.Is(err, ErrServerClosed) {
+				t.Errorf("Serve() after Shutdown() err = %v, want %v", err, ErrServerClosed)
+			}
+		})
+	}
+}
+
+// TestServerShutdownDuringHandshake shuts down the server
+// while a client is sending its handshake, so run it with -race.
+func TestServerShutdownDuringHandshake(t *testing.T) {
+	t.Parallel()
+
+	srv, err := New(
+		WithConfig(testConfig(compression.Disabled, false)),
+		WithLogger(log.New(t.Output(), "", 0)),
+	)
+	if err != nil {
+		t.Fatalf("New() unexpected err: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(listener) }()
+
+	c := dialTestServer(t, listener.Addr().String())
+	for len(srv.Conns().Conns()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	handshakeErr := make(chan error, 1)
+	go func() { handshakeErr <- c.startLogin("Notch", uuid.New()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() unexpected err: %v", err)
+	}
+	// The handshake may or may not have been written before the conn closed.
+	<-handshakeErr
+
+	if err := <-serveErr; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve() err = %v, want %v", err, ErrServerClosed)
+	}
+	if conns := srv.Conns().Conns(); len(conns) != 0 {
+		t.Errorf("Conns() after Shutdown() = %d conns, want 0", len(conns))
+	}
+}
+
+func readJSONReason(buf *bytes.Buffer) (types.TextComponent, error) {
+	var reason types.TextComponent
+	s, err := read.String(buf)
+	if err != nil {
+		return reason, err
+	}
+	err = json.Unmarshal([]byte(s), &reason)
+	return reason, err
+}
+
+func readNBTReason(buf *bytes.Buffer) (types.TextComponent, error) {
+	var reason types.TextComponent
+	err := nbt.UnmarshalNetwork(buf.Bytes(), &reason)
+	return reason, err
+}
+
+func TestServerHooks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mtx          sync.Mutex
+		joined       []auth.Profile
+		disconnected = make(chan auth.Profile, 1)
+	)
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Hooks: Hooks{
+			OnJoin: func(c *Conn) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				joined = append(joined, c.Profile())
+			},
+			OnDisconnect: func(c *Conn) {
+				disconnected <- c.Profile()
+			},
+		},
+	})
+
+	c := dialTestServer(t, addr)
+	if err := c.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+	if _, err := c.readLoginSuccess(); err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+	if err := c.joinGame(); err != nil {
+		t.Fatalf("joinGame() unexpected err: %v", err)
+	}
+	c.conn.Close()
+
+	var got auth.Profile
+	select {
+	case got = <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnDisconnect wasn't called")
+	}
+
+	want := auth.Profile{ID: offlineUUID("Notch"), Name: "Notch"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("OnDisconnect profile diff (-want +got):\n%s", diff)
+	}
+	mtx.Lock()
+	defer mtx.Unlock()
+	if diff := cmp.Diff([]auth.Profile{want}, joined); diff != "" {
+		t.Errorf("OnJoin profiles diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerOnConnectReject(t *testing.T) {
+	t.Parallel()
+
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Hooks: Hooks{
+			OnConnect: func(c *Conn) error {
+				return errors.New("go away")
+			},
+		},
+	})
+
+	c := dialTestServer(t, addr)
+	c.writePacket(0x00) // may fail if the conn is already closed
+	if _, _, err := c.readPacket(); err == nil {
+		t.Errorf("readPacket() on rejected conn unexpected success")
+	}
+}
@@ -6,27 +6,36 @@ import (
 	"bytes"
 	"context"
 	"crypto/sha1"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net"
-	"net/http"
-	"net/url"
-	"os"
+	"net/netip"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
+	"github.com/airforce270/mc-srv/compression"
 	"github.com/airforce270/mc-srv/crypto"
+	"github.com/airforce270/mc-srv/packet"
 	"github.com/airforce270/mc-srv/packet/config"
 	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/protocol"
 	"github.com/airforce270/mc-srv/packet/readpacket"
 	"github.com/airforce270/mc-srv/packet/slp"
 	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/registry"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/forwarding"
 	"github.com/airforce270/mc-srv/server/keepaliver"
+	"github.com/airforce270/mc-srv/server/serverconfig"
 	"github.com/airforce270/mc-srv/server/serverstate"
+	"github.com/airforce270/mc-srv/server/status"
 	"github.com/google/uuid"
 )
 
@@ -35,52 +44,208 @@ const (
 
 	pingInterval      = 5 * time.Second
 	keepAliveInterval = 5 * time.Second
+
+	simulationDistance = 10
+	worldSeed          = 0
+
+	overworld = "minecraft:overworld"
+	theNether = "minecraft:the_nether"
+	theEnd    = "minecraft:the_end"
+
+	vanillaFeatureFlag = "minecraft:vanilla"
 )
 
-// errEnableEncryption is not an error per se,
-// but indicates to the caller that encryption should be used
-// for all future reads and writes.
-var errEnableEncryption = errors.New("enable encryption")
+// corePacks are the vanilla data packs offered to clients
+// so they can use their own copy of the registry data.
+// 1.21 and 1.21.1 share a protocol version but not a pack version,
+// so both are offered.
+var corePacks = []config.KnownPack{
+	{Namespace: "minecraft", ID: "core", Version: "1.21"},
+	{Namespace: "minecraft", ID: "core", Version: "1.21.1"},
+}
 
-var mojangHasJoinedURL = url.URL{Scheme: "https", Host: "sessionserver.mojang.com", Path: "session/minecraft/hasJoined"}
+// lastEntityID is the most recently allocated entity ID.
+var lastEntityID atomic.Int32
+
+// Options configures a Conn.
+type Options struct {
+	// Config is the server's configuration, e.g. from server.properties.
+	// If Config.OnlineMode is false, players aren't authenticated
+	// and their UUIDs are derived from their usernames.
+	Config serverconfig.Config
+	// Whitelist are the UUIDs of the players who can join
+	// if Config.WhiteList is set. Nobody can join if it's empty.
+	Whitelist []uuid.UUID
+	// Authenticator authenticates players in online mode.
+	// Defaults to auth.Mojang with its default settings.
+	Authenticator auth.Authenticator
+	// VelocitySecret is the secret shared with a Velocity proxy.
+	// If set, players must connect through the proxy using modern forwarding,
+	// and their info is taken from the proxy instead of authenticating them,
+	// regardless of Config.OnlineMode.
+	VelocitySecret []byte
+	// BungeeCord is whether players must connect through a BungeeCord-style
+	// proxy with IP forwarding enabled.
+	// If set, their IP, UUID and properties are taken from the handshake
+	// instead of authenticating them, regardless of Config.OnlineMode.
+	BungeeCord bool
+	// Status provides the status shown in the client's server list.
+	// Defaults to the status package's defaults, with no players online.
+	// Use Config.StatusConfig to show the server's configured MOTD.
+	Status status.Provider
+	// Conns tracks the server's conns, if set.
+	Conns *ConnTracker
+	// Logger is where the conn's logs are written.
+	// Its output and flags are used with a prefix of the client's address.
+	// Defaults to the standard logger.
+	Logger *log.Logger
+	// Hooks are called as the conn progresses.
+	Hooks Hooks
+	// Brand is the server's brand, shown on the client's debug screen.
+	// Defaults to DefaultBrand.
+	Brand string
+	// Channels are the handlers of the plugin channels the server listens on,
+	// which are registered with the client.
+	Channels map[types.Identifier]ChannelHandler
+	// ResourcePacks are offered to the player during configuration,
+	// which waits until the client has applied or rejected each of them.
+	ResourcePacks []ResourcePack
+	// ResourcePackRequiredMessage is the reason players are disconnected with
+	// when they don't apply a forced resource pack.
+	// Defaults to DefaultResourcePackRequiredMessage.
+	ResourcePackRequiredMessage *types.TextComponent
+}
+
+// Hooks are functions called on events in a conn's lifecycle.
+// Nil hooks are skipped.
+// They're called synchronously, so they should return quickly.
+type Hooks struct {
+	// OnConnect is called when a conn is accepted, before it's handled.
+	// If it returns an error, the conn is closed.
+	OnConnect func(c *Conn) error
+	// OnLogin is called once the player is identified,
+	// before login succeeds, e.g. to query the client with Conn.LoginQuery.
+	// Unlike the other hooks, it's called in its own goroutine
+	// and login waits for it to return.
+	// If it returns an error, the player is disconnected.
+	// The error is logged, and the player is shown a generic reason
+	// unless it wraps one from NewDisconnectError.
+	// ctx is done once the conn stops being handled.
+	OnLogin func(ctx context.Context, c *Conn) error
+	// OnJoin is called when a player joins the game.
+	OnJoin func(c *Conn)
+	// OnDisconnect is called once a conn is closed.
+	OnDisconnect func(c *Conn)
+	// OnCookie is called when the client responds to Conn.RequestCookie.
+	// payload is nil if the client doesn't have the cookie.
+	OnCookie func(c *Conn, key types.Identifier, payload []byte)
+}
 
 type Conn struct {
-	state  serverstate.State
+	// state is the serverstate.State of the conn.
+	// It's atomic so the conn can be disconnected from other goroutines.
+	state  atomic.Uint32
 	conn   net.Conn
+	opts   Options
 	logger *log.Logger
 
+	// r is what packets are read from.
+	// It starts as br and gains decryption and decompression
+	// as they are enabled during login.
+	r  io.Reader
 	br *bufio.Reader
-	bw *bufio.Writer
-
-	playerUsername string
-	playerUUID     uuid.UUID
-	sharedSecret   []byte
-	verifyToken    []byte
+	w  *connWriter
+
+	keepAlive *keepaliver.KeepAliver
+	closeOnce sync.Once
+
+	// protocol is the protocol.Version the client sent in its handshake.
+	// It's atomic so the conn can be disconnected from other goroutines.
+	protocol atomic.Int32
+	// transferred is whether the client was transferred
+	// from another server.
+	transferred bool
+	// cookies are the cookies stored on the client,
+	// as last stored or received.
+	cookies    map[types.Identifier][]byte
+	cookiesMtx sync.Mutex // protects cookies
+	// clientBrand is the brand the client announced.
+	clientBrand string
+	// clientChannels are the plugin channels the client registered.
+	clientChannels map[types.Identifier]struct{}
+	pluginMtx      sync.Mutex // protects clientBrand and clientChannels
+	// pendingResourcePacks are the resource packs the client
+	// hasn't finished applying, by UUID.
+	pendingResourcePacks map[uuid.UUID]ResourcePack
+	// resourcePackResults are the last results the client sent
+	// for each resource pack, by UUID.
+	resourcePackResults map[uuid.UUID]config.ResourcePackResult
+	resourcePacksMtx    sync.Mutex // protects pendingResourcePacks and resourcePackResults
+
+	entityID   int32
+	clientInfo config.ConfigClientInformation
+	// hasClientInfo is whether the client has sent its information.
+	hasClientInfo bool
+	// profile is the player's profile.
+	// Until the player is authenticated, it only has
+	// the username and UUID the client sent.
+	profile auth.Profile
+	// forwarded is whether a proxy forwarded the player's
+	// IP, UUID and properties in the handshake.
+	forwarded bool
+	// forwardedIP is the IP address of the player
+	// forwarded by a proxy, if any.
+	forwardedIP netip.Addr
+	// velocityMessageID is the message ID of the login plugin request
+	// for Velocity's forwarded player info.
+	velocityMessageID int32
+	// lastMessageID is the last login plugin request message ID used.
+	lastMessageID int32
+	// loginQueries are the responses awaited by LoginQuery, by message ID.
+	loginQueries    map[int32]chan<- login.LoginPluginResponse
+	loginQueriesMtx sync.Mutex // protects lastMessageID and loginQueries
+	sharedSecret    []byte
+	verifyToken     []byte
 }
 
-func NewConn(conn net.Conn) (*Conn, error) {
+func NewConn(conn net.Conn, opts Options) (*Conn, error) {
 	verifyToken := make([]byte, 4)
 	if _, err := crypto.RandReader.Read(verifyToken); err != nil {
 		return nil, fmt.Errorf("failed to generate verify token: %w", err)
 	}
 
-	logger := log.New(os.Stderr, fmt.Sprintf("[%s] ", conn.RemoteAddr().String()), log.Flags()|log.Lmsgprefix)
+	baseLogger := opts.Logger
+	if baseLogger == nil {
+		baseLogger = log.Default()
+	}
+	logger := log.New(baseLogger.Writer(), fmt.Sprintf("%s[%s] ", baseLogger.Prefix(), conn.RemoteAddr().String()), baseLogger.Flags()|log.Lmsgprefix)
+
+	br := newLoggingReader(conn, logger)
 
-	return &Conn{
-		state:       serverstate.PreHandshake,
+	c := &Conn{
 		conn:        conn,
+		opts:        opts,
 		logger:      logger,
-		br:          newLoggingReader(conn, logger),
-		bw:          newLoggingWriter(conn, logger),
+		r:           br,
+		br:          br,
+		w:           newConnWriter(newLoggingWriter(conn, logger)),
+		entityID:    lastEntityID.Add(1),
 		verifyToken: verifyToken,
-	}, nil
+	}
+	if opts.Conns != nil {
+		opts.Conns.add(c)
+	}
+	if opts.Hooks.OnConnect != nil {
+		if err := opts.Hooks.OnConnect(c); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("connect hook rejected conn: %w", err)
+		}
+	}
+	return c, nil
 }
 
-// handleConn handles a new connection.
+// Handle handles the connection until it's closed or ctx is done.
 func (c *Conn) Handle(ctx context.Context) {
-	var r io.Reader = c.br
-	var w io.Writer = c.bw
-
 	for {
 		select {
 		case <-ctx.Done():
@@ -89,71 +254,97 @@ func (c *Conn) Handle(ctx context.Context) {
 		default:
 		}
 
-		err := c.handlePacket(ctx, r, w)
+		err := c.handlePacket(ctx)
 		if err != nil {
+			if reason, ok := disconnectReason(err); ok {
+				c.logger.Printf("Failed to handle packet, disconnecting: %v", err)
+				c.Disconnect(reason)
+				return
+			}
 			if errors.Is(err, net.ErrClosed) || errors.Is(err, crypto.ErrCloseConn) {
 				c.logger.Printf("Failed to handle packet, closing conn: %v", err)
-				return
-			} else if errors.Is(err, errEnableEncryption) {
-				c.logger.Printf("Enabling encryption for read stream...")
-				if cr, err := crypto.NewDecryptReader(c.conn, c.sharedSecret); err == nil {
-					r = newLoggingReader(cr, c.logger)
-					c.logger.Printf("Enabled encryption for read stream.")
-				} else {
-					c.logger.Printf("Failed to enable encryption for read stream: %v", err)
+				if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+					c.logger.Printf("Failed to close conn: %v", err)
 				}
-
-				c.logger.Printf("Enabling encryption for write stream...")
-				if cw, err := crypto.NewEncryptWriter(w, c.sharedSecret); err == nil {
-					w = newLoggingWriter(cw, c.logger)
-					c.logger.Printf("Enabled encryption for write stream.")
-				} else {
-					c.logger.Printf("Failed to enable encryption for write stream: %v", err)
-				}
-
-			} else {
-				c.logger.Printf("Failed to handle packet: %v", err)
+				return
 			}
-		}
-		if err := c.bw.Flush(); err != nil {
-			c.logger.Printf("Flushing conn write buffer failed: %v", err)
+			c.logger.Printf("Failed to handle packet: %v", err)
 		}
 	}
 }
 
-// Disconnect disconnects the client and closes the conn.
+// Disconnect disconnects the client with the Disconnect packet
+// of its state, if it has one, and closes the conn.
+// It's safe to call from any goroutine.
 func (c *Conn) Disconnect(reason types.TextComponent) {
-	switch {
-	case c.state < serverstate.LoginComplete:
-		break
-	case c.state < serverstate.ConfigurationComplete:
-		p := config.Disconnect{Reason: reason}
-		if err := p.Write(c.bw); err != nil {
-			c.logger.Printf("Disconnecting: failed to write disconnect packet: %v", err)
-			break
-		}
-		if err := c.bw.Flush(); err != nil {
-			c.logger.Printf("Disconnecting: failed to flush writer: %v", err)
-			break
-		}
+	var err error
+	switch protocol.StateOf(c.State()) {
+	case protocol.Handshake, protocol.Status:
+		// There's no disconnect packet while handshaking or in status.
+	case protocol.Login:
+		err = c.writePacket(login.Disconnect{Reason: reason})
+	case protocol.Configuration:
+		err = c.writePacket(config.Disconnect{Reason: reason})
+	default:
+		err = c.writePacket(play.Disconnect{Reason: reason})
+	}
+	if err != nil {
+		c.logger.Printf("Disconnecting: failed to write disconnect packet: %v", err)
+	} else {
+		c.logger.Printf("Disconnected: %s", reason)
 	}
 
-	if err := c.Close(); err != nil {
+	if err := c.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
 		c.logger.Printf("Disconnecting: failed to close conn: %v", err)
 	}
 }
 
+// State returns the state of the conn.
+func (c *Conn) State() serverstate.State {
+	return serverstate.State(c.state.Load())
+}
+
+// Protocol returns the protocol version the client sent in its handshake,
+// or 0 if it hasn't sent one yet.
+func (c *Conn) Protocol() protocol.Version {
+	return protocol.Version(c.protocol.Load())
+}
+
+func (c *Conn) setState(state serverstate.State) {
+	c.state.Store(uint32(state))
+}
+
 // Close closes the conn.
+// Closing an already closed conn returns net.ErrClosed.
 func (c *Conn) Close() error {
-	return c.conn.Close()
+	err := net.ErrClosed
+	c.closeOnce.Do(func() {
+		if c.opts.Conns != nil {
+			c.opts.Conns.remove(c)
+		}
+		err = c.conn.Close()
+		if c.opts.Hooks.OnDisconnect != nil {
+			c.opts.Hooks.OnDisconnect(c)
+		}
+	})
+	return err
 }
 
-func (c *Conn) handlePacket(ctx context.Context, r io.Reader, w io.Writer) error {
-	p, err := readpacket.Read(r, c.state, c.logger)
+func (c *Conn) handlePacket(ctx context.Context) error {
+	w := c.w
+
+	if c.State() == serverstate.PreHandshake && slp.IsLegacyPing(c.br) {
+		return c.handleLegacyPing()
+	}
+
+	p, err := readpacket.Read(c.r, c.Protocol(), c.State(), c.logger)
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			return fmt.Errorf("got EOF, closing: %w %w", err, crypto.ErrCloseConn)
 		}
+		if errors.Is(err, readpacket.ErrFraming) {
+			return fmt.Errorf("failed to read packet, closing: %w %w", err, crypto.ErrCloseConn)
+		}
 		return fmt.Errorf("failed to read packet: %w", err)
 	}
 	if p == nil {
@@ -162,157 +353,617 @@ func (c *Conn) handlePacket(ctx context.Context, r io.Reader, w io.Writer) error
 	c.logger.Printf("Received %T%+v", p, p)
 
 	switch pp := p.(type) {
+	case packet.UnknownPacket:
+		c.logger.Printf("Ignoring unknown packet 0x%02x in state %s", int32(pp.PacketID), protocol.StateOf(c.State()))
 	case slp.StatusRequest:
 		// do nothing
 	case slp.Handshake:
+		c.protocol.Store(pp.ProtocolVersion)
 		switch pp.NextState {
 		case slp.HandshakeNextStateStatus:
-			c.state = serverstate.ClientRequestingStatus
-			sr, err := slp.NewStatusResponse(int(pp.ProtocolVersion))
+			c.setState(serverstate.ClientRequestingStatus)
+			// Only claim the client's version if it can actually join.
+			v := c.Protocol()
+			if !protocol.Default.Supports(v) {
+				v = protocol.Latest
+			}
+			sr, err := slp.NewStatusResponse(c.statusProvider().Status(int(v)))
 			if err != nil {
 				return fmt.Errorf("failed to create status response: %w", err)
 			}
-			if err := sr.Write(w); err != nil {
+			if err := c.writePacket(sr); err != nil {
 				return fmt.Errorf("failed to write status response: %w", err)
 			}
 			c.logger.Print("Wrote status response")
-		case slp.HandshakeNextStateLogin:
-			c.state = serverstate.ClientRequestingLogin
+		case slp.HandshakeNextStateLogin, slp.HandshakeNextStateTransfer:
+			c.setState(serverstate.ClientRequestingLogin)
+			if !protocol.Default.Supports(c.Protocol()) {
+				reason := reasonOutdatedClient
+				if c.Protocol() > protocol.Latest {
+					reason = reasonOutdatedServer
+				}
+				return disconnectWith(fmt.Sprintf(reason, protocol.SupportedRange()), fmt.Errorf("client's protocol version %d isn't supported", c.Protocol()))
+			}
+			if pp.NextState == slp.HandshakeNextStateTransfer {
+				if !c.opts.Config.AcceptsTransfers {
+					return disconnectWith(reasonTransfersDisabled, errors.New("client was transferred, but transfers aren't accepted"))
+				}
+				c.transferred = true
+			}
+			if c.opts.BungeeCord {
+				_, player, err := forwarding.ParseBungeeCord(pp.ServerAddress)
+				if err != nil {
+					return fmt.Errorf("failed to parse bungeecord forwarded player info, client must connect through the proxy: %w", disconnectWith(reasonBungeeCordRequired, err))
+				}
+				c.forwarded = true
+				c.forwardedIP = player.Addr
+				c.profile = player.Profile
+			}
 		}
 	case slp.HandshakePingRequest:
-		err := slp.HandshakePingResponse{Payload: pp.Payload}.Write(w)
+		err := c.writePacket(slp.HandshakePingResponse{Payload: pp.Payload})
 		if err != nil {
 			return fmt.Errorf("failed to write ping response: %w  ", err)
 		}
 		c.logger.Print("Wrote ping response")
 	case login.LoginStart:
-		c.playerUsername = pp.PlayerName
-		c.playerUUID = pp.PlayerUUID
+		if err := c.expectState(pp, serverstate.ClientRequestingLogin); err != nil {
+			return err
+		}
+		if c.forwarded {
+			// The proxy already authenticated the player.
+			c.profile.Name = pp.PlayerName
+			if err := c.completeLogin(ctx); err != nil {
+				return fmt.Errorf("failed to complete login: %w", err)
+			}
+			return nil
+		}
+
+		c.profile = auth.Profile{ID: pp.PlayerUUID, Name: pp.PlayerName}
+
+		if len(c.opts.VelocitySecret) > 0 {
+			c.velocityMessageID = c.nextMessageID()
+			pr := login.LoginPluginRequest{
+				MessageID: c.velocityMessageID,
+				Channel:   forwarding.VelocityChannel,
+				Data:      forwarding.VelocityRequestData(),
+			}
+			if err := c.writePacket(pr); err != nil {
+				return fmt.Errorf("failed to write velocity login plugin request: %w", err)
+			}
+			c.logger.Print("Wrote velocity login plugin request")
+			c.setState(serverstate.LoginPluginRequested)
+			return nil
+		}
+
+		if !c.opts.Config.OnlineMode {
+			c.profile.ID = offlineUUID(c.profile.Name)
+			if err := c.completeLogin(ctx); err != nil {
+				return fmt.Errorf("failed to complete login: %w", err)
+			}
+			return nil
+		}
 
 		er := login.EncryptionRequest{
-			ServerID:          serverID,
-			PublicKeyLength:   int32(len(crypto.PublicKeyPKIX)),
-			PublicKey:         crypto.PublicKeyPKIX,
-			VerifyTokenLength: int32(len(c.verifyToken)),
-			VerifyToken:       c.verifyToken,
+			ServerID:           serverID,
+			PublicKey:          crypto.PublicKeyPKIX,
+			VerifyToken:        c.verifyToken,
+			ShouldAuthenticate: true,
 		}
-		if err := er.Write(w); err != nil {
+		if err := c.writePacket(er); err != nil {
 			return fmt.Errorf("failed to write encryption request: %w", err)
 		}
 		c.logger.Print("Wrote encryption request")
-		c.state = serverstate.EncryptionRequested
-	case login.EncryptionResponse:
-		var err error
-		c.sharedSecret, err = crypto.PrivateKey.Decrypt(crypto.RandReader, pp.SharedSecret, crypto.DecryptOpts)
-		if err != nil {
-			return fmt.Errorf("failed to decrypt shared secret: %w", err)
+		c.setState(serverstate.EncryptionRequested)
+	case login.LoginPluginResponse:
+		if c.State() == serverstate.LoginHookRunning {
+			return c.answerLoginQuery(pp)
 		}
-
-		hash := sha1.New()
-		hash.Write(stringToASCII(serverID))
-		hash.Write(c.sharedSecret)
-		hash.Write(crypto.PublicKeyPKIX)
-
-		reqURL := mojangHasJoinedURL
-		reqURL.RawQuery = url.Values{
-			"username": {c.playerUsername},
-			"serverId": {minecraftDigest(hash)},
-		}.Encode()
-		resp, err := http.Get(reqURL.String())
+		if err := c.expectState(pp, serverstate.LoginPluginRequested); err != nil {
+			return err
+		}
+		if pp.MessageID != c.velocityMessageID {
+			return fmt.Errorf("login plugin response has message ID %d, want %d", pp.MessageID, c.velocityMessageID)
+		}
+		if !pp.Successful {
+			return disconnectWith(reasonVelocityRequired, errors.New("client didn't understand velocity login plugin request, it must connect through the proxy"))
+		}
+		player, err := forwarding.ParseVelocity(pp.Data, c.opts.VelocitySecret)
 		if err != nil {
-			return fmt.Errorf("failed to call Mojang Session hasJoined API: %w", err)
+			return fmt.Errorf("failed to parse velocity forwarded player info: %w", disconnectWith(reasonVelocityRequired, err))
 		}
+		c.forwarded = true
+		c.forwardedIP = player.Addr
+		c.profile = player.Profile
 
-		defer resp.Body.Close()
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read body from hasJoined call: %w", err)
+		if err := c.completeLogin(ctx); err != nil {
+			return fmt.Errorf("failed to complete login: %w", err)
 		}
-		var hasJoinedResp HasJoinedResponse
-		if err := json.Unmarshal(body, &hasJoinedResp); err != nil {
-			return fmt.Errorf("failed to unmarshal body from hasJoined call to JSON: %w", nil)
+	case login.EncryptionResponse:
+		if err := c.expectState(pp, serverstate.EncryptionRequested); err != nil {
+			return err
 		}
-
-		newPlayerUUID, err := uuid.Parse(hasJoinedResp.ID)
+		var err error
+		c.sharedSecret, err = crypto.PrivateKey.Decrypt(crypto.RandReader, pp.SharedSecret, crypto.DecryptOpts)
 		if err != nil {
-			return fmt.Errorf("failed to parse player's UUID (%s): %w", hasJoinedResp.ID, err)
+			return fmt.Errorf("failed to decrypt shared secret: %w %w", err, crypto.ErrCloseConn)
 		}
-		if newPlayerUUID.String() != c.playerUUID.String() {
-			return fmt.Errorf("new player UUID %s doesn't match the UUID we saw before: %s", newPlayerUUID, c.playerUUID)
-		}
-		if !strings.EqualFold(hasJoinedResp.Name, c.playerUsername) {
-			return fmt.Errorf("new player username %s doesn't match the name we saw before: %s", hasJoinedResp.Name, c.playerUsername)
+		// The client encrypts everything after the encryption response,
+		// so encryption is enabled before anything can disconnect it.
+		if err := c.enableEncryption(); err != nil {
+			return fmt.Errorf("failed to enable encryption: %w %w", err, crypto.ErrCloseConn)
 		}
 
 		verifyToken, err := crypto.PrivateKey.Decrypt(crypto.RandReader, pp.VerifyToken, crypto.DecryptOpts)
 		if err != nil {
-			return fmt.Errorf("failed to decrypt verify token: %w", err)
+			return fmt.Errorf("failed to decrypt verify token: %w", disconnectWith(reasonEncryptionFailed, err))
 		}
 		if !bytes.Equal(verifyToken, c.verifyToken) {
-			return fmt.Errorf("returned verify token (%x) does not match sent (%x), closing connection", verifyToken, c.verifyToken)
+			return disconnectWith(reasonEncryptionFailed, fmt.Errorf("returned verify token (%x) does not match sent (%x)", verifyToken, c.verifyToken))
 		}
 
-		ls := login.LoginSuccess{
-			UUID:     c.playerUUID,
-			Username: c.playerUsername,
-		}
+		hash := sha1.New()
+		hash.Write(stringToASCII(serverID))
+		hash.Write(c.sharedSecret)
+		hash.Write(crypto.PublicKeyPKIX)
 
-		cw, err := crypto.NewEncryptWriter(w, c.sharedSecret)
+		profile, err := c.authenticator().Authenticate(ctx, c.profile.Name, minecraftDigest(hash), c.RemoteIP())
+		if errors.Is(err, auth.ErrNotAuthenticated) {
+			return fmt.Errorf("failed to authenticate %s: %w", c.profile.Name, disconnectWith(reasonUnverifiedUsername, err))
+		}
 		if err != nil {
-			return fmt.Errorf("failed to create encrypter: %w", err)
+			return fmt.Errorf("failed to authenticate %s: %w", c.profile.Name, disconnectWith(reasonAuthServersDown, err))
 		}
+		if profile.ID != c.profile.ID {
+			return disconnectWith(reasonUnverifiedUsername, fmt.Errorf("authenticated player UUID %s doesn't match the UUID we saw before: %s", profile.ID, c.profile.ID))
+		}
+		if !strings.EqualFold(profile.Name, c.profile.Name) {
+			return disconnectWith(reasonUnverifiedUsername, fmt.Errorf("authenticated player username %s doesn't match the name we saw before: %s", profile.Name, c.profile.Name))
+		}
+		c.profile = profile
 
-		c.logger.Print("Warning: next logged write is the encrypted bytes, not the raw bytes.")
-
-		if err := ls.Write(cw, c.logger); err != nil {
-			return fmt.Errorf("failed to write login success: %w", err)
+		if err := c.completeLogin(ctx); err != nil {
+			return fmt.Errorf("failed to complete login: %w", err)
 		}
-		c.logger.Print("Wrote login success")
-		c.state = serverstate.LoginCompletePendingAcknowledgement
-		return errEnableEncryption
 	case login.LoginAcknowledgement:
-		c.state = serverstate.LoginComplete
-		keepAlive := keepaliver.New(keepAliveInterval, w)
-		go keepAlive.StartPinging(ctx, c.logger)
+		if err := c.expectState(pp, serverstate.LoginCompletePendingAcknowledgement); err != nil {
+			return err
+		}
+		keepAlive := keepaliver.New(keepAliveInterval, w, c.Protocol())
+		c.keepAlive = &keepAlive
+		go c.keepAlive.StartPinging(ctx, c.logger)
 		go func() {
 			select {
 			case <-ctx.Done():
 				return
-			case <-keepAlive.Notifier():
-				c.Disconnect(types.TextComponent{
-					Text: "Timeout - failed to respond to keepalive",
-				})
+			case <-c.keepAlive.Notifier():
+				c.logger.Print("Failed to respond to keepalive, disconnecting")
+				c.Disconnect(types.TextComponent{Text: reasonTimedOut})
 				return
 			}
 		}()
+		if err := c.sendBrand(); err != nil {
+			return fmt.Errorf("failed to send brand: %w", err)
+		}
+		if err := c.sendResourcePacks(); err != nil {
+			return fmt.Errorf("failed to send resource packs: %w", err)
+		}
+		if c.Protocol() < protocol.V1_21 {
+			c.setState(serverstate.LoginComplete)
+			if err := c.sendRegistries(false); err != nil {
+				return fmt.Errorf("failed to send registries: %w", err)
+			}
+			return nil
+		}
+		// Since 1.20.5, the client says which registry data it already has
+		// before it's sent.
+		if err := c.writePacket(config.ClientboundKnownPacks{Packs: corePacks}); err != nil {
+			return fmt.Errorf("failed to write known packs: %w", err)
+		}
+		c.logger.Print("Wrote known packs")
+		c.setState(serverstate.KnownPacksRequested)
+	case config.ServerboundKnownPacks:
+		if err := c.expectState(pp, serverstate.KnownPacksRequested); err != nil {
+			return err
+		}
+		knowsCore := slices.ContainsFunc(pp.Packs, func(p config.KnownPack) bool {
+			return slices.Contains(corePacks, p)
+		})
+		c.setState(serverstate.LoginComplete)
+		if err := c.sendRegistries(knowsCore); err != nil {
+			return fmt.Errorf("failed to send registries: %w", err)
+		}
+		if err := c.maybeFinishConfiguration(); err != nil {
+			return fmt.Errorf("failed to finish configuration: %w", err)
+		}
 	case config.ConfigClientInformation:
+		c.clientInfo = pp
+		c.hasClientInfo = true
+		if err := c.maybeFinishConfiguration(); err != nil {
+			return fmt.Errorf("failed to finish configuration: %w", err)
+		}
+	case config.ConfigResourcePackResponse:
+		if err := c.receiveResourcePackResult(pp.ResourcePackUUID, pp.Result); err != nil {
+			return err
+		}
+		if err := c.maybeFinishConfiguration(); err != nil {
+			return fmt.Errorf("failed to finish configuration: %w", err)
+		}
+	case config.ConfigServerboundPlugin:
+		return c.handlePluginMessage(pp.Channel, pp.Data)
+	case config.ServerboundKeepAlive:
+		c.keepAlive.Receive(pp.KeepAliveID)
 	case config.AcknowledgeFinishConfiguration:
-		c.state = serverstate.ConfigurationComplete
+		if err := c.expectState(pp, serverstate.ConfigurationCompletePendingAcknowledgement); err != nil {
+			return err
+		}
+		// The client is in the play state once it acknowledges,
+		// so keepalives from now on must be play ones,
+		// including any sent before the login (play) packet.
+		c.keepAlive.EnterPlay()
+		c.setState(serverstate.ConfigurationComplete)
+		if err := c.joinGame(); err != nil {
+			return fmt.Errorf("failed to join game: %w", err)
+		}
+	case play.ServerboundPlugin:
+		return c.handlePluginMessage(pp.Channel, pp.Data)
+	case play.ServerboundKeepAlive:
+		c.keepAlive.Receive(pp.KeepAliveID)
+	case login.CookieResponse:
+		return c.receiveCookie(pp.Key, pp.Payload)
+	case config.CookieResponse:
+		return c.receiveCookie(pp.Key, pp.Payload)
+	case play.CookieResponse:
+		return c.receiveCookie(pp.Key, pp.Payload)
 	}
 
 	return nil
 }
 
-// HasJoinedResponse is the response from the /hasJoined Mojang endpoint.
-type HasJoinedResponse struct {
-	// Player's identifier, in the format 11111111222233334444555555555555
-	ID string `json:"id"`
-	// Player's username
-	Name string `json:"name"`
-	// Other properties, normally has one containing the user's skin blob:
-	// {Name: "textures", Value: "base64 string",
-	//  Signature: "base64 string signed using Yggdrasil's private key'"}
-	Properties []HasJoinedResponseProperty `json:"properties"`
-}
-
-// HasJoinedResponseProperty is a property in HasJoinedResponse.
-type HasJoinedResponseProperty struct {
-	// Name of the property.
-	Name string `json:"name"`
-	// Value of the property.
-	Value string `json:"value"`
-	// Signature of the value.
-	Signature string `json:"signature"`
+// writePacket writes the clientbound packet p
+// with its ID and layout in the client's protocol version.
+// Clients on unsupported versions are only sent
+// status and login Disconnect packets, which haven't changed,
+// so they're sent those of the latest version.
+func (c *Conn) writePacket(p any) error {
+	v := c.Protocol()
+	if !protocol.Default.Supports(v) {
+		v = protocol.Latest
+	}
+	return protocol.Default.Write(c.w, v, p)
+}
+
+// expectState returns an error if the conn isn't in the state
+// p is expected in.
+func (c *Conn) expectState(p packet.Packet, want serverstate.State) error {
+	if state := c.State(); state != want {
+		return fmt.Errorf("received %s in state %d, want %d: %w", p.Name(), state, want, errUnexpectedPacket)
+	}
+	return nil
+}
+
+// authenticator returns the authenticator to authenticate the player with.
+func (c *Conn) authenticator() auth.Authenticator {
+	if c.opts.Authenticator == nil {
+		return auth.Mojang{}
+	}
+	return c.opts.Authenticator
+}
+
+// Profile returns the player's profile.
+// It's only complete once login has succeeded.
+func (c *Conn) Profile() auth.Profile {
+	return c.profile
+}
+
+// Forwarded returns whether a proxy forwarded the player's info.
+func (c *Conn) Forwarded() bool {
+	return c.forwarded
+}
+
+// RemoteIP returns the IP address of the client,
+// or the zero value if it isn't an IP connection.
+// If a proxy forwarded the player's IP, that's returned instead.
+func (c *Conn) RemoteIP() netip.Addr {
+	if c.forwardedIP.IsValid() {
+		return c.forwardedIP
+	}
+	addrPort, err := netip.ParseAddrPort(c.conn.RemoteAddr().String())
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addrPort.Addr().Unmap()
+}
+
+// completeLogin checks the whitelist, enables compression,
+// runs the OnLogin hook and tells the client login succeeded.
+func (c *Conn) completeLogin(ctx context.Context) error {
+	// Like the Notchian server, the whitelist is checked
+	// once the player is identified, before compression is enabled.
+	if c.opts.Config.WhiteList && !slices.Contains(c.opts.Whitelist, c.profile.ID) {
+		return disconnectWith(reasonNotWhitelisted, fmt.Errorf("%s (%s) isn't whitelisted", c.profile.Name, c.profile.ID))
+	}
+
+	// Compression is enabled first so packets read
+	// while the hook is running are read with it.
+	if err := c.enableCompression(); err != nil {
+		return fmt.Errorf("failed to enable compression: %w %w", err, crypto.ErrCloseConn)
+	}
+
+	if c.opts.Hooks.OnLogin == nil {
+		return c.writeLoginSuccess()
+	}
+	c.setState(serverstate.LoginHookRunning)
+	go func() {
+		if err := c.opts.Hooks.OnLogin(ctx, c); err != nil {
+			c.logger.Printf("Login hook failed, disconnecting: %v", err)
+			reason, ok := disconnectReason(err)
+			if !ok {
+				reason = types.TextComponent{Text: reasonLoginFailed}
+			}
+			c.Disconnect(reason)
+			return
+		}
+		if err := c.writeLoginSuccess(); err != nil {
+			c.logger.Printf("Failed to complete login, closing conn: %v", err)
+			c.Close()
+		}
+	}()
+	return nil
+}
+
+// writeLoginSuccess tells the client login succeeded.
+func (c *Conn) writeLoginSuccess() error {
+	ls := login.LoginSuccess{
+		UUID:     c.profile.ID,
+		Username: c.profile.Name,
+	}
+	for _, p := range c.profile.Properties {
+		lp := login.LoginSuccessProperty{Name: p.Name, Value: p.Value}
+		if p.Signature != "" {
+			lp.Signature = &p.Signature
+		}
+		ls.Properties = append(ls.Properties, lp)
+	}
+	// The state is set first, since the OnLogin hook's goroutine
+	// may write it while another reads the acknowledgement.
+	c.setState(serverstate.LoginCompletePendingAcknowledgement)
+	if err := c.writePacket(ls); err != nil {
+		return fmt.Errorf("failed to write login success: %w", err)
+	}
+	c.logger.Print("Wrote login success")
+	return nil
+}
+
+// handleLegacyPing responds to a legacy (pre-1.7) server list ping.
+// Legacy clients close the conn after the response,
+// so it's closed on our side too.
+func (c *Conn) handleLegacyPing() error {
+	p, err := slp.ReadLegacyPing(c.br)
+	if err != nil {
+		return fmt.Errorf("failed to read legacy ping: %w %w", err, crypto.ErrCloseConn)
+	}
+	c.logger.Printf("Received %T%+v", p, p)
+
+	// Legacy clients can't join anyway, so the protocol doesn't matter.
+	s := c.statusProvider().Status(0)
+	if err := slp.NewLegacyPingResponse(s).Write(c.w); err != nil {
+		return fmt.Errorf("failed to write legacy ping response: %w %w", err, crypto.ErrCloseConn)
+	}
+	c.logger.Print("Wrote legacy ping response")
+
+	return fmt.Errorf("responded to legacy ping: %w", crypto.ErrCloseConn)
+}
+
+// sendRegistries sends the registry data, feature flags and tags
+// the client needs before it can join the game.
+// If knowsCore, the client has the vanilla registry data,
+// so only the entries' names are sent.
+// Otherwise, every entry's data is sent,
+// and the player is disconnected if any of it isn't bundled.
+func (c *Conn) sendRegistries(knowsCore bool) error {
+	if c.Protocol() < protocol.V1_21 {
+		codec, err := registry.Codec()
+		if err != nil {
+			return fmt.Errorf("failed to load registry codec: %w", err)
+		}
+		if err := c.writePacket(config.RegistryData{RegistryCodec: codec}); err != nil {
+			return fmt.Errorf("failed to write registry data: %w", err)
+		}
+		c.logger.Print("Wrote registry data")
+	} else {
+		regs, err := registry.All(int32(c.Protocol()))
+		if err != nil {
+			return fmt.Errorf("failed to load registries: %w", err)
+		}
+		// The registries are all built before any are written,
+		// so the client isn't sent some of them before being disconnected.
+		var packets []config.Registry
+		for _, r := range regs {
+			p := config.Registry{RegistryID: r.Name}
+			for _, e := range r.Entries {
+				re := config.RegistryEntry{ID: e.Name}
+				if !knowsCore {
+					// Leaving the entry out would leave the client's registry
+					// incomplete, so it can't join without the core pack.
+					if !e.HasData() {
+						return disconnectWith(reasonCorePackRequired, fmt.Errorf("registry %s entry %s has no bundled data", r.Name, e.Name))
+					}
+					data := e.NBT()
+					re.Data = &data
+				}
+				p.Entries = append(p.Entries, re)
+			}
+			packets = append(packets, p)
+		}
+		for _, p := range packets {
+			if err := c.writePacket(p); err != nil {
+				return fmt.Errorf("failed to write registry %s: %w", p.RegistryID, err)
+			}
+			c.logger.Printf("Wrote registry %s", p.RegistryID)
+		}
+	}
+
+	if err := c.writePacket(config.FeatureFlags{Flags: []string{vanillaFeatureFlag}}); err != nil {
+		return fmt.Errorf("failed to write feature flags: %w", err)
+	}
+	c.logger.Print("Wrote feature flags")
+
+	tags, err := registry.Tags(int32(c.Protocol()))
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", err)
+	}
+	if err := c.writePacket(config.UpdateTags{Tags: tags}); err != nil {
+		return fmt.Errorf("failed to write update tags: %w", err)
+	}
+	c.logger.Print("Wrote update tags")
+
+	return nil
+}
+
+// maybeFinishConfiguration finishes configuration once the registries
+// have been sent, the client has sent its information
+// and it has applied or rejected the resource packs.
+func (c *Conn) maybeFinishConfiguration() error {
+	if c.State() != serverstate.LoginComplete || !c.hasClientInfo || c.resourcePacksPending() {
+		return nil
+	}
+	return c.finishConfiguration()
+}
+
+// finishConfiguration tells the client configuration has finished.
+func (c *Conn) finishConfiguration() error {
+	if err := c.writePacket(config.FinishConfiguration{}); err != nil {
+		return fmt.Errorf("failed to write finish configuration: %w", err)
+	}
+	c.logger.Print("Wrote finish configuration")
+	c.setState(serverstate.ConfigurationCompletePendingAcknowledgement)
+	return nil
+}
+
+// joinGame moves the client from configuration into the play state.
+func (c *Conn) joinGame() error {
+	dimensionTypeID, err := registryID(c.Protocol(), "minecraft:dimension_type", overworld)
+	if err != nil {
+		return err
+	}
+	l := play.Login{
+		EntityID:            c.entityID,
+		IsHardcore:          false,
+		DimensionNames:      []string{overworld, theNether, theEnd},
+		MaxPlayers:          int32(c.opts.Config.PlayerLimit()),
+		ViewDistance:        int32(c.opts.Config.ViewDistance),
+		SimulationDistance:  simulationDistance,
+		ReducedDebugInfo:    false,
+		EnableRespawnScreen: true,
+		DoLimitedCrafting:   false,
+		DimensionType:       overworld,
+		DimensionTypeID:     dimensionTypeID,
+		DimensionName:       overworld,
+		HashedSeed:          play.HashSeed(worldSeed),
+		GameMode:            play.GameMode(c.opts.Config.GameMode),
+		PreviousGameMode:    play.GameModeUndefined,
+		IsDebug:             false,
+		IsFlat:              true,
+		DeathLocation:       nil,
+		PortalCooldown:      0,
+		EnforcesSecureChat:  c.opts.Config.EnforceSecureProfile,
+	}
+	if err := c.writePacket(l); err != nil {
+		return fmt.Errorf("failed to write login (play): %w", err)
+	}
+	c.logger.Print("Wrote login (play)")
+
+	d := play.ChangeDifficulty{Difficulty: play.Difficulty(c.opts.Config.Difficulty)}
+	if err := c.writePacket(d); err != nil {
+		return fmt.Errorf("failed to write change difficulty: %w", err)
+	}
+	c.logger.Print("Wrote change difficulty")
+
+	c.setState(serverstate.Play)
+	if c.opts.Conns != nil {
+		c.opts.Conns.play(c, c.profile, c.clientInfo.AllowServerListings)
+	}
+	if c.opts.Hooks.OnJoin != nil {
+		c.opts.Hooks.OnJoin(c)
+	}
+	return nil
+}
+
+// registryID returns the network ID of the named entry
+// in the bundled registry sent to clients on protocol version v.
+func registryID(v protocol.Version, registryName, entryName string) (int32, error) {
+	regs, err := registry.All(int32(v))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load registries: %w", err)
+	}
+	for _, r := range regs {
+		if r.Name != registryName {
+			continue
+		}
+		if id, ok := r.ID(entryName); ok {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("%s has no entry %s", registryName, entryName)
+}
+
+// defaultStatus is the status provider used if none is set.
+var defaultStatus = sync.OnceValue(func() status.Provider {
+	p, err := status.New(status.Config{}, nil)
+	if err != nil {
+		panic(fmt.Sprintf("failed to create default status provider: %v", err))
+	}
+	return p
+})
+
+// statusProvider returns the provider of the status shown in the server list.
+func (c *Conn) statusProvider() status.Provider {
+	if c.opts.Status == nil {
+		return defaultStatus()
+	}
+	return c.opts.Status
+}
+
+// enableEncryption encrypts all future reads and writes
+// with the shared secret.
+func (c *Conn) enableEncryption() error {
+	c.logger.Printf("Enabling encryption...")
+
+	// Wrap br rather than the conn itself so bytes
+	// the client sent after the encryption response,
+	// which may already be buffered, are decrypted too.
+	dr, err := crypto.NewDecryptReader(c.br, c.sharedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to enable encryption for read stream: %w", err)
+	}
+	if err := c.w.enableEncryption(c.sharedSecret); err != nil {
+		return fmt.Errorf("failed to enable encryption for write stream: %w", err)
+	}
+	c.r = dr
+
+	c.logger.Printf("Enabled encryption.")
+	return nil
+}
+
+// enableCompression sends the compression threshold to the client
+// and compresses all future reads and writes,
+// if compression is enabled.
+func (c *Conn) enableCompression() error {
+	threshold := c.opts.Config.NetworkCompressionThreshold
+	if threshold < 0 {
+		return nil
+	}
+
+	sc := login.SetCompression{Threshold: int32(threshold)}
+	if err := c.writePacket(sc); err != nil {
+		return fmt.Errorf("failed to write set compression: %w", err)
+	}
+	c.logger.Printf("Wrote set compression (threshold=%d)", threshold)
+
+	c.w.enableCompression(threshold)
+	c.r = compression.NewReader(c.r)
+	return nil
 }
 
 func newLoggingReader(r io.Reader, logger *log.Logger) *bufio.Reader {
@@ -0,0 +1,79 @@
+package serverconfig
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/airforce270/mc-srv/packet/play"
+)
+
+// GameMode is a play.GameMode that's read and written by name.
+type GameMode play.GameMode
+
+var gameModeNames = []string{
+	play.GameModeSurvival:  "survival",
+	play.GameModeCreative:  "creative",
+	play.GameModeAdventure: "adventure",
+	play.GameModeSpectator: "spectator",
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (g GameMode) MarshalText() ([]byte, error) {
+	if int(g) >= len(gameModeNames) {
+		return nil, fmt.Errorf("unknown game mode %d", g)
+	}
+	return []byte(gameModeNames[g]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// Like the Notchian server, the game mode's ID is also accepted.
+func (g *GameMode) UnmarshalText(b []byte) error {
+	i, err := parseEnum(string(b), gameModeNames)
+	if err != nil {
+		return fmt.Errorf("unknown game mode: %w", err)
+	}
+	*g = GameMode(i)
+	return nil
+}
+
+// Difficulty is a play.Difficulty that's read and written by name.
+type Difficulty play.Difficulty
+
+var difficultyNames = []string{
+	play.DifficultyPeaceful: "peaceful",
+	play.DifficultyEasy:     "easy",
+	play.DifficultyNormal:   "normal",
+	play.DifficultyHard:     "hard",
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Difficulty) MarshalText() ([]byte, error) {
+	if int(d) >= len(difficultyNames) {
+		return nil, fmt.Errorf("unknown difficulty %d", d)
+	}
+	return []byte(difficultyNames[d]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// Like the Notchian server, the difficulty's ID is also accepted.
+func (d *Difficulty) UnmarshalText(b []byte) error {
+	i, err := parseEnum(string(b), difficultyNames)
+	if err != nil {
+		return fmt.Errorf("unknown difficulty: %w", err)
+	}
+	*d = Difficulty(i)
+	return nil
+}
+
+// parseEnum returns the index of s in names, or s itself if it's an index.
+func parseEnum(s string, names []string) (int, error) {
+	for i, name := range names {
+		if s == name {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < len(names) {
+		return i, nil
+	}
+	return 0, fmt.Errorf("%q", s)
+}
@@ -0,0 +1,166 @@
+package serverconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadProperties reads a Java .properties file.
+// https://docs.oracle.com/javase/8/docs/api/java/util/Properties.html#load-java.io.Reader-
+func ReadProperties(r io.Reader) (map[string]string, error) {
+	props := map[string]string{}
+
+	s := bufio.NewScanner(r)
+	var logical strings.Builder
+	for s.Scan() {
+		line := strings.TrimLeft(s.Text(), " \t\f")
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// An odd number of trailing backslashes continues the line.
+		trailing := len(line) - len(strings.TrimRight(line, `\`))
+		if trailing%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+		logical.Reset()
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read properties: %w", err)
+	}
+	if logical.Len() > 0 {
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+	}
+
+	return props, nil
+}
+
+// splitProperty splits a logical line into its unescaped key and value.
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	rawKey, rest := line[:end], line[end:]
+
+	// The separator is any whitespace, optionally around one '=' or ':'.
+	rest = strings.TrimLeft(rest, " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	key, err := unescape(rawKey)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid key %q: %w", rawKey, err)
+	}
+	value, err := unescape(rest)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid value of %s: %w", key, err)
+	}
+	return key, value, nil
+}
+
+// unescape replaces the escape sequences in a key or value.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			break
+		}
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("truncated unicode escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape %q: %w", s[i-1:i+5], err)
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// apply sets the fields of the config from server.properties.
+// Unknown properties are ignored,
+// since vanilla files have many this server doesn't use.
+func (c *Config) apply(props map[string]string) error {
+	for key, value := range props {
+		var err error
+		switch key {
+		case "server-ip":
+			c.ServerIP = value
+		case "server-port":
+			c.ServerPort, err = strconv.Atoi(value)
+		case "motd":
+			c.MOTD = value
+		case "version-name":
+			c.VersionName = value
+		case "max-players":
+			c.MaxPlayers, err = strconv.Atoi(value)
+		case "online-mode":
+			c.OnlineMode, err = strconv.ParseBool(value)
+		case "network-compression-threshold":
+			c.NetworkCompressionThreshold, err = strconv.Atoi(value)
+		case "view-distance":
+			c.ViewDistance, err = strconv.Atoi(value)
+		case "gamemode":
+			err = c.GameMode.UnmarshalText([]byte(value))
+		case "difficulty":
+			err = c.Difficulty.UnmarshalText([]byte(value))
+		case "white-list":
+			c.WhiteList, err = strconv.ParseBool(value)
+		case "enforce-secure-profile":
+			c.EnforceSecureProfile, err = strconv.ParseBool(value)
+		case "accepts-transfers":
+			c.AcceptsTransfers, err = strconv.ParseBool(value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, value, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Package serverconfig loads the server's configuration
+// from a vanilla-compatible server.properties file or a TOML file.
+// https://minecraft.wiki/w/Server.properties
+package serverconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/server/status"
+)
+
+// Config is the server's configuration.
+// Field names in files are the same as in server.properties.
+type Config struct {
+	// IP to listen on. Empty means all interfaces.
+	ServerIP string `toml:"server-ip"`
+	// Port to listen on.
+	ServerPort int `toml:"server-port"`
+	// Message shown in the server list,
+	// as plain text or a JSON text component.
+	MOTD string `toml:"motd"`
+	// Name of the server's version shown in the server list.
+	// Not a vanilla property.
+	VersionName string `toml:"version-name"`
+	// Maximum number of players.
+	// 0 means status.DefaultMaxPlayers.
+	MaxPlayers int `toml:"max-players"`
+	// Whether to authenticate players with Mojang and encrypt connections.
+	OnlineMode bool `toml:"online-mode"`
+	// Minimum size of a packet, in bytes, before it is compressed.
+	// -1 disables compression.
+	NetworkCompressionThreshold int `toml:"network-compression-threshold"`
+	// Maximum view distance, in chunks.
+	ViewDistance int `toml:"view-distance"`
+	// Game mode of players when they join.
+	GameMode GameMode `toml:"gamemode"`
+	// Difficulty of the world.
+	Difficulty Difficulty `toml:"difficulty"`
+	// Whether only whitelisted players can join.
+	// The whitelist itself isn't part of the config,
+	// e.g. see LoadWhitelist.
+	WhiteList bool `toml:"white-list"`
+	// Whether players must have a Mojang-signed public key to chat.
+	EnforceSecureProfile bool `toml:"enforce-secure-profile"`
+	// Whether players can be transferred to this server from others.
+	AcceptsTransfers bool `toml:"accepts-transfers"`
+}
+
+// Default returns the default configuration,
+// the same as the Notchian server's.
+func Default() Config {
+	return Config{
+		ServerIP:                    "",
+		ServerPort:                  25565,
+		MOTD:                        status.DefaultMOTD,
+		VersionName:                 status.DefaultVersionName,
+		MaxPlayers:                  status.DefaultMaxPlayers,
+		OnlineMode:                  true,
+		NetworkCompressionThreshold: 256,
+		ViewDistance:                10,
+		GameMode:                    GameMode(play.GameModeSurvival),
+		Difficulty:                  Difficulty(play.DifficultyEasy),
+		WhiteList:                   false,
+		EnforceSecureProfile:        true,
+		AcceptsTransfers:            false,
+	}
+}
+
+// Load loads the configuration file at name on top of the defaults.
+// Files ending in .toml are read as TOML,
+// and everything else as server.properties.
+func Load(name string) (Config, error) {
+	cfg := Default()
+
+	f, err := os.Open(name)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(name), ".toml") {
+		if _, err := toml.NewDecoder(f).Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+	} else {
+		props, err := ReadProperties(f)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := cfg.apply(props); err != nil {
+			return cfg, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that the values in the config are in range.
+func (c Config) Validate() error {
+	if c.ServerPort < 0 || c.ServerPort > 65535 {
+		return fmt.Errorf("server-port %d is out of range", c.ServerPort)
+	}
+	if c.MaxPlayers < 0 {
+		return fmt.Errorf("max-players %d must not be negative", c.MaxPlayers)
+	}
+	if c.ViewDistance < 2 || c.ViewDistance > 32 {
+		return fmt.Errorf("view-distance %d must be between 2 and 32", c.ViewDistance)
+	}
+	return nil
+}
+
+// Description returns the MOTD as a text component.
+// Plain text MOTDs may have legacy formatting codes, e.g. "§aHello".
+func (c Config) Description() (types.TextComponent, error) {
+	if !strings.HasPrefix(strings.TrimSpace(c.MOTD), "{") {
+		return types.ParseLegacy(c.MOTD), nil
+	}
+	var tc types.TextComponent
+	if err := json.Unmarshal([]byte(c.MOTD), &tc); err != nil {
+		return tc, fmt.Errorf("failed to unmarshal motd as a JSON text component: %w", err)
+	}
+	return tc, nil
+}
+
+// PlayerLimit returns the maximum number of players,
+// falling back to status.DefaultMaxPlayers if it's unset.
+func (c Config) PlayerLimit() int {
+	if c.MaxPlayers == 0 {
+		return status.DefaultMaxPlayers
+	}
+	return c.MaxPlayers
+}
+
+// StatusConfig returns the config of the status shown in the server list.
+func (c Config) StatusConfig() (status.Config, error) {
+	motd, err := c.Description()
+	if err != nil {
+		return status.Config{}, err
+	}
+	return status.Config{
+		VersionName:        c.VersionName,
+		MOTD:               &motd,
+		MaxPlayers:         c.PlayerLimit(),
+		EnforcesSecureChat: c.EnforceSecureProfile,
+	}, nil
+}
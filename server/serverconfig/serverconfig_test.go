@@ -0,0 +1,321 @@
+package serverconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/server/serverconfig"
+	"github.com/airforce270/mc-srv/server/status"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadProperties(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc    string
+		input   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			desc: "vanilla",
+			input: "#Minecraft server properties\n" +
+				"#Tue Jan 02 03:04:05 UTC 2024\n" +
+				"enable-jmx-monitoring=false\n" +
+				"server-port=25566\n" +
+				"motd=A Minecraft Server\n" +
+				"server-ip=\n",
+			want: map[string]string{
+				"enable-jmx-monitoring": "false",
+				"server-port":           "25566",
+				"motd":                  "A Minecraft Server",
+				"server-ip":             "",
+			},
+		},
+		{
+			desc: "separators",
+			input: "a = 1\n" +
+				"b:2\n" +
+				"c 3\n" +
+				"  d\t=  4 5\n" +
+				"e\n",
+			want: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4 5", "e": ""},
+		},
+		{
+			desc: "comments",
+			input: "# comment\n" +
+				"! comment\n" +
+				"\n" +
+				"a=#not a comment\n",
+			want: map[string]string{"a": "#not a comment"},
+		},
+		{
+			desc: "escapes",
+			input: `motd=§aHello\tthere\\` + "\n" +
+				`key\=with\:separators=value` + "\n",
+			want: map[string]string{
+				"motd":                "§aHello\tthere\\",
+				"key=with:separators": "value",
+			},
+		},
+		{
+			desc: "continuation",
+			input: "motd=first \\\n" +
+				"    second\n" +
+				"last=line\\",
+			want: map[string]string{"motd": "first second", "last": "line"},
+		},
+		{
+			desc:    "invalid unicode escape",
+			input:   `motd=\uzzzz`,
+			wantErr: true,
+		},
+		{
+			desc:    "truncated unicode escape",
+			input:   `motd=\u00`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := serverconfig.ReadProperties(strings.NewReader(tc.input))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ReadProperties() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ReadProperties() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc     string
+		fileName string
+		input    string
+		want     serverconfig.Config
+		wantErr  bool
+	}{
+		{
+			desc:     "empty properties",
+			fileName: "server.properties",
+			input:    "",
+			want:     serverconfig.Default(),
+		},
+		{
+			desc:     "properties",
+			fileName: "server.properties",
+			input: "server-ip=::1\n" +
+				"server-port=25566\n" +
+				"motd=Hello\n" +
+				"version-name=mc-srv 1.21\n" +
+				"max-players=5\n" +
+				"online-mode=false\n" +
+				"network-compression-threshold=-1\n" +
+				"view-distance=16\n" +
+				"gamemode=creative\n" +
+				"difficulty=3\n" +
+				"white-list=true\n" +
+				"enforce-secure-profile=false\n" +
+				"accepts-transfers=true\n" +
+				"level-name=world\n",
+			want: serverconfig.Config{
+				ServerIP:                    "::1",
+				ServerPort:                  25566,
+				MOTD:                        "Hello",
+				VersionName:                 "mc-srv 1.21",
+				MaxPlayers:                  5,
+				OnlineMode:                  false,
+				NetworkCompressionThreshold: -1,
+				ViewDistance:                16,
+				GameMode:                    serverconfig.GameMode(play.GameModeCreative),
+				Difficulty:                  serverconfig.Difficulty(play.DifficultyHard),
+				WhiteList:                   true,
+				EnforceSecureProfile:        false,
+				AcceptsTransfers:            true,
+			},
+		},
+		{
+			desc:     "toml",
+			fileName: "server.toml",
+			input: "server-port = 25566\n" +
+				"motd = \"Hello\"\n" +
+				"online-mode = false\n" +
+				"gamemode = \"spectator\"\n" +
+				"difficulty = \"peaceful\"\n",
+			want: func() serverconfig.Config {
+				cfg := serverconfig.Default()
+				cfg.ServerPort = 25566
+				cfg.MOTD = "Hello"
+				cfg.OnlineMode = false
+				cfg.GameMode = serverconfig.GameMode(play.GameModeSpectator)
+				cfg.Difficulty = serverconfig.Difficulty(play.DifficultyPeaceful)
+				return cfg
+			}(),
+		},
+		{
+			desc:     "invalid int",
+			fileName: "server.properties",
+			input:    "max-players=lots\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "invalid bool",
+			fileName: "server.properties",
+			input:    "online-mode=maybe\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "unknown game mode",
+			fileName: "server.properties",
+			input:    "gamemode=hardcore\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "port out of range",
+			fileName: "server.properties",
+			input:    "server-port=65536\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "view distance out of range",
+			fileName: "server.toml",
+			input:    "view-distance = 1\n",
+			wantErr:  true,
+		},
+		{
+			desc:     "invalid toml",
+			fileName: "server.toml",
+			input:    "motd = \n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			name := filepath.Join(t.TempDir(), tc.fileName)
+			if err := os.WriteFile(name, []byte(tc.input), 0o644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			got, err := serverconfig.Load(name)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Load() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Load() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDescription(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc    string
+		motd    string
+		want    types.TextComponent
+		wantErr bool
+	}{
+		{
+			desc: "plain text",
+			motd: "Hello",
+			want: types.TextComponent{Text: "Hello"},
+		},
+		{
+			desc: "legacy formatting codes",
+			motd: "§aHello",
+			want: types.TextComponent{Text: "Hello", Color: types.ColorGreen},
+		},
+		{
+			desc: "json",
+			motd: `{"text":"Hello"}`,
+			want: types.TextComponent{Text: "Hello"},
+		},
+		{
+			desc:    "invalid json",
+			motd:    `{"text":`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := serverconfig.Default()
+			cfg.MOTD = tc.motd
+
+			got, err := cfg.Description()
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Description() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Description() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlayerLimit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc       string
+		maxPlayers int
+		want       int
+	}{
+		{
+			desc:       "set",
+			maxPlayers: 5,
+			want:       5,
+		},
+		{
+			desc:       "unset",
+			maxPlayers: 0,
+			want:       status.DefaultMaxPlayers,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := serverconfig.Default()
+			cfg.MaxPlayers = tc.maxPlayers
+
+			if got := cfg.PlayerLimit(); got != tc.want {
+				t.Errorf("PlayerLimit() = %d, want %d", got, tc.want)
+			}
+			statusCfg, err := cfg.StatusConfig()
+			if err != nil {
+				t.Fatalf("StatusConfig() unexpected error: %v", err)
+			}
+			if statusCfg.MaxPlayers != tc.want {
+				t.Errorf("StatusConfig().MaxPlayers = %d, want %d", statusCfg.MaxPlayers, tc.want)
+			}
+		})
+	}
+}
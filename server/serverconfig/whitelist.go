@@ -0,0 +1,34 @@
+package serverconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWhitelistFile is the name of the Notchian server's whitelist file.
+const DefaultWhitelistFile = "whitelist.json"
+
+// A WhitelistEntry is a player who can join when white-list is enabled.
+type WhitelistEntry struct {
+	UUID uuid.UUID `json:"uuid"`
+	// Name is the player's username when they were added,
+	// kept for readability. Players are matched by UUID.
+	Name string `json:"name"`
+}
+
+// LoadWhitelist loads the players in a whitelist.json file
+// in the Notchian server's format.
+func LoadWhitelist(name string) ([]WhitelistEntry, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	var entries []WhitelistEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,67 @@
+package serverconfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/airforce270/mc-srv/server/serverconfig"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestLoadWhitelist(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc    string
+		input   string
+		want    []serverconfig.WhitelistEntry
+		wantErr bool
+	}{
+		{
+			desc:  "vanilla",
+			input: `[{"uuid":"8996cb86-cb63-4c2d-8b45-7cdfd7b542c8","name":"airfors"}]`,
+			want: []serverconfig.WhitelistEntry{
+				{UUID: uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"), Name: "airfors"},
+			},
+		},
+		{
+			desc:  "empty",
+			input: `[]`,
+			want:  []serverconfig.WhitelistEntry{},
+		},
+		{
+			desc:    "invalid uuid",
+			input:   `[{"uuid":"airfors","name":"airfors"}]`,
+			wantErr: true,
+		},
+		{
+			desc:    "invalid json",
+			input:   `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			name := filepath.Join(t.TempDir(), serverconfig.DefaultWhitelistFile)
+			if err := os.WriteFile(name, []byte(tc.input), 0o644); err != nil {
+				t.Fatalf("Failed to write whitelist: %v", err)
+			}
+
+			got, err := serverconfig.LoadWhitelist(name)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("LoadWhitelist() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("LoadWhitelist() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -7,11 +7,19 @@ const (
 	PreHandshake State = iota
 	ClientRequestingStatus
 	ClientRequestingLogin
+	LoginPluginRequested
 	EncryptionRequested
+	// LoginHookRunning is when the server is waiting for
+	// the OnLogin hook to return before login succeeds.
+	LoginHookRunning
 	LoginSucceededPendingConfirmation
 	LoginSucceeded
 	LoginCompletePendingAcknowledgement
+	// KnownPacksRequested is when the server is waiting for the client's
+	// known packs (1.20.5+), before sending registry data.
+	KnownPacksRequested
 	LoginComplete
 	ConfigurationCompletePendingAcknowledgement
 	ConfigurationComplete
+	Play
 )
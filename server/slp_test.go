@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/slp/slptest"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/server/status"
+	"github.com/google/uuid"
+)
+
+func TestLegacyPing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		input []byte
+	}{
+		{
+			desc:  "beta",
+			input: slptest.LegacyPingBeta,
+		},
+		{
+			desc:  "1.4",
+			input: slptest.LegacyPing14,
+		},
+		{
+			desc:  "1.6",
+			input: slptest.LegacyPing16,
+		},
+	}
+
+	pingStatus := slp.Status{VersionName: "1.20.4", MaxPlayers: 20, Description: types.TextComponent{Text: "hi"}}
+	var want bytes.Buffer
+	if err := slp.NewLegacyPingResponse(pingStatus).Write(&want); err != nil {
+		t.Fatalf("Failed to write expected response: %v", err)
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+				Status: staticStatus(pingStatus),
+			})
+			c := dialTestServer(t, addr)
+
+			if _, err := c.conn.Write(tc.input); err != nil {
+				t.Fatalf("Failed to write legacy ping: %v", err)
+			}
+
+			// The server closes the conn after responding.
+			got, err := io.ReadAll(c.conn)
+			if err != nil {
+				t.Fatalf("Failed to read legacy ping response: %v", err)
+			}
+			if !bytes.Equal(got, want.Bytes()) {
+				t.Errorf("Legacy ping response = %x, want %x", got, want.Bytes())
+			}
+		})
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	conns := NewConnTracker()
+	statusProvider, err := status.New(status.Config{MOTD: &types.TextComponent{Text: "hi"}}, conns)
+	if err != nil {
+		t.Fatalf("status.New() unexpected err: %v", err)
+	}
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Status: statusProvider,
+		Conns:  conns,
+	})
+
+	player := dialTestServer(t, addr)
+	if err := player.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+	if _, err := player.readLoginSuccess(); err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+	if err := player.joinGame(); err != nil {
+		t.Fatalf("joinGame() unexpected err: %v", err)
+	}
+
+	got, err := dialTestServer(t, addr).status()
+	if err != nil {
+		t.Fatalf("status() unexpected err: %v", err)
+	}
+
+	want := `{"version":{"name":"1.20.4-1.21.1","protocol":765},"players":{"max":20,"online":1,"sample":[{"name":"Notch","id":"b50ad385-829d-3141-a216-7e7d7539ba7f"}]},"description":{"text":"hi"},"enforcesSecureChat":false,"previewsChat":false}`
+	if got != want {
+		t.Errorf("status() = %s, want %s", got, want)
+	}
+}
+
+func TestStatusUnlisted(t *testing.T) {
+	t.Parallel()
+
+	conns := NewConnTracker()
+	statusProvider, err := status.New(status.Config{MOTD: &types.TextComponent{Text: "hi"}}, conns)
+	if err != nil {
+		t.Fatalf("status.New() unexpected err: %v", err)
+	}
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Status: statusProvider,
+		Conns:  conns,
+	})
+
+	player := dialTestServer(t, addr)
+	player.unlisted = true
+	if err := player.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+	if _, err := player.readLoginSuccess(); err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+	if err := player.joinGame(); err != nil {
+		t.Fatalf("joinGame() unexpected err: %v", err)
+	}
+
+	got, err := dialTestServer(t, addr).status()
+	if err != nil {
+		t.Fatalf("status() unexpected err: %v", err)
+	}
+
+	want := `{"version":{"name":"1.20.4-1.21.1","protocol":765},"players":{"max":20,"online":1,"sample":[{"name":"Anonymous Player","id":"00000000-0000-0000-0000-000000000000"}]},"description":{"text":"hi"},"enforcesSecureChat":false,"previewsChat":false}`
+	if got != want {
+		t.Errorf("status() = %s, want %s", got, want)
+	}
+}
+
+// staticStatus provides the same status to every client.
+type staticStatus slp.Status
+
+func (s staticStatus) Status(int) slp.Status { return slp.Status(s) }
+
+func TestStatusUnsupportedVersion(t *testing.T) {
+	t.Parallel()
+
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+	})
+	c := dialTestServer(t, addr)
+	c.protocol = 47
+
+	got, err := c.status()
+	if err != nil {
+		t.Fatalf("status() unexpected err: %v", err)
+	}
+
+	// The client's version isn't claimed, so it shows as incompatible.
+	want := `{"version":{"name":"1.20.4-1.21.1","protocol":767},"players":{"max":20,"online":0},"description":{"text":"A Minecraft Server"},"enforcesSecureChat":true,"previewsChat":false}`
+	if got != want {
+		t.Errorf("status() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+// Package status provides the status shown in the client's server list.
+// https://wiki.vg/Server_List_Ping
+package status
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image/png"
+	"math/rand/v2"
+	"os"
+
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/server/auth"
+)
+
+const (
+	// DefaultVersionName is the version name reported by default.
+	DefaultVersionName = "1.20.4-1.21.1"
+	// DefaultMOTD is the MOTD shown by default, the same as the Notchian server.
+	DefaultMOTD = "A Minecraft Server"
+	// DefaultMaxPlayers is the maximum number of players by default,
+	// the same as the Notchian server.
+	DefaultMaxPlayers = 20
+
+	// maxSample is the maximum number of players in the sample,
+	// the same as the Notchian server.
+	maxSample = 12
+	// faviconSize is the required width and height of the favicon, in pixels.
+	faviconSize = 64
+)
+
+// A Provider provides the status shown in the client's server list.
+type Provider interface {
+	// Status returns the current status,
+	// for a client using the given protocol version.
+	Status(protocol int) slp.Status
+}
+
+// AnonymousPlayer is shown in the sample in place of players
+// who don't allow server listings, the same as the Notchian server.
+var AnonymousPlayer = auth.Profile{Name: "Anonymous Player"}
+
+// PlayerLister lists the players currently playing on the server.
+type PlayerLister interface {
+	// ListedPlayers returns the profiles of the players in the play state,
+	// with those who don't allow server listings replaced by AnonymousPlayer.
+	ListedPlayers() []auth.Profile
+}
+
+// Config configures a status.
+type Config struct {
+	// Name of the server's version.
+	// Defaults to DefaultVersionName.
+	VersionName string
+	// MOTD shown in the server list.
+	// Defaults to DefaultMOTD.
+	MOTD *types.TextComponent
+	// Maximum number of players.
+	// Defaults to DefaultMaxPlayers.
+	MaxPlayers int
+	// FaviconFile is the path to a 64x64 PNG shown in the server list.
+	// No icon is shown if it's empty.
+	FaviconFile string
+	// Whether the server enforces secure chat.
+	EnforcesSecureChat bool
+}
+
+// ConfigProvider provides a status from a Config
+// and the players currently online.
+type ConfigProvider struct {
+	versionName        string
+	motd               types.TextComponent
+	maxPlayers         int
+	favicon            string
+	enforcesSecureChat bool
+	players            PlayerLister
+}
+
+// New returns a provider of the status in the config.
+// The favicon is loaded and validated immediately.
+// If players is nil, no players are reported online.
+func New(cfg Config, players PlayerLister) (*ConfigProvider, error) {
+	p := &ConfigProvider{
+		versionName:        cfg.VersionName,
+		motd:               types.TextComponent{Text: DefaultMOTD},
+		maxPlayers:         cfg.MaxPlayers,
+		enforcesSecureChat: cfg.EnforcesSecureChat,
+		players:            players,
+	}
+	if p.versionName == "" {
+		p.versionName = DefaultVersionName
+	}
+	if cfg.MOTD != nil {
+		p.motd = *cfg.MOTD
+	}
+	if p.maxPlayers == 0 {
+		p.maxPlayers = DefaultMaxPlayers
+	}
+
+	if cfg.FaviconFile != "" {
+		b, err := os.ReadFile(cfg.FaviconFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read favicon: %w", err)
+		}
+		p.favicon, err = FaviconDataURI(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid favicon %s: %w", cfg.FaviconFile, err)
+		}
+	}
+
+	return p, nil
+}
+
+// Status returns the current status.
+func (p *ConfigProvider) Status(protocol int) slp.Status {
+	s := slp.Status{
+		VersionName:        p.versionName,
+		Protocol:           protocol,
+		MaxPlayers:         p.maxPlayers,
+		Description:        p.motd,
+		Favicon:            p.favicon,
+		EnforcesSecureChat: p.enforcesSecureChat,
+	}
+	if p.players == nil {
+		return s
+	}
+
+	players := p.players.ListedPlayers()
+	s.OnlinePlayers = len(players)
+	rand.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+	for _, player := range players[:min(len(players), maxSample)] {
+		s.Sample = append(s.Sample, slp.StatusPlayer{Name: player.Name, ID: player.ID})
+	}
+	return s
+}
+
+// FaviconDataURI validates that b is a 64x64 PNG
+// and returns it as a data URI to use as a favicon.
+func FaviconDataURI(b []byte) (string, error) {
+	cfg, err := png.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode PNG: %w", err)
+	}
+	if cfg.Width != faviconSize || cfg.Height != faviconSize {
+		return "", fmt.Errorf("favicon must be %dx%d, but is %dx%d", faviconSize, faviconSize, cfg.Width, cfg.Height)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,174 @@
+package status_test
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/status"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+type fakePlayers []auth.Profile
+
+func (p fakePlayers) ListedPlayers() []auth.Profile { return append([]auth.Profile{}, p...) }
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	airfors := auth.Profile{ID: uuid.MustParse("8996cb86-cb63-4c2d-8b45-7cdfd7b542c8"), Name: "airfors"}
+
+	tests := []struct {
+		desc    string
+		cfg     status.Config
+		players status.PlayerLister
+		want    slp.Status
+	}{
+		{
+			desc: "defaults",
+			want: slp.Status{
+				VersionName: status.DefaultVersionName,
+				Protocol:    765,
+				MaxPlayers:  status.DefaultMaxPlayers,
+				Description: types.TextComponent{Text: status.DefaultMOTD},
+			},
+		},
+		{
+			desc: "configured",
+			cfg: status.Config{
+				VersionName:        "mc-srv",
+				MOTD:               &types.TextComponent{Text: "hello"},
+				MaxPlayers:         5,
+				EnforcesSecureChat: true,
+			},
+			players: fakePlayers{airfors},
+			want: slp.Status{
+				VersionName:        "mc-srv",
+				Protocol:           765,
+				MaxPlayers:         5,
+				OnlinePlayers:      1,
+				Sample:             []slp.StatusPlayer{{Name: "airfors", ID: airfors.ID}},
+				Description:        types.TextComponent{Text: "hello"},
+				EnforcesSecureChat: true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := status.New(tc.cfg, tc.players)
+			if err != nil {
+				t.Fatalf("New() unexpected err: %v", err)
+			}
+
+			got := p.Status(765)
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Status() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusSample(t *testing.T) {
+	t.Parallel()
+
+	var players fakePlayers
+	for range 20 {
+		players = append(players, auth.Profile{ID: uuid.New(), Name: "player"})
+	}
+
+	p, err := status.New(status.Config{}, players)
+	if err != nil {
+		t.Fatalf("New() unexpected err: %v", err)
+	}
+
+	got := p.Status(765)
+	if got.OnlinePlayers != 20 {
+		t.Errorf("Status() OnlinePlayers = %d, want 20", got.OnlinePlayers)
+	}
+	if len(got.Sample) != 12 {
+		t.Errorf("Status() has %d players in sample, want 12", len(got.Sample))
+	}
+}
+
+func TestFavicon(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		file    []byte
+		wantErr bool
+	}{
+		{
+			desc: "64x64",
+			file: encodePNG(t, 64, 64),
+		},
+		{
+			desc:    "wrong size",
+			file:    encodePNG(t, 32, 32),
+			wantErr: true,
+		},
+		{
+			desc:    "not a PNG",
+			file:    []byte("GIF89a"),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			file := filepath.Join(t.TempDir(), "server-icon.png")
+			if err := os.WriteFile(file, tc.file, 0o644); err != nil {
+				t.Fatalf("Failed to write favicon: %v", err)
+			}
+
+			p, err := status.New(status.Config{FaviconFile: file}, nil)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("New() err = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() unexpected err: %v", err)
+			}
+
+			got := p.Status(765).Favicon
+			if !strings.HasPrefix(got, "data:image/png;base64,") {
+				t.Errorf("Status() Favicon = %q, want a PNG data URI", got)
+			}
+		})
+	}
+}
+
+func TestFaviconMissing(t *testing.T) {
+	t.Parallel()
+
+	_, err := status.New(status.Config{FaviconFile: filepath.Join(t.TempDir(), "missing.png")}, nil)
+	if err == nil {
+		t.Errorf("New() err = nil, want an error")
+	}
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
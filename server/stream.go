@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/crypto"
+)
+
+// connWriter is the write side of a Conn.
+//
+// Packets may be written from multiple goroutines (e.g. keepalives),
+// so writes are serialized and each one is flushed to the conn immediately.
+// Encryption and compression are layered on top of the buffered writer
+// as they are enabled during login.
+type connWriter struct {
+	mtx sync.Mutex
+	w   io.Writer     // top of the stream, what packets are written to
+	bw  *bufio.Writer // bottom of the stream, directly above the conn
+}
+
+func newConnWriter(bw *bufio.Writer) *connWriter {
+	return &connWriter{w: bw, bw: bw}
+}
+
+// Write writes a packet to the stream and flushes it to the conn.
+func (cw *connWriter) Write(b []byte) (int, error) {
+	cw.mtx.Lock()
+	defer cw.mtx.Unlock()
+
+	n, err := cw.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	if err := cw.bw.Flush(); err != nil {
+		return n, fmt.Errorf("failed to flush conn write buffer: %w", err)
+	}
+	return n, nil
+}
+
+// enableEncryption encrypts everything written after it returns.
+func (cw *connWriter) enableEncryption(secret []byte) error {
+	cw.mtx.Lock()
+	defer cw.mtx.Unlock()
+
+	ew, err := crypto.NewEncryptWriter(cw.bw, secret)
+	if err != nil {
+		return fmt.Errorf("failed to create encrypter: %w", err)
+	}
+	cw.w = ew
+	return nil
+}
+
+// enableCompression compresses everything written after it returns.
+func (cw *connWriter) enableCompression(threshold int) {
+	cw.mtx.Lock()
+	defer cw.mtx.Unlock()
+
+	cw.w = compression.NewWriter(cw.w, threshold)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/airforce270/mc-srv/server/auth"
+	"github.com/airforce270/mc-srv/server/status"
+)
+
+// ConnTracker tracks the open conns of a server
+// and which of them are playing.
+// It's safe for concurrent use.
+type ConnTracker struct {
+	mtx sync.Mutex
+	// conns are the open conns.
+	conns map[*Conn]struct{}
+	// players are the profiles of the conns in the play state.
+	players map[*Conn]auth.Profile
+	// unlisted are the players who don't allow server listings.
+	unlisted map[*Conn]struct{}
+}
+
+// NewConnTracker returns a new tracker with no conns.
+func NewConnTracker() *ConnTracker {
+	return &ConnTracker{
+		conns:    map[*Conn]struct{}{},
+		players:  map[*Conn]auth.Profile{},
+		unlisted: map[*Conn]struct{}{},
+	}
+}
+
+// Conns returns the open conns.
+func (t *ConnTracker) Conns() []*Conn {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	conns := make([]*Conn, 0, len(t.conns))
+	for c := range t.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// Players returns the profiles of the players in the play state.
+func (t *ConnTracker) Players() []auth.Profile {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	players := make([]auth.Profile, 0, len(t.players))
+	for _, p := range t.players {
+		players = append(players, p)
+	}
+	return players
+}
+
+// ListedPlayers returns the profiles of the players in the play state,
+// with those who don't allow server listings replaced by status.AnonymousPlayer.
+func (t *ConnTracker) ListedPlayers() []auth.Profile {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	players := make([]auth.Profile, 0, len(t.players))
+	for c, p := range t.players {
+		if _, ok := t.unlisted[c]; ok {
+			p = status.AnonymousPlayer
+		}
+		players = append(players, p)
+	}
+	return players
+}
+
+// Player returns the conn of the player in the play state
+// with the given username, ignoring case.
+func (t *ConnTracker) Player(name string) (*Conn, bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for c, p := range t.players {
+		if strings.EqualFold(p.Name, name) {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func (t *ConnTracker) add(c *Conn) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.conns[c] = struct{}{}
+}
+
+func (t *ConnTracker) play(c *Conn, p auth.Profile, listed bool) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.players[c] = p
+	if !listed {
+		t.unlisted[c] = struct{}{}
+	}
+}
+
+func (t *ConnTracker) remove(c *Conn) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.conns, c)
+	delete(t.players, c)
+	delete(t.unlisted, c)
+}
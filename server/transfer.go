@@ -0,0 +1,150 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/airforce270/mc-srv/packet/config"
+	"github.com/airforce270/mc-srv/packet/login"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/types"
+)
+
+// MaxCookieSize is the maximum size of a cookie's payload, in bytes,
+// the same as the Notchian client.
+const MaxCookieSize = 5120
+
+var (
+	// ErrCookieTooLarge is returned when storing a cookie
+	// larger than MaxCookieSize.
+	ErrCookieTooLarge = errors.New("cookie too large")
+	// ErrWrongState is returned when a conn is asked to do something
+	// it can't in its current state,
+	// e.g. transferring a player who's still logging in.
+	ErrWrongState = errors.New("not possible in the conn's state")
+)
+
+// StoreCookie stores a cookie on the client.
+// The client keeps it across transfers, but not reconnects,
+// so it can pass data to the server the player is transferred to.
+// The conn must be in the configuration or play state,
+// and the client must be on 1.20.5 or later.
+func (c *Conn) StoreCookie(key types.Identifier, payload []byte) error {
+	if len(payload) > MaxCookieSize {
+		return fmt.Errorf("cookie %s is %d bytes, at most %d are allowed: %w", key, len(payload), MaxCookieSize, ErrCookieTooLarge)
+	}
+
+	var p any
+	switch protocol.StateOf(c.State()) {
+	case protocol.Configuration:
+		p = config.StoreCookie{Key: key, Payload: payload}
+	case protocol.Play:
+		p = play.StoreCookie{Key: key, Payload: payload}
+	default:
+		return fmt.Errorf("failed to store cookie %s: %w", key, ErrWrongState)
+	}
+	if err := c.writePacket(p); err != nil {
+		return fmt.Errorf("failed to write store cookie: %w", err)
+	}
+	c.setCookie(key, payload)
+	return nil
+}
+
+// RequestCookie asks the client for the cookie stored under key.
+// Once it responds, the cookie is available from Cookie
+// and Hooks.OnCookie is called.
+// The conn must be in the login, configuration or play state,
+// and the client must be on 1.20.5 or later.
+func (c *Conn) RequestCookie(key types.Identifier) error {
+	var p any
+	switch protocol.StateOf(c.State()) {
+	case protocol.Login:
+		p = login.CookieRequest{Key: key}
+	case protocol.Configuration:
+		p = config.CookieRequest{Key: key}
+	case protocol.Play:
+		p = play.CookieRequest{Key: key}
+	default:
+		return fmt.Errorf("failed to request cookie %s: %w", key, ErrWrongState)
+	}
+	if err := c.writePacket(p); err != nil {
+		return fmt.Errorf("failed to write cookie request: %w", err)
+	}
+	return nil
+}
+
+// Cookie returns the cookie stored under key,
+// as last stored on or received from the client,
+// and whether there is one.
+func (c *Conn) Cookie(key types.Identifier) ([]byte, bool) {
+	c.cookiesMtx.Lock()
+	defer c.cookiesMtx.Unlock()
+	payload, ok := c.cookies[key]
+	return payload, ok
+}
+
+// setCookie records the cookie stored under key,
+// or that there isn't one if payload is nil.
+func (c *Conn) setCookie(key types.Identifier, payload []byte) {
+	c.cookiesMtx.Lock()
+	defer c.cookiesMtx.Unlock()
+	if payload == nil {
+		delete(c.cookies, key)
+		return
+	}
+	if c.cookies == nil {
+		c.cookies = map[types.Identifier][]byte{}
+	}
+	c.cookies[key] = payload
+}
+
+// receiveCookie handles a cookie response from the client.
+func (c *Conn) receiveCookie(key types.Identifier, payload *[]byte) error {
+	var b []byte
+	if payload != nil {
+		// The packets' codec rejects payloads over MaxCookieSize.
+		b = *payload
+		if b == nil {
+			b = []byte{}
+		}
+	}
+	c.setCookie(key, b)
+	if c.opts.Hooks.OnCookie != nil {
+		c.opts.Hooks.OnCookie(c, key, b)
+	}
+	return nil
+}
+
+// Transfer tells the client to connect to the server at host:port,
+// which must accept transfers.
+// The client disconnects from this server once it has.
+// Cookies stored with StoreCookie are kept across the transfer.
+// The conn must be in the configuration or play state,
+// and the client must be on 1.20.5 or later.
+func (c *Conn) Transfer(host string, port int) error {
+	if port < 0 || port > 65535 {
+		return fmt.Errorf("port %d is out of range", port)
+	}
+
+	var p any
+	switch protocol.StateOf(c.State()) {
+	case protocol.Configuration:
+		p = config.Transfer{Host: host, Port: int32(port)}
+	case protocol.Play:
+		p = play.Transfer{Host: host, Port: int32(port)}
+	default:
+		return fmt.Errorf("failed to transfer to %s:%d: %w", host, port, ErrWrongState)
+	}
+	if err := c.writePacket(p); err != nil {
+		return fmt.Errorf("failed to write transfer: %w", err)
+	}
+	c.logger.Printf("Transferred to %s:%d", host, port)
+	return nil
+}
+
+// Transferred returns whether the client was transferred
+// from another server.
+func (c *Conn) Transferred() bool {
+	return c.transferred
+}
@@ -0,0 +1,276 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/airforce270/mc-srv/compression"
+	"github.com/airforce270/mc-srv/packet/codec"
+	"github.com/airforce270/mc-srv/packet/play"
+	"github.com/airforce270/mc-srv/packet/protocol"
+	"github.com/airforce270/mc-srv/packet/slp"
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+// join logs in and joins the game as a client on the given protocol version.
+func join(t *testing.T, addr string, v protocol.Version) *testClient {
+	t.Helper()
+
+	c := dialTestServer(t, addr)
+	c.protocol = int32(v)
+	if err := c.startLogin("Notch", uuid.New()); err != nil {
+		t.Fatalf("startLogin() unexpected err: %v", err)
+	}
+	if _, err := c.readLoginSuccess(); err != nil {
+		t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+	}
+	if err := c.joinGame(); err != nil {
+		t.Fatalf("joinGame() unexpected err: %v", err)
+	}
+	return c
+}
+
+func TestStoreCookieAndTransfer(t *testing.T) {
+	t.Parallel()
+
+	const key = types.Identifier("mc-srv:hub")
+	cookie := []byte("lobby=3")
+	hookErrs := make(chan error, 1)
+	addr := startTestServer(t, Options{
+		Config: testConfig(compression.Disabled, false),
+		Hooks: Hooks{
+			OnJoin: func(c *Conn) {
+				// A hub would look the player up to transfer them.
+				if p, ok := c.opts.Conns.Player("notch"); !ok || p != c {
+					hookErrs <- errors.New("Player(notch) isn't the joined conn")
+					return
+				}
+				if err := c.StoreCookie(key, cookie); err != nil {
+					hookErrs <- err
+					return
+				}
+				hookErrs <- c.Transfer("play.example.com", 25566)
+			},
+		},
+	})
+	c := join(t, addr, protocol.V1_21)
+
+	buf, err := c.readUntilPacket(0x6B)
+	if err != nil {
+		t.Fatalf("failed to read store cookie: %v", err)
+	}
+	var sc play.StoreCookie
+	if err := codec.Decode(buf, &sc); err != nil {
+		t.Fatalf("failed to decode store cookie: %v", err)
+	}
+	if diff := cmp.Diff(play.StoreCookie{Key: key, Payload: cookie}, sc); diff != "" {
+		t.Errorf("store cookie diff (-want +got):\n%s", diff)
+	}
+
+	buf, err = c.readUntilPacket(0x73)
+	if err != nil {
+		t.Fatalf("failed to read transfer: %v", err)
+	}
+	var tr play.Transfer
+	if err := codec.Decode(buf, &tr); err != nil {
+		t.Fatalf("failed to decode transfer: %v", err)
+	}
+	if diff := cmp.Diff(play.Transfer{Host: "play.example.com", Port: 25566}, tr); diff != "" {
+		t.Errorf("transfer diff (-want +got):\n%s", diff)
+	}
+
+	if err := <-hookErrs; err != nil {
+		t.Errorf("OnJoin hook unexpected err: %v", err)
+	}
+}
+
+func TestStoreCookieUnsupported(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		version protocol.Version
+		payload []byte
+		wantErr error
+	}{
+		{
+			desc:    "too large",
+			version: protocol.V1_21,
+			payload: make([]byte, MaxCookieSize+1),
+			wantErr: ErrCookieTooLarge,
+		},
+		{
+			desc:    "1.20.4",
+			version: protocol.V1_20_4,
+			payload: []byte("hi"),
+			wantErr: protocol.ErrNotRegistered,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			hookErrs := make(chan error, 1)
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+				Hooks: Hooks{
+					OnJoin: func(c *Conn) {
+						hookErrs <- c.StoreCookie("mc-srv:hub", tc.payload)
+					},
+				},
+			})
+			join(t, addr, tc.version)
+
+			if err := <-hookErrs; !errors.Is(err, tc.wantErr) {
+				t.Errorf("StoreCookie() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequestCookie(t *testing.T) {
+	t.Parallel()
+
+	const key = types.Identifier("mc-srv:hub")
+	tests := []struct {
+		desc   string
+		fields []any
+		want   []byte
+	}{
+		{
+			desc:   "has cookie",
+			fields: []any{string(key), true, int32(2), []byte("hi")},
+			want:   []byte("hi"),
+		},
+		{
+			desc:   "no cookie",
+			fields: []any{string(key), false},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			type response struct {
+				payload []byte
+				stored  []byte
+				ok      bool
+			}
+			responses := make(chan response, 1)
+			addr := startTestServer(t, Options{
+				Config: testConfig(compression.Disabled, false),
+				Hooks: Hooks{
+					OnJoin: func(c *Conn) {
+						if err := c.RequestCookie(key); err != nil {
+							t.Errorf("RequestCookie() unexpected err: %v", err)
+						}
+					},
+					OnCookie: func(c *Conn, gotKey types.Identifier, payload []byte) {
+						stored, ok := c.Cookie(gotKey)
+						responses <- response{payload: payload, stored: stored, ok: ok}
+					},
+				},
+			})
+			c := join(t, addr, protocol.V1_21)
+
+			buf, err := c.readUntilPacket(0x16)
+			if err != nil {
+				t.Fatalf("failed to read cookie request: %v", err)
+			}
+			var req play.CookieRequest
+			if err := codec.Decode(buf, &req); err != nil {
+				t.Fatalf("failed to decode cookie request: %v", err)
+			}
+			if req.Key != key {
+				t.Errorf("cookie request key = %s, want %s", req.Key, key)
+			}
+			if err := c.writePacket(0x11, tc.fields...); err != nil {
+				t.Fatalf("failed to write cookie response: %v", err)
+			}
+
+			var got response
+			select {
+			case got = <-responses:
+			case <-time.After(5 * time.Second):
+				t.Fatal("OnCookie wasn't called")
+			}
+			if diff := cmp.Diff(tc.want, got.payload); diff != "" {
+				t.Errorf("OnCookie payload diff (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want, got.stored, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Cookie() diff (-want +got):\n%s", diff)
+			}
+			if wantOK := tc.want != nil; got.ok != wantOK {
+				t.Errorf("Cookie() ok = %t, want %t", got.ok, wantOK)
+			}
+		})
+	}
+}
+
+func TestLoginTransferred(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc             string
+		acceptsTransfers bool
+		wantReason       string
+	}{
+		{
+			desc:             "accepted",
+			acceptsTransfers: true,
+		},
+		{
+			desc:       "not accepted",
+			wantReason: "Server does not accept transfers",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := testConfig(compression.Disabled, false)
+			cfg.AcceptsTransfers = tc.acceptsTransfers
+			transferred := make(chan bool, 1)
+			addr := startTestServer(t, Options{
+				Config: cfg,
+				Hooks: Hooks{
+					OnJoin: func(c *Conn) { transferred <- c.Transferred() },
+				},
+			})
+			c := dialTestServer(t, addr)
+			c.protocol = int32(protocol.V1_21)
+
+			if err := c.startLoginWith(slp.HandshakeNextStateTransfer, "Notch", uuid.New()); err != nil {
+				t.Fatalf("startLoginWith() unexpected err: %v", err)
+			}
+
+			if tc.wantReason != "" {
+				reason, err := c.readLoginDisconnect()
+				if err != nil {
+					t.Fatalf("readLoginDisconnect() unexpected err: %v", err)
+				}
+				if reason.Text != tc.wantReason {
+					t.Errorf("readLoginDisconnect() reason = %q, want %q", reason.Text, tc.wantReason)
+				}
+				return
+			}
+
+			if _, err := c.readLoginSuccess(); err != nil {
+				t.Fatalf("readLoginSuccess() unexpected err: %v", err)
+			}
+			if err := c.joinGame(); err != nil {
+				t.Fatalf("joinGame() unexpected err: %v", err)
+			}
+			if !<-transferred {
+				t.Errorf("Transferred() = false, want true")
+			}
+		})
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 
+	"github.com/airforce270/mc-srv/nbt"
+	"github.com/airforce270/mc-srv/packet/types"
 	"github.com/google/uuid"
 )
 
@@ -36,6 +39,20 @@ func Byte(w io.Writer, b byte) error {
 	return Bytes(w, []byte{b})
 }
 
+// Short writes a signed short to the given writer.
+func Short(w io.Writer, v int16) error {
+	return UnsignedShort(w, uint16(v))
+}
+
+// UnsignedShort writes an unsigned short to the given writer.
+func UnsignedShort(w io.Writer, v uint16) error {
+	if err := binary.Write(w, binary.BigEndian, v); err != nil {
+		return fmt.Errorf("failed to write short %d: %w", v, err)
+	}
+
+	return nil
+}
+
 // Int writes an int to the given writer.
 func Int(w io.Writer, v int32) error {
 	if err := binary.Write(w, binary.BigEndian, v); err != nil {
@@ -54,6 +71,35 @@ func Long(w io.Writer, v int64) error {
 	return nil
 }
 
+// Float writes a float to the given writer.
+func Float(w io.Writer, v float32) error {
+	if err := Int(w, int32(math.Float32bits(v))); err != nil {
+		return fmt.Errorf("failed to write float %v: %w", v, err)
+	}
+	return nil
+}
+
+// Double writes a double to the given writer.
+func Double(w io.Writer, v float64) error {
+	if err := Long(w, int64(math.Float64bits(v))); err != nil {
+		return fmt.Errorf("failed to write double %v: %w", v, err)
+	}
+	return nil
+}
+
+// Angle writes an angle to the given writer.
+func Angle(w io.Writer, v types.Angle) error {
+	return Byte(w, byte(v))
+}
+
+// Position writes a block position to the given writer.
+func Position(w io.Writer, v types.Position) error {
+	if err := Long(w, v.Pack()); err != nil {
+		return fmt.Errorf("failed to write position %s: %w", v, err)
+	}
+	return nil
+}
+
 // String writes a string to the given writer.
 func String(w io.Writer, s string) error {
 	l := int32(len(s))
@@ -91,6 +137,23 @@ func VarInt(w io.Writer, v int32) error {
 	}
 }
 
+// VarLong writes a int64 to the given writer.
+func VarLong(w io.Writer, v int64) error {
+	for {
+		if (v & ^segmentBits) == 0 {
+			if err := Byte(w, byte(v)); err != nil {
+				return fmt.Errorf("failed to write terminal varlong byte %x: %w", v, err)
+			}
+			return nil
+		}
+		b := byte((v & segmentBits) | continueBit)
+		if err := Byte(w, b); err != nil {
+			return fmt.Errorf("failed to write varlong %d: %w", b, err)
+		}
+		v = int64(uint64(v) >> 7)
+	}
+}
+
 // VarIntLen returns the serialized len of the given varint.
 func VarIntLen(v int32) int {
 	var buf discardingWriter
@@ -101,6 +164,15 @@ func VarIntLen(v int32) int {
 	return buf.Len()
 }
 
+// Identifier validates and writes an identifier to the given writer.
+func Identifier(w io.Writer, v types.Identifier) error {
+	id, err := types.ParseIdentifier(string(v))
+	if err != nil {
+		return fmt.Errorf("failed to validate identifier: %w", err)
+	}
+	return String(w, string(id))
+}
+
 // UUID writes a UUID to the given writer.
 func UUID(w io.Writer, v uuid.UUID) error {
 	b, err := v.MarshalBinary()
@@ -121,3 +193,85 @@ func Bytes(w io.Writer, b []byte) error {
 	}
 	return nil
 }
+
+// ByteArray writes a byte array prefixed with its length as a VarInt.
+func ByteArray(w io.Writer, b []byte) error {
+	if err := VarInt(w, int32(len(b))); err != nil {
+		return fmt.Errorf("failed to write byte array's length (%d): %w", len(b), err)
+	}
+	return Bytes(w, b)
+}
+
+// BitSet writes a bit set prefixed with its length in longs as a VarInt.
+func BitSet(w io.Writer, v types.BitSet) error {
+	if err := Array(w, v, Long); err != nil {
+		return fmt.Errorf("failed to write bit set: %w", err)
+	}
+	return nil
+}
+
+// FixedBitSet writes a bit set of n bits to the given writer.
+func FixedBitSet(w io.Writer, v types.FixedBitSet, n int) error {
+	if l := types.FixedBitSetLen(n); len(v) != l {
+		return fmt.Errorf("fixed bit set of %d bits is %d bytes long, expected %d", n, len(v), l)
+	}
+	return Bytes(w, v)
+}
+
+// Optional writes a value prefixed with a bool saying whether it's present,
+// writing it with write if v isn't nil.
+func Optional[T any](w io.Writer, v *T, write func(io.Writer, T) error) error {
+	if err := Bool(w, v != nil); err != nil {
+		return fmt.Errorf("failed to write whether optional value is present: %w", err)
+	}
+	if v == nil {
+		return nil
+	}
+
+	if err := write(w, *v); err != nil {
+		return fmt.Errorf("failed to write optional value: %w", err)
+	}
+	return nil
+}
+
+// Array writes an array prefixed with its length as a VarInt,
+// writing each element with write.
+func Array[T any](w io.Writer, vals []T, write func(io.Writer, T) error) error {
+	if err := VarInt(w, int32(len(vals))); err != nil {
+		return fmt.Errorf("failed to write array's length (%d): %w", len(vals), err)
+	}
+
+	for i, v := range vals {
+		if err := write(w, v); err != nil {
+			return fmt.Errorf("failed to write array element %d of %d: %w", i, len(vals), err)
+		}
+	}
+	return nil
+}
+
+// Slot writes an item stack to the given writer.
+func Slot(w io.Writer, v types.Slot) error {
+	if err := Bool(w, !v.Empty()); err != nil {
+		return fmt.Errorf("failed to write whether slot is present: %w", err)
+	}
+	if v.Empty() {
+		return nil
+	}
+
+	if err := VarInt(w, v.ItemID); err != nil {
+		return fmt.Errorf("failed to write slot's item ID: %w", err)
+	}
+	if err := Byte(w, byte(v.Count)); err != nil {
+		return fmt.Errorf("failed to write slot's item count: %w", err)
+	}
+	if v.NBT == nil {
+		if err := Byte(w, byte(nbt.TagEnd)); err != nil {
+			return fmt.Errorf("failed to write slot's empty NBT: %w", err)
+		}
+		return nil
+	}
+	if err := nbt.NewNetworkEncoder(w).Encode(v.NBT); err != nil {
+		return fmt.Errorf("failed to write slot's NBT: %w", err)
+	}
+	return nil
+}
@@ -3,8 +3,12 @@ package write_test
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"slices"
 	"testing"
 
+	"github.com/airforce270/mc-srv/packet/types"
+	"github.com/airforce270/mc-srv/read"
 	"github.com/airforce270/mc-srv/write"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
@@ -268,3 +272,536 @@ func TestBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestShort(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input int16
+		want  []byte
+	}{
+		{0, []byte{0x0, 0x0}},
+		{1234, []byte{0x04, 0xd2}},
+		{-1, []byte{0xff, 0xff}},
+		{-32768, []byte{0x80, 0x00}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Short(&buf, tc.input); err != nil {
+				t.Fatalf("Short() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Short() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnsignedShort(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input uint16
+		want  []byte
+	}{
+		{0, []byte{0x0, 0x0}},
+		{1024, []byte{0x4, 0x0}},
+		{25565, []byte{0x63, 0xdd}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.UnsignedShort(&buf, tc.input); err != nil {
+				t.Fatalf("UnsignedShort() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("UnsignedShort() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFloat(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input float32
+		want  []byte
+	}{
+		{0, []byte{0x0, 0x0, 0x0, 0x0}},
+		{1, []byte{0x3f, 0x80, 0x0, 0x0}},
+		{-3.140625, []byte{0xc0, 0x49, 0x0, 0x0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%v->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Float(&buf, tc.input); err != nil {
+				t.Fatalf("Float() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Float() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDouble(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input float64
+		want  []byte
+	}{
+		{0, []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}},
+		{1, []byte{0x3f, 0xf0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0}},
+		{-3.140625, []byte{0xc0, 0x09, 0x20, 0x0, 0x0, 0x0, 0x0, 0x0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%v->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Double(&buf, tc.input); err != nil {
+				t.Fatalf("Double() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Double() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAngle(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input float32
+		want  []byte
+	}{
+		{0, []byte{0x00}},
+		{90, []byte{0x40}},
+		{-90, []byte{0xc0}},
+		{360, []byte{0x00}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%v->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Angle(&buf, types.AngleFromDegrees(tc.input)); err != nil {
+				t.Fatalf("Angle() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Angle() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPosition(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input types.Position
+		want  []byte
+	}{
+		{
+			input: types.Position{},
+			want:  []byte{0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0},
+		},
+		{
+			// Example from https://wiki.vg/Protocol#Position
+			input: types.Position{X: 18357644, Y: 831, Z: -20882616},
+			want:  []byte{0x46, 0x07, 0x63, 0x2c, 0x15, 0xb4, 0x83, 0x3f},
+		},
+		{
+			input: types.Position{X: -1, Y: -1, Z: -1},
+			want:  []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		},
+		{
+			input: types.Position{X: -33554432, Y: -2048, Z: 33554431},
+			want:  []byte{0x80, 0x0, 0x0, 0x1f, 0xff, 0xff, 0xf8, 0x0},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Position(&buf, tc.input); err != nil {
+				t.Fatalf("Position() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Position() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVarLong(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input int64
+		want  []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{255, []byte{0xff, 0x01}},
+		{2147483647, []byte{0xff, 0xff, 0xff, 0xff, 0x07}},
+		{9223372036854775807, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{-2147483648, []byte{0x80, 0x80, 0x80, 0x80, 0xf8, 0xff, 0xff, 0xff, 0xff, 0x01}},
+		{-9223372036854775808, []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.VarLong(&buf, tc.input); err != nil {
+				t.Fatalf("VarLong() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("VarLong() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input   types.Identifier
+		want    []byte
+		wantErr bool
+	}{
+		{
+			input: "minecraft:stone",
+			want:  slices.Concat([]byte{0x0f}, []byte("minecraft:stone")),
+		},
+		{
+			input: "stone",
+			want:  slices.Concat([]byte{0x0f}, []byte("minecraft:stone")),
+		},
+		{
+			input:   "minecraft:Stone",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			err := write.Identifier(&buf, tc.input)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Identifier() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Identifier() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestByteArray(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []byte
+		want  []byte
+	}{
+		{nil, []byte{0x00}},
+		{[]byte{0x11, 0x12, 0x13}, []byte{0x03, 0x11, 0x12, 0x13}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.ByteArray(&buf, tc.input); err != nil {
+				t.Fatalf("ByteArray() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ByteArray() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBitSet(t *testing.T) {
+	t.Parallel()
+
+	var bits types.BitSet
+	bits.Set(0)
+	bits.Set(2)
+	bits.Set(127)
+
+	tests := []struct {
+		input types.BitSet
+		want  []byte
+	}{
+		{nil, []byte{0x00}},
+		{
+			input: bits,
+			want: []byte{
+				0x02, // length
+				0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x05,
+				0x80, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.BitSet(&buf, tc.input); err != nil {
+				t.Fatalf("BitSet() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("BitSet() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFixedBitSet(t *testing.T) {
+	t.Parallel()
+
+	bits := types.NewFixedBitSet(10)
+	bits.Set(0)
+	bits.Set(7)
+	bits.Set(9)
+
+	tests := []struct {
+		input   types.FixedBitSet
+		bits    int
+		want    []byte
+		wantErr bool
+	}{
+		{input: types.NewFixedBitSet(1), bits: 1, want: []byte{0x00}},
+		{input: bits, bits: 10, want: []byte{0x81, 0x02}},
+		{input: bits, bits: 20, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			err := write.FixedBitSet(&buf, tc.input, tc.bits)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("FixedBitSet() err = %v, want err? %t", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("FixedBitSet() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOptional(t *testing.T) {
+	t.Parallel()
+	hi := "hi"
+	tests := []struct {
+		input *string
+		want  []byte
+	}{
+		{nil, []byte{0x00}},
+		{&hi, []byte{0x01, 0x02, 'h', 'i'}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x", tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Optional(&buf, tc.input, write.String); err != nil {
+				t.Fatalf("Optional() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Optional() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestArray(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input []int32
+		want  []byte
+	}{
+		{nil, []byte{0x00}},
+		{[]int32{1, 128, 127}, []byte{0x03, 0x01, 0x80, 0x01, 0x7f}},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d->%x", tc.input, tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Array(&buf, tc.input, write.VarInt); err != nil {
+				t.Fatalf("Array() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Array() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSlot(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		input types.Slot
+		want  []byte
+	}{
+		{
+			input: types.Slot{},
+			want:  []byte{0x00},
+		},
+		{
+			input: types.Slot{ItemID: 128},
+			want:  []byte{0x00},
+		},
+		{
+			input: types.Slot{ItemID: 128, Count: 64},
+			want: []byte{
+				0x01,       // present
+				0x80, 0x01, // item ID
+				0x40, // count
+				0x00, // no NBT
+			},
+		},
+		{
+			input: types.Slot{ItemID: 5, Count: 1, NBT: map[string]any{"Damage": int32(7)}},
+			want: slices.Concat(
+				[]byte{
+					0x01, // present
+					0x05, // item ID
+					0x01, // count
+				},
+				[]byte{0x0a},                   // compound
+				[]byte{0x03, 0x00, 0x06},       // int tag named...
+				[]byte("Damage"),               // ...Damage
+				[]byte{0x00, 0x00, 0x00, 0x07}, // 7
+				[]byte{0x00},                   // end
+			),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%x", tc.want), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := write.Slot(&buf, tc.input); err != nil {
+				t.Fatalf("Slot() unexpected error: %v", err)
+			}
+			got := buf.Bytes()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Slot() diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		desc  string
+		write func(io.Writer) error
+		read  func(io.Reader) (any, error)
+		want  any
+	}{
+		{
+			desc:  "position",
+			write: func(w io.Writer) error { return write.Position(w, types.Position{X: 12, Y: -64, Z: -5000}) },
+			read:  func(r io.Reader) (any, error) { return read.Position(r) },
+			want:  types.Position{X: 12, Y: -64, Z: -5000},
+		},
+		{
+			desc:  "varlong",
+			write: func(w io.Writer) error { return write.VarLong(w, -123456789012) },
+			read:  func(r io.Reader) (any, error) { return read.VarLong(r) },
+			want:  int64(-123456789012),
+		},
+		{
+			desc: "optional array of identifiers",
+			write: func(w io.Writer) error {
+				ids := []types.Identifier{"minecraft:core", "mc-srv:test"}
+				return write.Optional(w, &ids, func(w io.Writer, ids []types.Identifier) error {
+					return write.Array(w, ids, write.Identifier)
+				})
+			},
+			read: func(r io.Reader) (any, error) {
+				return read.Optional(r, func(r io.Reader) ([]types.Identifier, error) {
+					return read.Array(r, read.Identifier)
+				})
+			},
+			want: &[]types.Identifier{"minecraft:core", "mc-srv:test"},
+		},
+		{
+			desc: "slot",
+			write: func(w io.Writer) error {
+				return write.Slot(w, types.Slot{ItemID: 1, Count: 3, NBT: map[string]any{"display": map[string]any{"Name": "x"}}})
+			},
+			read: func(r io.Reader) (any, error) { return read.Slot(r) },
+			want: types.Slot{ItemID: 1, Count: 3, NBT: map[string]any{"display": map[string]any{"Name": "x"}}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+
+			if err := tc.write(&buf); err != nil {
+				t.Fatalf("write unexpected error: %v", err)
+			}
+			got, err := tc.read(&buf)
+			if err != nil {
+				t.Fatalf("read unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("read(write()) diff (-want, +got):\n%s", diff)
+			}
+			if buf.Len() != 0 {
+				t.Errorf("read(write()) left %d bytes unread", buf.Len())
+			}
+		})
+	}
+}